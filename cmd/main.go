@@ -7,14 +7,21 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/cors"
+	"github.com/ryuudan/golang-rest-api/src/constants"
 	"github.com/ryuudan/golang-rest-api/src/database"
 	"github.com/ryuudan/golang-rest-api/src/routes"
 	"github.com/ryuudan/golang-rest-api/src/utils"
+	"github.com/ryuudan/golang-rest-api/src/utils/encryption"
+	"github.com/ryuudan/golang-rest-api/src/utils/mailer"
+	"github.com/ryuudan/golang-rest-api/src/utils/passwords"
+	"github.com/ryuudan/golang-rest-api/src/utils/proxies"
+	"github.com/ryuudan/golang-rest-api/src/workers"
 )
 
 func main() {
@@ -34,20 +41,86 @@ func main() {
 
 	defer pg_client.Close()
 
+	encoder, err := database.EncoderFromEnv()
+	if err != nil {
+		log.Fatalf("Error configuring the cache: %v", err)
+	}
+
+	cache := database.NewRedisCache(redis_client, database.CacheTimeoutsFromEnv(), encoder)
+
+	// Optional in-process cache in front of Redis, e.g. LOCAL_CACHE_SIZE=10000 and LOCAL_CACHE_TTL=30s.
+	// Set it on every instance or none, since only instances with it announce their writes.
+	// Revoked tokens must be rejected by every instance at once, so they are never kept locally.
+	if size, err := strconv.Atoi(os.Getenv("LOCAL_CACHE_SIZE")); err == nil && size > 0 {
+		ttl, err := time.ParseDuration(os.Getenv("LOCAL_CACHE_TTL"))
+		if err != nil {
+			ttl = constants.DEFAULT_LOCAL_CACHE_EXPIRATION
+		}
+		cache.EnableLocalCache(
+			context.Background(),
+			database.NewLocalCache(size, ttl),
+			constants.REVOKED_TOKENS_CACHE_NAMESPACE,
+			constants.REVOKED_USERS_CACHE_NAMESPACE,
+		)
+	}
+
+	// Drop cached users whenever they change, whatever code path changes them
+	pg_client.User.Use(database.InvalidateOnMutation(cache.Namespace(constants.USERS_CACHE_NAMESPACE)))
+	pg_client.User.Use(database.InvalidateOnMutation(cache.Namespace(constants.USER_PLANS_CACHE_NAMESPACE)))
+	pg_client.User.Use(database.InvalidateTagsOnMutation(cache, func(ids []int) []string {
+		return []string{constants.USERS_CACHE_TAG}
+	}))
+
+	mail, err := mailer.FromEnv()
+	if err != nil {
+		log.Fatalf("Error configuring the mailer: %v", err)
+	}
+
+	// Encrypts TOTP secrets at rest
+	cipher, err := encryption.CipherFromEnv("MFA_ENCRYPTION_KEY")
+	if err != nil {
+		log.Fatalf("Error configuring the MFA encryption key: %v", err)
+	}
+
+	hasher, err := passwords.FromEnv()
+	if err != nil {
+		log.Fatalf("Error configuring password hashing: %v", err)
+	}
+
+	rules, err := passwords.RulesFromEnv()
+	if err != nil {
+		log.Fatalf("Error configuring the password rules: %v", err)
+	}
+
+	// Only these may tell the client IP in X-Forwarded-For or X-Real-IP
+	trusted, err := proxies.FromEnv()
+	if err != nil {
+		log.Fatalf("Error configuring the trusted proxies: %v", err)
+	}
+
+	if err := database.SeedRoles(context.Background(), pg_client, hasher); err != nil {
+		log.Fatalf("Error seeding roles and permissions: %v", err)
+	}
+
+	// Deliver queued mail in the background until shutdown
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+
+	go workers.NewOutboxWorker(pg_client, mail).Run(workerCtx)
+
 	// Set up router
 	app := chi.NewRouter()
 	app.Use(middleware.Heartbeat("/ping"))
 	app.Use(middleware.RequestID)
-	app.Use(middleware.RealIP)
+	app.Use(proxies.RealIP(trusted))
 	app.Use(middleware.Logger)
 	app.Use(middleware.Recoverer)
-	app.Use(middleware.Throttle(100))
 
 	cors := cors.New(cors.Options{
 		AllowedOrigins:   []string{"*"},
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "PATCH", "OPTIONS"},
 		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token", "X-Api-Version"},
-		ExposedHeaders:   []string{"Link"},
+		ExposedHeaders:   []string{"Link", "RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "RateLimit-Policy", "Retry-After"},
 		AllowCredentials: true,
 		MaxAge:           300, // Maximum value not ignored by any of major browsers
 	})
@@ -65,9 +138,11 @@ func main() {
 		w.WriteHeader(http.StatusOK)
 	})
 
-	// Initialize public and private routes
-	app.Mount("/api", routes.PrivateRouter(pg_client, redis_client))
-	app.Mount("/public", routes.PublicRouter(pg_client, redis_client))
+	// Initialize public and private routes, sharing the services both of them use
+	shared := routes.NewServices(pg_client, redis_client, cache, mail, cipher, hasher, rules)
+
+	app.Mount("/api", routes.PrivateRouter(pg_client, redis_client, cache, shared, hasher, rules))
+	app.Mount("/public", routes.PublicRouter(redis_client, shared))
 
 	// Start server
 	server := http.Server{
@@ -90,6 +165,7 @@ func main() {
 	<-sigChan
 
 	log.Println("Shutting down server...")
+	stopWorkers()
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -1,3 +1,3 @@
 package ent
 
-//go:generate go run -mod=mod entgo.io/ent/cmd/ent generate ./schema --target ./generated
+//go:generate go run -mod=mod entgo.io/ent/cmd/ent generate --feature intercept,sql/lock ./schema --target ./generated
@@ -12,6 +12,12 @@ import (
 	"entgo.io/ent"
 	"entgo.io/ent/dialect/sql"
 	"entgo.io/ent/dialect/sql/sqlgraph"
+	"github.com/ryuudan/golang-rest-api/ent/generated/apikey"
+	"github.com/ryuudan/golang-rest-api/ent/generated/lockevent"
+	"github.com/ryuudan/golang-rest-api/ent/generated/outboxmessage"
+	"github.com/ryuudan/golang-rest-api/ent/generated/permission"
+	"github.com/ryuudan/golang-rest-api/ent/generated/recoverycode"
+	"github.com/ryuudan/golang-rest-api/ent/generated/role"
 	"github.com/ryuudan/golang-rest-api/ent/generated/user"
 )
 
@@ -73,7 +79,13 @@ var (
 func checkColumn(table, column string) error {
 	initCheck.Do(func() {
 		columnCheck = sql.NewColumnCheck(map[string]func(string) bool{
-			user.Table: user.ValidColumn,
+			apikey.Table:        apikey.ValidColumn,
+			lockevent.Table:     lockevent.ValidColumn,
+			outboxmessage.Table: outboxmessage.ValidColumn,
+			permission.Table:    permission.ValidColumn,
+			recoverycode.Table:  recoverycode.ValidColumn,
+			role.Table:          role.ValidColumn,
+			user.Table:          user.ValidColumn,
 		})
 	})
 	return columnCheck(table, column)
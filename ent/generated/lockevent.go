@@ -0,0 +1,237 @@
+// Code generated by ent, DO NOT EDIT.
+
+package generated
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"entgo.io/ent"
+	"entgo.io/ent/dialect/sql"
+	"github.com/ryuudan/golang-rest-api/ent/generated/lockevent"
+	"github.com/ryuudan/golang-rest-api/ent/generated/user"
+)
+
+// LockEvent is the model entity for the LockEvent schema.
+type LockEvent struct {
+	config `json:"-"`
+	// ID of the ent.
+	ID int `json:"id,omitempty"`
+	// Scope holds the value of the "scope" field.
+	Scope lockevent.Scope `json:"scope"`
+	// Subject holds the value of the "subject" field.
+	Subject string `json:"subject"`
+	// Action holds the value of the "action" field.
+	Action lockevent.Action `json:"action"`
+	// Failures holds the value of the "failures" field.
+	Failures int `json:"failures"`
+	// LockedUntil holds the value of the "locked_until" field.
+	LockedUntil *time.Time `json:"locked_until,omitempty"`
+	// IP holds the value of the "ip" field.
+	IP *string `json:"ip,omitempty"`
+	// UserID holds the value of the "user_id" field.
+	UserID *int `json:"user_id,omitempty"`
+	// ActorID holds the value of the "actor_id" field.
+	ActorID *int `json:"actor_id,omitempty"`
+	// CreatedAt holds the value of the "created_at" field.
+	CreatedAt time.Time `json:"created_at"`
+	// Edges holds the relations/edges for other nodes in the graph.
+	// The values are being populated by the LockEventQuery when eager-loading is set.
+	Edges        LockEventEdges `json:"edges"`
+	selectValues sql.SelectValues
+}
+
+// LockEventEdges holds the relations/edges for other nodes in the graph.
+type LockEventEdges struct {
+	// User holds the value of the user edge.
+	User *User `json:"user,omitempty"`
+	// loadedTypes holds the information for reporting if a
+	// type was loaded (or requested) in eager-loading or not.
+	loadedTypes [1]bool
+}
+
+// UserOrErr returns the User value or an error if the edge
+// was not loaded in eager-loading, or loaded but was not found.
+func (e LockEventEdges) UserOrErr() (*User, error) {
+	if e.loadedTypes[0] {
+		if e.User == nil {
+			// Edge was loaded but was not found.
+			return nil, &NotFoundError{label: user.Label}
+		}
+		return e.User, nil
+	}
+	return nil, &NotLoadedError{edge: "user"}
+}
+
+// scanValues returns the types for scanning values from sql.Rows.
+func (*LockEvent) scanValues(columns []string) ([]any, error) {
+	values := make([]any, len(columns))
+	for i := range columns {
+		switch columns[i] {
+		case lockevent.FieldID, lockevent.FieldFailures, lockevent.FieldUserID, lockevent.FieldActorID:
+			values[i] = new(sql.NullInt64)
+		case lockevent.FieldScope, lockevent.FieldSubject, lockevent.FieldAction, lockevent.FieldIP:
+			values[i] = new(sql.NullString)
+		case lockevent.FieldLockedUntil, lockevent.FieldCreatedAt:
+			values[i] = new(sql.NullTime)
+		default:
+			values[i] = new(sql.UnknownType)
+		}
+	}
+	return values, nil
+}
+
+// assignValues assigns the values that were returned from sql.Rows (after scanning)
+// to the LockEvent fields.
+func (le *LockEvent) assignValues(columns []string, values []any) error {
+	if m, n := len(values), len(columns); m < n {
+		return fmt.Errorf("mismatch number of scan values: %d != %d", m, n)
+	}
+	for i := range columns {
+		switch columns[i] {
+		case lockevent.FieldID:
+			value, ok := values[i].(*sql.NullInt64)
+			if !ok {
+				return fmt.Errorf("unexpected type %T for field id", value)
+			}
+			le.ID = int(value.Int64)
+		case lockevent.FieldScope:
+			if value, ok := values[i].(*sql.NullString); !ok {
+				return fmt.Errorf("unexpected type %T for field scope", values[i])
+			} else if value.Valid {
+				le.Scope = lockevent.Scope(value.String)
+			}
+		case lockevent.FieldSubject:
+			if value, ok := values[i].(*sql.NullString); !ok {
+				return fmt.Errorf("unexpected type %T for field subject", values[i])
+			} else if value.Valid {
+				le.Subject = value.String
+			}
+		case lockevent.FieldAction:
+			if value, ok := values[i].(*sql.NullString); !ok {
+				return fmt.Errorf("unexpected type %T for field action", values[i])
+			} else if value.Valid {
+				le.Action = lockevent.Action(value.String)
+			}
+		case lockevent.FieldFailures:
+			if value, ok := values[i].(*sql.NullInt64); !ok {
+				return fmt.Errorf("unexpected type %T for field failures", values[i])
+			} else if value.Valid {
+				le.Failures = int(value.Int64)
+			}
+		case lockevent.FieldLockedUntil:
+			if value, ok := values[i].(*sql.NullTime); !ok {
+				return fmt.Errorf("unexpected type %T for field locked_until", values[i])
+			} else if value.Valid {
+				le.LockedUntil = new(time.Time)
+				*le.LockedUntil = value.Time
+			}
+		case lockevent.FieldIP:
+			if value, ok := values[i].(*sql.NullString); !ok {
+				return fmt.Errorf("unexpected type %T for field ip", values[i])
+			} else if value.Valid {
+				le.IP = new(string)
+				*le.IP = value.String
+			}
+		case lockevent.FieldUserID:
+			if value, ok := values[i].(*sql.NullInt64); !ok {
+				return fmt.Errorf("unexpected type %T for field user_id", values[i])
+			} else if value.Valid {
+				le.UserID = new(int)
+				*le.UserID = int(value.Int64)
+			}
+		case lockevent.FieldActorID:
+			if value, ok := values[i].(*sql.NullInt64); !ok {
+				return fmt.Errorf("unexpected type %T for field actor_id", values[i])
+			} else if value.Valid {
+				le.ActorID = new(int)
+				*le.ActorID = int(value.Int64)
+			}
+		case lockevent.FieldCreatedAt:
+			if value, ok := values[i].(*sql.NullTime); !ok {
+				return fmt.Errorf("unexpected type %T for field created_at", values[i])
+			} else if value.Valid {
+				le.CreatedAt = value.Time
+			}
+		default:
+			le.selectValues.Set(columns[i], values[i])
+		}
+	}
+	return nil
+}
+
+// Value returns the ent.Value that was dynamically selected and assigned to the LockEvent.
+// This includes values selected through modifiers, order, etc.
+func (le *LockEvent) Value(name string) (ent.Value, error) {
+	return le.selectValues.Get(name)
+}
+
+// QueryUser queries the "user" edge of the LockEvent entity.
+func (le *LockEvent) QueryUser() *UserQuery {
+	return NewLockEventClient(le.config).QueryUser(le)
+}
+
+// Update returns a builder for updating this LockEvent.
+// Note that you need to call LockEvent.Unwrap() before calling this method if this LockEvent
+// was returned from a transaction, and the transaction was committed or rolled back.
+func (le *LockEvent) Update() *LockEventUpdateOne {
+	return NewLockEventClient(le.config).UpdateOne(le)
+}
+
+// Unwrap unwraps the LockEvent entity that was returned from a transaction after it was closed,
+// so that all future queries will be executed through the driver which created the transaction.
+func (le *LockEvent) Unwrap() *LockEvent {
+	_tx, ok := le.config.driver.(*txDriver)
+	if !ok {
+		panic("generated: LockEvent is not a transactional entity")
+	}
+	le.config.driver = _tx.drv
+	return le
+}
+
+// String implements the fmt.Stringer.
+func (le *LockEvent) String() string {
+	var builder strings.Builder
+	builder.WriteString("LockEvent(")
+	builder.WriteString(fmt.Sprintf("id=%v, ", le.ID))
+	builder.WriteString("scope=")
+	builder.WriteString(fmt.Sprintf("%v", le.Scope))
+	builder.WriteString(", ")
+	builder.WriteString("subject=")
+	builder.WriteString(le.Subject)
+	builder.WriteString(", ")
+	builder.WriteString("action=")
+	builder.WriteString(fmt.Sprintf("%v", le.Action))
+	builder.WriteString(", ")
+	builder.WriteString("failures=")
+	builder.WriteString(fmt.Sprintf("%v", le.Failures))
+	builder.WriteString(", ")
+	if v := le.LockedUntil; v != nil {
+		builder.WriteString("locked_until=")
+		builder.WriteString(v.Format(time.ANSIC))
+	}
+	builder.WriteString(", ")
+	if v := le.IP; v != nil {
+		builder.WriteString("ip=")
+		builder.WriteString(*v)
+	}
+	builder.WriteString(", ")
+	if v := le.UserID; v != nil {
+		builder.WriteString("user_id=")
+		builder.WriteString(fmt.Sprintf("%v", *v))
+	}
+	builder.WriteString(", ")
+	if v := le.ActorID; v != nil {
+		builder.WriteString("actor_id=")
+		builder.WriteString(fmt.Sprintf("%v", *v))
+	}
+	builder.WriteString(", ")
+	builder.WriteString("created_at=")
+	builder.WriteString(le.CreatedAt.Format(time.ANSIC))
+	builder.WriteByte(')')
+	return builder.String()
+}
+
+// LockEvents is a parsable slice of LockEvent.
+type LockEvents []*LockEvent
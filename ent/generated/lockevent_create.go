@@ -0,0 +1,372 @@
+// Code generated by ent, DO NOT EDIT.
+
+package generated
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"entgo.io/ent/dialect/sql/sqlgraph"
+	"entgo.io/ent/schema/field"
+	"github.com/ryuudan/golang-rest-api/ent/generated/lockevent"
+	"github.com/ryuudan/golang-rest-api/ent/generated/user"
+)
+
+// LockEventCreate is the builder for creating a LockEvent entity.
+type LockEventCreate struct {
+	config
+	mutation *LockEventMutation
+	hooks    []Hook
+}
+
+// SetScope sets the "scope" field.
+func (lec *LockEventCreate) SetScope(l lockevent.Scope) *LockEventCreate {
+	lec.mutation.SetScope(l)
+	return lec
+}
+
+// SetSubject sets the "subject" field.
+func (lec *LockEventCreate) SetSubject(s string) *LockEventCreate {
+	lec.mutation.SetSubject(s)
+	return lec
+}
+
+// SetAction sets the "action" field.
+func (lec *LockEventCreate) SetAction(l lockevent.Action) *LockEventCreate {
+	lec.mutation.SetAction(l)
+	return lec
+}
+
+// SetFailures sets the "failures" field.
+func (lec *LockEventCreate) SetFailures(i int) *LockEventCreate {
+	lec.mutation.SetFailures(i)
+	return lec
+}
+
+// SetNillableFailures sets the "failures" field if the given value is not nil.
+func (lec *LockEventCreate) SetNillableFailures(i *int) *LockEventCreate {
+	if i != nil {
+		lec.SetFailures(*i)
+	}
+	return lec
+}
+
+// SetLockedUntil sets the "locked_until" field.
+func (lec *LockEventCreate) SetLockedUntil(t time.Time) *LockEventCreate {
+	lec.mutation.SetLockedUntil(t)
+	return lec
+}
+
+// SetNillableLockedUntil sets the "locked_until" field if the given value is not nil.
+func (lec *LockEventCreate) SetNillableLockedUntil(t *time.Time) *LockEventCreate {
+	if t != nil {
+		lec.SetLockedUntil(*t)
+	}
+	return lec
+}
+
+// SetIP sets the "ip" field.
+func (lec *LockEventCreate) SetIP(s string) *LockEventCreate {
+	lec.mutation.SetIP(s)
+	return lec
+}
+
+// SetNillableIP sets the "ip" field if the given value is not nil.
+func (lec *LockEventCreate) SetNillableIP(s *string) *LockEventCreate {
+	if s != nil {
+		lec.SetIP(*s)
+	}
+	return lec
+}
+
+// SetUserID sets the "user_id" field.
+func (lec *LockEventCreate) SetUserID(i int) *LockEventCreate {
+	lec.mutation.SetUserID(i)
+	return lec
+}
+
+// SetNillableUserID sets the "user_id" field if the given value is not nil.
+func (lec *LockEventCreate) SetNillableUserID(i *int) *LockEventCreate {
+	if i != nil {
+		lec.SetUserID(*i)
+	}
+	return lec
+}
+
+// SetActorID sets the "actor_id" field.
+func (lec *LockEventCreate) SetActorID(i int) *LockEventCreate {
+	lec.mutation.SetActorID(i)
+	return lec
+}
+
+// SetNillableActorID sets the "actor_id" field if the given value is not nil.
+func (lec *LockEventCreate) SetNillableActorID(i *int) *LockEventCreate {
+	if i != nil {
+		lec.SetActorID(*i)
+	}
+	return lec
+}
+
+// SetCreatedAt sets the "created_at" field.
+func (lec *LockEventCreate) SetCreatedAt(t time.Time) *LockEventCreate {
+	lec.mutation.SetCreatedAt(t)
+	return lec
+}
+
+// SetNillableCreatedAt sets the "created_at" field if the given value is not nil.
+func (lec *LockEventCreate) SetNillableCreatedAt(t *time.Time) *LockEventCreate {
+	if t != nil {
+		lec.SetCreatedAt(*t)
+	}
+	return lec
+}
+
+// SetUser sets the "user" edge to the User entity.
+func (lec *LockEventCreate) SetUser(u *User) *LockEventCreate {
+	return lec.SetUserID(u.ID)
+}
+
+// Mutation returns the LockEventMutation object of the builder.
+func (lec *LockEventCreate) Mutation() *LockEventMutation {
+	return lec.mutation
+}
+
+// Save creates the LockEvent in the database.
+func (lec *LockEventCreate) Save(ctx context.Context) (*LockEvent, error) {
+	lec.defaults()
+	return withHooks(ctx, lec.sqlSave, lec.mutation, lec.hooks)
+}
+
+// SaveX calls Save and panics if Save returns an error.
+func (lec *LockEventCreate) SaveX(ctx context.Context) *LockEvent {
+	v, err := lec.Save(ctx)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+// Exec executes the query.
+func (lec *LockEventCreate) Exec(ctx context.Context) error {
+	_, err := lec.Save(ctx)
+	return err
+}
+
+// ExecX is like Exec, but panics if an error occurs.
+func (lec *LockEventCreate) ExecX(ctx context.Context) {
+	if err := lec.Exec(ctx); err != nil {
+		panic(err)
+	}
+}
+
+// defaults sets the default values of the builder before save.
+func (lec *LockEventCreate) defaults() {
+	if _, ok := lec.mutation.Failures(); !ok {
+		v := lockevent.DefaultFailures
+		lec.mutation.SetFailures(v)
+	}
+	if _, ok := lec.mutation.CreatedAt(); !ok {
+		v := lockevent.DefaultCreatedAt()
+		lec.mutation.SetCreatedAt(v)
+	}
+}
+
+// check runs all checks and user-defined validators on the builder.
+func (lec *LockEventCreate) check() error {
+	if _, ok := lec.mutation.Scope(); !ok {
+		return &ValidationError{Name: "scope", err: errors.New(`generated: missing required field "LockEvent.scope"`)}
+	}
+	if v, ok := lec.mutation.Scope(); ok {
+		if err := lockevent.ScopeValidator(v); err != nil {
+			return &ValidationError{Name: "scope", err: fmt.Errorf(`generated: validator failed for field "LockEvent.scope": %w`, err)}
+		}
+	}
+	if _, ok := lec.mutation.Subject(); !ok {
+		return &ValidationError{Name: "subject", err: errors.New(`generated: missing required field "LockEvent.subject"`)}
+	}
+	if v, ok := lec.mutation.Subject(); ok {
+		if err := lockevent.SubjectValidator(v); err != nil {
+			return &ValidationError{Name: "subject", err: fmt.Errorf(`generated: validator failed for field "LockEvent.subject": %w`, err)}
+		}
+	}
+	if _, ok := lec.mutation.Action(); !ok {
+		return &ValidationError{Name: "action", err: errors.New(`generated: missing required field "LockEvent.action"`)}
+	}
+	if v, ok := lec.mutation.Action(); ok {
+		if err := lockevent.ActionValidator(v); err != nil {
+			return &ValidationError{Name: "action", err: fmt.Errorf(`generated: validator failed for field "LockEvent.action": %w`, err)}
+		}
+	}
+	if _, ok := lec.mutation.Failures(); !ok {
+		return &ValidationError{Name: "failures", err: errors.New(`generated: missing required field "LockEvent.failures"`)}
+	}
+	if _, ok := lec.mutation.CreatedAt(); !ok {
+		return &ValidationError{Name: "created_at", err: errors.New(`generated: missing required field "LockEvent.created_at"`)}
+	}
+	return nil
+}
+
+func (lec *LockEventCreate) sqlSave(ctx context.Context) (*LockEvent, error) {
+	if err := lec.check(); err != nil {
+		return nil, err
+	}
+	_node, _spec := lec.createSpec()
+	if err := sqlgraph.CreateNode(ctx, lec.driver, _spec); err != nil {
+		if sqlgraph.IsConstraintError(err) {
+			err = &ConstraintError{msg: err.Error(), wrap: err}
+		}
+		return nil, err
+	}
+	id := _spec.ID.Value.(int64)
+	_node.ID = int(id)
+	lec.mutation.id = &_node.ID
+	lec.mutation.done = true
+	return _node, nil
+}
+
+func (lec *LockEventCreate) createSpec() (*LockEvent, *sqlgraph.CreateSpec) {
+	var (
+		_node = &LockEvent{config: lec.config}
+		_spec = sqlgraph.NewCreateSpec(lockevent.Table, sqlgraph.NewFieldSpec(lockevent.FieldID, field.TypeInt))
+	)
+	if value, ok := lec.mutation.Scope(); ok {
+		_spec.SetField(lockevent.FieldScope, field.TypeEnum, value)
+		_node.Scope = value
+	}
+	if value, ok := lec.mutation.Subject(); ok {
+		_spec.SetField(lockevent.FieldSubject, field.TypeString, value)
+		_node.Subject = value
+	}
+	if value, ok := lec.mutation.Action(); ok {
+		_spec.SetField(lockevent.FieldAction, field.TypeEnum, value)
+		_node.Action = value
+	}
+	if value, ok := lec.mutation.Failures(); ok {
+		_spec.SetField(lockevent.FieldFailures, field.TypeInt, value)
+		_node.Failures = value
+	}
+	if value, ok := lec.mutation.LockedUntil(); ok {
+		_spec.SetField(lockevent.FieldLockedUntil, field.TypeTime, value)
+		_node.LockedUntil = &value
+	}
+	if value, ok := lec.mutation.IP(); ok {
+		_spec.SetField(lockevent.FieldIP, field.TypeString, value)
+		_node.IP = &value
+	}
+	if value, ok := lec.mutation.ActorID(); ok {
+		_spec.SetField(lockevent.FieldActorID, field.TypeInt, value)
+		_node.ActorID = &value
+	}
+	if value, ok := lec.mutation.CreatedAt(); ok {
+		_spec.SetField(lockevent.FieldCreatedAt, field.TypeTime, value)
+		_node.CreatedAt = value
+	}
+	if nodes := lec.mutation.UserIDs(); len(nodes) > 0 {
+		edge := &sqlgraph.EdgeSpec{
+			Rel:     sqlgraph.M2O,
+			Inverse: true,
+			Table:   lockevent.UserTable,
+			Columns: []string{lockevent.UserColumn},
+			Bidi:    false,
+			Target: &sqlgraph.EdgeTarget{
+				IDSpec: sqlgraph.NewFieldSpec(user.FieldID, field.TypeInt),
+			},
+		}
+		for _, k := range nodes {
+			edge.Target.Nodes = append(edge.Target.Nodes, k)
+		}
+		_node.UserID = &nodes[0]
+		_spec.Edges = append(_spec.Edges, edge)
+	}
+	return _node, _spec
+}
+
+// LockEventCreateBulk is the builder for creating many LockEvent entities in bulk.
+type LockEventCreateBulk struct {
+	config
+	err      error
+	builders []*LockEventCreate
+}
+
+// Save creates the LockEvent entities in the database.
+func (lecb *LockEventCreateBulk) Save(ctx context.Context) ([]*LockEvent, error) {
+	if lecb.err != nil {
+		return nil, lecb.err
+	}
+	specs := make([]*sqlgraph.CreateSpec, len(lecb.builders))
+	nodes := make([]*LockEvent, len(lecb.builders))
+	mutators := make([]Mutator, len(lecb.builders))
+	for i := range lecb.builders {
+		func(i int, root context.Context) {
+			builder := lecb.builders[i]
+			builder.defaults()
+			var mut Mutator = MutateFunc(func(ctx context.Context, m Mutation) (Value, error) {
+				mutation, ok := m.(*LockEventMutation)
+				if !ok {
+					return nil, fmt.Errorf("unexpected mutation type %T", m)
+				}
+				if err := builder.check(); err != nil {
+					return nil, err
+				}
+				builder.mutation = mutation
+				var err error
+				nodes[i], specs[i] = builder.createSpec()
+				if i < len(mutators)-1 {
+					_, err = mutators[i+1].Mutate(root, lecb.builders[i+1].mutation)
+				} else {
+					spec := &sqlgraph.BatchCreateSpec{Nodes: specs}
+					// Invoke the actual operation on the latest mutation in the chain.
+					if err = sqlgraph.BatchCreate(ctx, lecb.driver, spec); err != nil {
+						if sqlgraph.IsConstraintError(err) {
+							err = &ConstraintError{msg: err.Error(), wrap: err}
+						}
+					}
+				}
+				if err != nil {
+					return nil, err
+				}
+				mutation.id = &nodes[i].ID
+				if specs[i].ID.Value != nil {
+					id := specs[i].ID.Value.(int64)
+					nodes[i].ID = int(id)
+				}
+				mutation.done = true
+				return nodes[i], nil
+			})
+			for i := len(builder.hooks) - 1; i >= 0; i-- {
+				mut = builder.hooks[i](mut)
+			}
+			mutators[i] = mut
+		}(i, ctx)
+	}
+	if len(mutators) > 0 {
+		if _, err := mutators[0].Mutate(ctx, lecb.builders[0].mutation); err != nil {
+			return nil, err
+		}
+	}
+	return nodes, nil
+}
+
+// SaveX is like Save, but panics if an error occurs.
+func (lecb *LockEventCreateBulk) SaveX(ctx context.Context) []*LockEvent {
+	v, err := lecb.Save(ctx)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+// Exec executes the query.
+func (lecb *LockEventCreateBulk) Exec(ctx context.Context) error {
+	_, err := lecb.Save(ctx)
+	return err
+}
+
+// ExecX is like Exec, but panics if an error occurs.
+func (lecb *LockEventCreateBulk) ExecX(ctx context.Context) {
+	if err := lecb.Exec(ctx); err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,88 @@
+// Code generated by ent, DO NOT EDIT.
+
+package generated
+
+import (
+	"context"
+
+	"entgo.io/ent/dialect/sql"
+	"entgo.io/ent/dialect/sql/sqlgraph"
+	"entgo.io/ent/schema/field"
+	"github.com/ryuudan/golang-rest-api/ent/generated/lockevent"
+	"github.com/ryuudan/golang-rest-api/ent/generated/predicate"
+)
+
+// LockEventDelete is the builder for deleting a LockEvent entity.
+type LockEventDelete struct {
+	config
+	hooks    []Hook
+	mutation *LockEventMutation
+}
+
+// Where appends a list predicates to the LockEventDelete builder.
+func (led *LockEventDelete) Where(ps ...predicate.LockEvent) *LockEventDelete {
+	led.mutation.Where(ps...)
+	return led
+}
+
+// Exec executes the deletion query and returns how many vertices were deleted.
+func (led *LockEventDelete) Exec(ctx context.Context) (int, error) {
+	return withHooks(ctx, led.sqlExec, led.mutation, led.hooks)
+}
+
+// ExecX is like Exec, but panics if an error occurs.
+func (led *LockEventDelete) ExecX(ctx context.Context) int {
+	n, err := led.Exec(ctx)
+	if err != nil {
+		panic(err)
+	}
+	return n
+}
+
+func (led *LockEventDelete) sqlExec(ctx context.Context) (int, error) {
+	_spec := sqlgraph.NewDeleteSpec(lockevent.Table, sqlgraph.NewFieldSpec(lockevent.FieldID, field.TypeInt))
+	if ps := led.mutation.predicates; len(ps) > 0 {
+		_spec.Predicate = func(selector *sql.Selector) {
+			for i := range ps {
+				ps[i](selector)
+			}
+		}
+	}
+	affected, err := sqlgraph.DeleteNodes(ctx, led.driver, _spec)
+	if err != nil && sqlgraph.IsConstraintError(err) {
+		err = &ConstraintError{msg: err.Error(), wrap: err}
+	}
+	led.mutation.done = true
+	return affected, err
+}
+
+// LockEventDeleteOne is the builder for deleting a single LockEvent entity.
+type LockEventDeleteOne struct {
+	led *LockEventDelete
+}
+
+// Where appends a list predicates to the LockEventDelete builder.
+func (ledo *LockEventDeleteOne) Where(ps ...predicate.LockEvent) *LockEventDeleteOne {
+	ledo.led.mutation.Where(ps...)
+	return ledo
+}
+
+// Exec executes the deletion query.
+func (ledo *LockEventDeleteOne) Exec(ctx context.Context) error {
+	n, err := ledo.led.Exec(ctx)
+	switch {
+	case err != nil:
+		return err
+	case n == 0:
+		return &NotFoundError{lockevent.Label}
+	default:
+		return nil
+	}
+}
+
+// ExecX is like Exec, but panics if an error occurs.
+func (ledo *LockEventDeleteOne) ExecX(ctx context.Context) {
+	if err := ledo.Exec(ctx); err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,645 @@
+// Code generated by ent, DO NOT EDIT.
+
+package generated
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"entgo.io/ent/dialect"
+	"entgo.io/ent/dialect/sql"
+	"entgo.io/ent/dialect/sql/sqlgraph"
+	"entgo.io/ent/schema/field"
+	"github.com/ryuudan/golang-rest-api/ent/generated/lockevent"
+	"github.com/ryuudan/golang-rest-api/ent/generated/predicate"
+	"github.com/ryuudan/golang-rest-api/ent/generated/user"
+)
+
+// LockEventQuery is the builder for querying LockEvent entities.
+type LockEventQuery struct {
+	config
+	ctx        *QueryContext
+	order      []lockevent.OrderOption
+	inters     []Interceptor
+	predicates []predicate.LockEvent
+	withUser   *UserQuery
+	modifiers  []func(*sql.Selector)
+	// intermediate query (i.e. traversal path).
+	sql  *sql.Selector
+	path func(context.Context) (*sql.Selector, error)
+}
+
+// Where adds a new predicate for the LockEventQuery builder.
+func (leq *LockEventQuery) Where(ps ...predicate.LockEvent) *LockEventQuery {
+	leq.predicates = append(leq.predicates, ps...)
+	return leq
+}
+
+// Limit the number of records to be returned by this query.
+func (leq *LockEventQuery) Limit(limit int) *LockEventQuery {
+	leq.ctx.Limit = &limit
+	return leq
+}
+
+// Offset to start from.
+func (leq *LockEventQuery) Offset(offset int) *LockEventQuery {
+	leq.ctx.Offset = &offset
+	return leq
+}
+
+// Unique configures the query builder to filter duplicate records on query.
+// By default, unique is set to true, and can be disabled using this method.
+func (leq *LockEventQuery) Unique(unique bool) *LockEventQuery {
+	leq.ctx.Unique = &unique
+	return leq
+}
+
+// Order specifies how the records should be ordered.
+func (leq *LockEventQuery) Order(o ...lockevent.OrderOption) *LockEventQuery {
+	leq.order = append(leq.order, o...)
+	return leq
+}
+
+// QueryUser chains the current query on the "user" edge.
+func (leq *LockEventQuery) QueryUser() *UserQuery {
+	query := (&UserClient{config: leq.config}).Query()
+	query.path = func(ctx context.Context) (fromU *sql.Selector, err error) {
+		if err := leq.prepareQuery(ctx); err != nil {
+			return nil, err
+		}
+		selector := leq.sqlQuery(ctx)
+		if err := selector.Err(); err != nil {
+			return nil, err
+		}
+		step := sqlgraph.NewStep(
+			sqlgraph.From(lockevent.Table, lockevent.FieldID, selector),
+			sqlgraph.To(user.Table, user.FieldID),
+			sqlgraph.Edge(sqlgraph.M2O, true, lockevent.UserTable, lockevent.UserColumn),
+		)
+		fromU = sqlgraph.SetNeighbors(leq.driver.Dialect(), step)
+		return fromU, nil
+	}
+	return query
+}
+
+// First returns the first LockEvent entity from the query.
+// Returns a *NotFoundError when no LockEvent was found.
+func (leq *LockEventQuery) First(ctx context.Context) (*LockEvent, error) {
+	nodes, err := leq.Limit(1).All(setContextOp(ctx, leq.ctx, "First"))
+	if err != nil {
+		return nil, err
+	}
+	if len(nodes) == 0 {
+		return nil, &NotFoundError{lockevent.Label}
+	}
+	return nodes[0], nil
+}
+
+// FirstX is like First, but panics if an error occurs.
+func (leq *LockEventQuery) FirstX(ctx context.Context) *LockEvent {
+	node, err := leq.First(ctx)
+	if err != nil && !IsNotFound(err) {
+		panic(err)
+	}
+	return node
+}
+
+// FirstID returns the first LockEvent ID from the query.
+// Returns a *NotFoundError when no LockEvent ID was found.
+func (leq *LockEventQuery) FirstID(ctx context.Context) (id int, err error) {
+	var ids []int
+	if ids, err = leq.Limit(1).IDs(setContextOp(ctx, leq.ctx, "FirstID")); err != nil {
+		return
+	}
+	if len(ids) == 0 {
+		err = &NotFoundError{lockevent.Label}
+		return
+	}
+	return ids[0], nil
+}
+
+// FirstIDX is like FirstID, but panics if an error occurs.
+func (leq *LockEventQuery) FirstIDX(ctx context.Context) int {
+	id, err := leq.FirstID(ctx)
+	if err != nil && !IsNotFound(err) {
+		panic(err)
+	}
+	return id
+}
+
+// Only returns a single LockEvent entity found by the query, ensuring it only returns one.
+// Returns a *NotSingularError when more than one LockEvent entity is found.
+// Returns a *NotFoundError when no LockEvent entities are found.
+func (leq *LockEventQuery) Only(ctx context.Context) (*LockEvent, error) {
+	nodes, err := leq.Limit(2).All(setContextOp(ctx, leq.ctx, "Only"))
+	if err != nil {
+		return nil, err
+	}
+	switch len(nodes) {
+	case 1:
+		return nodes[0], nil
+	case 0:
+		return nil, &NotFoundError{lockevent.Label}
+	default:
+		return nil, &NotSingularError{lockevent.Label}
+	}
+}
+
+// OnlyX is like Only, but panics if an error occurs.
+func (leq *LockEventQuery) OnlyX(ctx context.Context) *LockEvent {
+	node, err := leq.Only(ctx)
+	if err != nil {
+		panic(err)
+	}
+	return node
+}
+
+// OnlyID is like Only, but returns the only LockEvent ID in the query.
+// Returns a *NotSingularError when more than one LockEvent ID is found.
+// Returns a *NotFoundError when no entities are found.
+func (leq *LockEventQuery) OnlyID(ctx context.Context) (id int, err error) {
+	var ids []int
+	if ids, err = leq.Limit(2).IDs(setContextOp(ctx, leq.ctx, "OnlyID")); err != nil {
+		return
+	}
+	switch len(ids) {
+	case 1:
+		id = ids[0]
+	case 0:
+		err = &NotFoundError{lockevent.Label}
+	default:
+		err = &NotSingularError{lockevent.Label}
+	}
+	return
+}
+
+// OnlyIDX is like OnlyID, but panics if an error occurs.
+func (leq *LockEventQuery) OnlyIDX(ctx context.Context) int {
+	id, err := leq.OnlyID(ctx)
+	if err != nil {
+		panic(err)
+	}
+	return id
+}
+
+// All executes the query and returns a list of LockEvents.
+func (leq *LockEventQuery) All(ctx context.Context) ([]*LockEvent, error) {
+	ctx = setContextOp(ctx, leq.ctx, "All")
+	if err := leq.prepareQuery(ctx); err != nil {
+		return nil, err
+	}
+	qr := querierAll[[]*LockEvent, *LockEventQuery]()
+	return withInterceptors[[]*LockEvent](ctx, leq, qr, leq.inters)
+}
+
+// AllX is like All, but panics if an error occurs.
+func (leq *LockEventQuery) AllX(ctx context.Context) []*LockEvent {
+	nodes, err := leq.All(ctx)
+	if err != nil {
+		panic(err)
+	}
+	return nodes
+}
+
+// IDs executes the query and returns a list of LockEvent IDs.
+func (leq *LockEventQuery) IDs(ctx context.Context) (ids []int, err error) {
+	if leq.ctx.Unique == nil && leq.path != nil {
+		leq.Unique(true)
+	}
+	ctx = setContextOp(ctx, leq.ctx, "IDs")
+	if err = leq.Select(lockevent.FieldID).Scan(ctx, &ids); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// IDsX is like IDs, but panics if an error occurs.
+func (leq *LockEventQuery) IDsX(ctx context.Context) []int {
+	ids, err := leq.IDs(ctx)
+	if err != nil {
+		panic(err)
+	}
+	return ids
+}
+
+// Count returns the count of the given query.
+func (leq *LockEventQuery) Count(ctx context.Context) (int, error) {
+	ctx = setContextOp(ctx, leq.ctx, "Count")
+	if err := leq.prepareQuery(ctx); err != nil {
+		return 0, err
+	}
+	return withInterceptors[int](ctx, leq, querierCount[*LockEventQuery](), leq.inters)
+}
+
+// CountX is like Count, but panics if an error occurs.
+func (leq *LockEventQuery) CountX(ctx context.Context) int {
+	count, err := leq.Count(ctx)
+	if err != nil {
+		panic(err)
+	}
+	return count
+}
+
+// Exist returns true if the query has elements in the graph.
+func (leq *LockEventQuery) Exist(ctx context.Context) (bool, error) {
+	ctx = setContextOp(ctx, leq.ctx, "Exist")
+	switch _, err := leq.FirstID(ctx); {
+	case IsNotFound(err):
+		return false, nil
+	case err != nil:
+		return false, fmt.Errorf("generated: check existence: %w", err)
+	default:
+		return true, nil
+	}
+}
+
+// ExistX is like Exist, but panics if an error occurs.
+func (leq *LockEventQuery) ExistX(ctx context.Context) bool {
+	exist, err := leq.Exist(ctx)
+	if err != nil {
+		panic(err)
+	}
+	return exist
+}
+
+// Clone returns a duplicate of the LockEventQuery builder, including all associated steps. It can be
+// used to prepare common query builders and use them differently after the clone is made.
+func (leq *LockEventQuery) Clone() *LockEventQuery {
+	if leq == nil {
+		return nil
+	}
+	return &LockEventQuery{
+		config:     leq.config,
+		ctx:        leq.ctx.Clone(),
+		order:      append([]lockevent.OrderOption{}, leq.order...),
+		inters:     append([]Interceptor{}, leq.inters...),
+		predicates: append([]predicate.LockEvent{}, leq.predicates...),
+		withUser:   leq.withUser.Clone(),
+		// clone intermediate query.
+		sql:  leq.sql.Clone(),
+		path: leq.path,
+	}
+}
+
+// WithUser tells the query-builder to eager-load the nodes that are connected to
+// the "user" edge. The optional arguments are used to configure the query builder of the edge.
+func (leq *LockEventQuery) WithUser(opts ...func(*UserQuery)) *LockEventQuery {
+	query := (&UserClient{config: leq.config}).Query()
+	for _, opt := range opts {
+		opt(query)
+	}
+	leq.withUser = query
+	return leq
+}
+
+// GroupBy is used to group vertices by one or more fields/columns.
+// It is often used with aggregate functions, like: count, max, mean, min, sum.
+//
+// Example:
+//
+//	var v []struct {
+//		Scope lockevent.Scope `json:"scope"`
+//		Count int `json:"count,omitempty"`
+//	}
+//
+//	client.LockEvent.Query().
+//		GroupBy(lockevent.FieldScope).
+//		Aggregate(generated.Count()).
+//		Scan(ctx, &v)
+func (leq *LockEventQuery) GroupBy(field string, fields ...string) *LockEventGroupBy {
+	leq.ctx.Fields = append([]string{field}, fields...)
+	grbuild := &LockEventGroupBy{build: leq}
+	grbuild.flds = &leq.ctx.Fields
+	grbuild.label = lockevent.Label
+	grbuild.scan = grbuild.Scan
+	return grbuild
+}
+
+// Select allows the selection one or more fields/columns for the given query,
+// instead of selecting all fields in the entity.
+//
+// Example:
+//
+//	var v []struct {
+//		Scope lockevent.Scope `json:"scope"`
+//	}
+//
+//	client.LockEvent.Query().
+//		Select(lockevent.FieldScope).
+//		Scan(ctx, &v)
+func (leq *LockEventQuery) Select(fields ...string) *LockEventSelect {
+	leq.ctx.Fields = append(leq.ctx.Fields, fields...)
+	sbuild := &LockEventSelect{LockEventQuery: leq}
+	sbuild.label = lockevent.Label
+	sbuild.flds, sbuild.scan = &leq.ctx.Fields, sbuild.Scan
+	return sbuild
+}
+
+// Aggregate returns a LockEventSelect configured with the given aggregations.
+func (leq *LockEventQuery) Aggregate(fns ...AggregateFunc) *LockEventSelect {
+	return leq.Select().Aggregate(fns...)
+}
+
+func (leq *LockEventQuery) prepareQuery(ctx context.Context) error {
+	for _, inter := range leq.inters {
+		if inter == nil {
+			return fmt.Errorf("generated: uninitialized interceptor (forgotten import generated/runtime?)")
+		}
+		if trv, ok := inter.(Traverser); ok {
+			if err := trv.Traverse(ctx, leq); err != nil {
+				return err
+			}
+		}
+	}
+	for _, f := range leq.ctx.Fields {
+		if !lockevent.ValidColumn(f) {
+			return &ValidationError{Name: f, err: fmt.Errorf("generated: invalid field %q for query", f)}
+		}
+	}
+	if leq.path != nil {
+		prev, err := leq.path(ctx)
+		if err != nil {
+			return err
+		}
+		leq.sql = prev
+	}
+	return nil
+}
+
+func (leq *LockEventQuery) sqlAll(ctx context.Context, hooks ...queryHook) ([]*LockEvent, error) {
+	var (
+		nodes       = []*LockEvent{}
+		_spec       = leq.querySpec()
+		loadedTypes = [1]bool{
+			leq.withUser != nil,
+		}
+	)
+	_spec.ScanValues = func(columns []string) ([]any, error) {
+		return (*LockEvent).scanValues(nil, columns)
+	}
+	_spec.Assign = func(columns []string, values []any) error {
+		node := &LockEvent{config: leq.config}
+		nodes = append(nodes, node)
+		node.Edges.loadedTypes = loadedTypes
+		return node.assignValues(columns, values)
+	}
+	if len(leq.modifiers) > 0 {
+		_spec.Modifiers = leq.modifiers
+	}
+	for i := range hooks {
+		hooks[i](ctx, _spec)
+	}
+	if err := sqlgraph.QueryNodes(ctx, leq.driver, _spec); err != nil {
+		return nil, err
+	}
+	if len(nodes) == 0 {
+		return nodes, nil
+	}
+	if query := leq.withUser; query != nil {
+		if err := leq.loadUser(ctx, query, nodes, nil,
+			func(n *LockEvent, e *User) { n.Edges.User = e }); err != nil {
+			return nil, err
+		}
+	}
+	return nodes, nil
+}
+
+func (leq *LockEventQuery) loadUser(ctx context.Context, query *UserQuery, nodes []*LockEvent, init func(*LockEvent), assign func(*LockEvent, *User)) error {
+	ids := make([]int, 0, len(nodes))
+	nodeids := make(map[int][]*LockEvent)
+	for i := range nodes {
+		if nodes[i].UserID == nil {
+			continue
+		}
+		fk := *nodes[i].UserID
+		if _, ok := nodeids[fk]; !ok {
+			ids = append(ids, fk)
+		}
+		nodeids[fk] = append(nodeids[fk], nodes[i])
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	query.Where(user.IDIn(ids...))
+	neighbors, err := query.All(ctx)
+	if err != nil {
+		return err
+	}
+	for _, n := range neighbors {
+		nodes, ok := nodeids[n.ID]
+		if !ok {
+			return fmt.Errorf(`unexpected foreign-key "user_id" returned %v`, n.ID)
+		}
+		for i := range nodes {
+			assign(nodes[i], n)
+		}
+	}
+	return nil
+}
+
+func (leq *LockEventQuery) sqlCount(ctx context.Context) (int, error) {
+	_spec := leq.querySpec()
+	if len(leq.modifiers) > 0 {
+		_spec.Modifiers = leq.modifiers
+	}
+	_spec.Node.Columns = leq.ctx.Fields
+	if len(leq.ctx.Fields) > 0 {
+		_spec.Unique = leq.ctx.Unique != nil && *leq.ctx.Unique
+	}
+	return sqlgraph.CountNodes(ctx, leq.driver, _spec)
+}
+
+func (leq *LockEventQuery) querySpec() *sqlgraph.QuerySpec {
+	_spec := sqlgraph.NewQuerySpec(lockevent.Table, lockevent.Columns, sqlgraph.NewFieldSpec(lockevent.FieldID, field.TypeInt))
+	_spec.From = leq.sql
+	if unique := leq.ctx.Unique; unique != nil {
+		_spec.Unique = *unique
+	} else if leq.path != nil {
+		_spec.Unique = true
+	}
+	if fields := leq.ctx.Fields; len(fields) > 0 {
+		_spec.Node.Columns = make([]string, 0, len(fields))
+		_spec.Node.Columns = append(_spec.Node.Columns, lockevent.FieldID)
+		for i := range fields {
+			if fields[i] != lockevent.FieldID {
+				_spec.Node.Columns = append(_spec.Node.Columns, fields[i])
+			}
+		}
+		if leq.withUser != nil {
+			_spec.Node.AddColumnOnce(lockevent.FieldUserID)
+		}
+	}
+	if ps := leq.predicates; len(ps) > 0 {
+		_spec.Predicate = func(selector *sql.Selector) {
+			for i := range ps {
+				ps[i](selector)
+			}
+		}
+	}
+	if limit := leq.ctx.Limit; limit != nil {
+		_spec.Limit = *limit
+	}
+	if offset := leq.ctx.Offset; offset != nil {
+		_spec.Offset = *offset
+	}
+	if ps := leq.order; len(ps) > 0 {
+		_spec.Order = func(selector *sql.Selector) {
+			for i := range ps {
+				ps[i](selector)
+			}
+		}
+	}
+	return _spec
+}
+
+func (leq *LockEventQuery) sqlQuery(ctx context.Context) *sql.Selector {
+	builder := sql.Dialect(leq.driver.Dialect())
+	t1 := builder.Table(lockevent.Table)
+	columns := leq.ctx.Fields
+	if len(columns) == 0 {
+		columns = lockevent.Columns
+	}
+	selector := builder.Select(t1.Columns(columns...)...).From(t1)
+	if leq.sql != nil {
+		selector = leq.sql
+		selector.Select(selector.Columns(columns...)...)
+	}
+	if leq.ctx.Unique != nil && *leq.ctx.Unique {
+		selector.Distinct()
+	}
+	for _, m := range leq.modifiers {
+		m(selector)
+	}
+	for _, p := range leq.predicates {
+		p(selector)
+	}
+	for _, p := range leq.order {
+		p(selector)
+	}
+	if offset := leq.ctx.Offset; offset != nil {
+		// limit is mandatory for offset clause. We start
+		// with default value, and override it below if needed.
+		selector.Offset(*offset).Limit(math.MaxInt32)
+	}
+	if limit := leq.ctx.Limit; limit != nil {
+		selector.Limit(*limit)
+	}
+	return selector
+}
+
+// ForUpdate locks the selected rows against concurrent updates, and prevent them from being
+// updated, deleted or "selected ... for update" by other sessions, until the transaction is
+// either committed or rolled-back.
+func (leq *LockEventQuery) ForUpdate(opts ...sql.LockOption) *LockEventQuery {
+	if leq.driver.Dialect() == dialect.Postgres {
+		leq.Unique(false)
+	}
+	leq.modifiers = append(leq.modifiers, func(s *sql.Selector) {
+		s.ForUpdate(opts...)
+	})
+	return leq
+}
+
+// ForShare behaves similarly to ForUpdate, except that it acquires a shared mode lock
+// on any rows that are read. Other sessions can read the rows, but cannot modify them
+// until your transaction commits.
+func (leq *LockEventQuery) ForShare(opts ...sql.LockOption) *LockEventQuery {
+	if leq.driver.Dialect() == dialect.Postgres {
+		leq.Unique(false)
+	}
+	leq.modifiers = append(leq.modifiers, func(s *sql.Selector) {
+		s.ForShare(opts...)
+	})
+	return leq
+}
+
+// LockEventGroupBy is the group-by builder for LockEvent entities.
+type LockEventGroupBy struct {
+	selector
+	build *LockEventQuery
+}
+
+// Aggregate adds the given aggregation functions to the group-by query.
+func (legb *LockEventGroupBy) Aggregate(fns ...AggregateFunc) *LockEventGroupBy {
+	legb.fns = append(legb.fns, fns...)
+	return legb
+}
+
+// Scan applies the selector query and scans the result into the given value.
+func (legb *LockEventGroupBy) Scan(ctx context.Context, v any) error {
+	ctx = setContextOp(ctx, legb.build.ctx, "GroupBy")
+	if err := legb.build.prepareQuery(ctx); err != nil {
+		return err
+	}
+	return scanWithInterceptors[*LockEventQuery, *LockEventGroupBy](ctx, legb.build, legb, legb.build.inters, v)
+}
+
+func (legb *LockEventGroupBy) sqlScan(ctx context.Context, root *LockEventQuery, v any) error {
+	selector := root.sqlQuery(ctx).Select()
+	aggregation := make([]string, 0, len(legb.fns))
+	for _, fn := range legb.fns {
+		aggregation = append(aggregation, fn(selector))
+	}
+	if len(selector.SelectedColumns()) == 0 {
+		columns := make([]string, 0, len(*legb.flds)+len(legb.fns))
+		for _, f := range *legb.flds {
+			columns = append(columns, selector.C(f))
+		}
+		columns = append(columns, aggregation...)
+		selector.Select(columns...)
+	}
+	selector.GroupBy(selector.Columns(*legb.flds...)...)
+	if err := selector.Err(); err != nil {
+		return err
+	}
+	rows := &sql.Rows{}
+	query, args := selector.Query()
+	if err := legb.build.driver.Query(ctx, query, args, rows); err != nil {
+		return err
+	}
+	defer rows.Close()
+	return sql.ScanSlice(rows, v)
+}
+
+// LockEventSelect is the builder for selecting fields of LockEvent entities.
+type LockEventSelect struct {
+	*LockEventQuery
+	selector
+}
+
+// Aggregate adds the given aggregation functions to the selector query.
+func (les *LockEventSelect) Aggregate(fns ...AggregateFunc) *LockEventSelect {
+	les.fns = append(les.fns, fns...)
+	return les
+}
+
+// Scan applies the selector query and scans the result into the given value.
+func (les *LockEventSelect) Scan(ctx context.Context, v any) error {
+	ctx = setContextOp(ctx, les.ctx, "Select")
+	if err := les.prepareQuery(ctx); err != nil {
+		return err
+	}
+	return scanWithInterceptors[*LockEventQuery, *LockEventSelect](ctx, les.LockEventQuery, les, les.inters, v)
+}
+
+func (les *LockEventSelect) sqlScan(ctx context.Context, root *LockEventQuery, v any) error {
+	selector := root.sqlQuery(ctx)
+	aggregation := make([]string, 0, len(les.fns))
+	for _, fn := range les.fns {
+		aggregation = append(aggregation, fn(selector))
+	}
+	switch n := len(*les.selector.flds); {
+	case n == 0 && len(aggregation) > 0:
+		selector.Select(aggregation...)
+	case n != 0 && len(aggregation) > 0:
+		selector.AppendSelect(aggregation...)
+	}
+	rows := &sql.Rows{}
+	query, args := selector.Query()
+	if err := les.driver.Query(ctx, query, args, rows); err != nil {
+		return err
+	}
+	defer rows.Close()
+	return sql.ScanSlice(rows, v)
+}
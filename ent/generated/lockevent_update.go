@@ -0,0 +1,193 @@
+// Code generated by ent, DO NOT EDIT.
+
+package generated
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"entgo.io/ent/dialect/sql"
+	"entgo.io/ent/dialect/sql/sqlgraph"
+	"entgo.io/ent/schema/field"
+	"github.com/ryuudan/golang-rest-api/ent/generated/lockevent"
+	"github.com/ryuudan/golang-rest-api/ent/generated/predicate"
+)
+
+// LockEventUpdate is the builder for updating LockEvent entities.
+type LockEventUpdate struct {
+	config
+	hooks    []Hook
+	mutation *LockEventMutation
+}
+
+// Where appends a list predicates to the LockEventUpdate builder.
+func (leu *LockEventUpdate) Where(ps ...predicate.LockEvent) *LockEventUpdate {
+	leu.mutation.Where(ps...)
+	return leu
+}
+
+// Mutation returns the LockEventMutation object of the builder.
+func (leu *LockEventUpdate) Mutation() *LockEventMutation {
+	return leu.mutation
+}
+
+// Save executes the query and returns the number of nodes affected by the update operation.
+func (leu *LockEventUpdate) Save(ctx context.Context) (int, error) {
+	return withHooks(ctx, leu.sqlSave, leu.mutation, leu.hooks)
+}
+
+// SaveX is like Save, but panics if an error occurs.
+func (leu *LockEventUpdate) SaveX(ctx context.Context) int {
+	affected, err := leu.Save(ctx)
+	if err != nil {
+		panic(err)
+	}
+	return affected
+}
+
+// Exec executes the query.
+func (leu *LockEventUpdate) Exec(ctx context.Context) error {
+	_, err := leu.Save(ctx)
+	return err
+}
+
+// ExecX is like Exec, but panics if an error occurs.
+func (leu *LockEventUpdate) ExecX(ctx context.Context) {
+	if err := leu.Exec(ctx); err != nil {
+		panic(err)
+	}
+}
+
+func (leu *LockEventUpdate) sqlSave(ctx context.Context) (n int, err error) {
+	_spec := sqlgraph.NewUpdateSpec(lockevent.Table, lockevent.Columns, sqlgraph.NewFieldSpec(lockevent.FieldID, field.TypeInt))
+	if ps := leu.mutation.predicates; len(ps) > 0 {
+		_spec.Predicate = func(selector *sql.Selector) {
+			for i := range ps {
+				ps[i](selector)
+			}
+		}
+	}
+	if leu.mutation.LockedUntilCleared() {
+		_spec.ClearField(lockevent.FieldLockedUntil, field.TypeTime)
+	}
+	if leu.mutation.IPCleared() {
+		_spec.ClearField(lockevent.FieldIP, field.TypeString)
+	}
+	if leu.mutation.ActorIDCleared() {
+		_spec.ClearField(lockevent.FieldActorID, field.TypeInt)
+	}
+	if n, err = sqlgraph.UpdateNodes(ctx, leu.driver, _spec); err != nil {
+		if _, ok := err.(*sqlgraph.NotFoundError); ok {
+			err = &NotFoundError{lockevent.Label}
+		} else if sqlgraph.IsConstraintError(err) {
+			err = &ConstraintError{msg: err.Error(), wrap: err}
+		}
+		return 0, err
+	}
+	leu.mutation.done = true
+	return n, nil
+}
+
+// LockEventUpdateOne is the builder for updating a single LockEvent entity.
+type LockEventUpdateOne struct {
+	config
+	fields   []string
+	hooks    []Hook
+	mutation *LockEventMutation
+}
+
+// Mutation returns the LockEventMutation object of the builder.
+func (leuo *LockEventUpdateOne) Mutation() *LockEventMutation {
+	return leuo.mutation
+}
+
+// Where appends a list predicates to the LockEventUpdate builder.
+func (leuo *LockEventUpdateOne) Where(ps ...predicate.LockEvent) *LockEventUpdateOne {
+	leuo.mutation.Where(ps...)
+	return leuo
+}
+
+// Select allows selecting one or more fields (columns) of the returned entity.
+// The default is selecting all fields defined in the entity schema.
+func (leuo *LockEventUpdateOne) Select(field string, fields ...string) *LockEventUpdateOne {
+	leuo.fields = append([]string{field}, fields...)
+	return leuo
+}
+
+// Save executes the query and returns the updated LockEvent entity.
+func (leuo *LockEventUpdateOne) Save(ctx context.Context) (*LockEvent, error) {
+	return withHooks(ctx, leuo.sqlSave, leuo.mutation, leuo.hooks)
+}
+
+// SaveX is like Save, but panics if an error occurs.
+func (leuo *LockEventUpdateOne) SaveX(ctx context.Context) *LockEvent {
+	node, err := leuo.Save(ctx)
+	if err != nil {
+		panic(err)
+	}
+	return node
+}
+
+// Exec executes the query on the entity.
+func (leuo *LockEventUpdateOne) Exec(ctx context.Context) error {
+	_, err := leuo.Save(ctx)
+	return err
+}
+
+// ExecX is like Exec, but panics if an error occurs.
+func (leuo *LockEventUpdateOne) ExecX(ctx context.Context) {
+	if err := leuo.Exec(ctx); err != nil {
+		panic(err)
+	}
+}
+
+func (leuo *LockEventUpdateOne) sqlSave(ctx context.Context) (_node *LockEvent, err error) {
+	_spec := sqlgraph.NewUpdateSpec(lockevent.Table, lockevent.Columns, sqlgraph.NewFieldSpec(lockevent.FieldID, field.TypeInt))
+	id, ok := leuo.mutation.ID()
+	if !ok {
+		return nil, &ValidationError{Name: "id", err: errors.New(`generated: missing "LockEvent.id" for update`)}
+	}
+	_spec.Node.ID.Value = id
+	if fields := leuo.fields; len(fields) > 0 {
+		_spec.Node.Columns = make([]string, 0, len(fields))
+		_spec.Node.Columns = append(_spec.Node.Columns, lockevent.FieldID)
+		for _, f := range fields {
+			if !lockevent.ValidColumn(f) {
+				return nil, &ValidationError{Name: f, err: fmt.Errorf("generated: invalid field %q for query", f)}
+			}
+			if f != lockevent.FieldID {
+				_spec.Node.Columns = append(_spec.Node.Columns, f)
+			}
+		}
+	}
+	if ps := leuo.mutation.predicates; len(ps) > 0 {
+		_spec.Predicate = func(selector *sql.Selector) {
+			for i := range ps {
+				ps[i](selector)
+			}
+		}
+	}
+	if leuo.mutation.LockedUntilCleared() {
+		_spec.ClearField(lockevent.FieldLockedUntil, field.TypeTime)
+	}
+	if leuo.mutation.IPCleared() {
+		_spec.ClearField(lockevent.FieldIP, field.TypeString)
+	}
+	if leuo.mutation.ActorIDCleared() {
+		_spec.ClearField(lockevent.FieldActorID, field.TypeInt)
+	}
+	_node = &LockEvent{config: leuo.config}
+	_spec.Assign = _node.assignValues
+	_spec.ScanValues = _node.scanValues
+	if err = sqlgraph.UpdateNode(ctx, leuo.driver, _spec); err != nil {
+		if _, ok := err.(*sqlgraph.NotFoundError); ok {
+			err = &NotFoundError{lockevent.Label}
+		} else if sqlgraph.IsConstraintError(err) {
+			err = &ConstraintError{msg: err.Error(), wrap: err}
+		}
+		return nil, err
+	}
+	leuo.mutation.done = true
+	return _node, nil
+}
@@ -0,0 +1,189 @@
+// Code generated by ent, DO NOT EDIT.
+
+package generated
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"entgo.io/ent"
+	"entgo.io/ent/dialect/sql"
+	"github.com/ryuudan/golang-rest-api/ent/generated/outboxmessage"
+)
+
+// OutboxMessage is the model entity for the OutboxMessage schema.
+type OutboxMessage struct {
+	config `json:"-"`
+	// ID of the ent.
+	ID int `json:"id,omitempty"`
+	// Recipient holds the value of the "recipient" field.
+	Recipient string `json:"recipient"`
+	// Subject holds the value of the "subject" field.
+	Subject string `json:"subject"`
+	// Body holds the value of the "body" field.
+	Body string `json:"body"`
+	// Attempts holds the value of the "attempts" field.
+	Attempts int `json:"attempts"`
+	// LastError holds the value of the "last_error" field.
+	LastError *string `json:"last_error,omitempty"`
+	// AvailableAt holds the value of the "available_at" field.
+	AvailableAt time.Time `json:"available_at"`
+	// SentAt holds the value of the "sent_at" field.
+	SentAt *time.Time `json:"sent_at,omitempty"`
+	// CreatedAt holds the value of the "created_at" field.
+	CreatedAt    time.Time `json:"created_at"`
+	selectValues sql.SelectValues
+}
+
+// scanValues returns the types for scanning values from sql.Rows.
+func (*OutboxMessage) scanValues(columns []string) ([]any, error) {
+	values := make([]any, len(columns))
+	for i := range columns {
+		switch columns[i] {
+		case outboxmessage.FieldID, outboxmessage.FieldAttempts:
+			values[i] = new(sql.NullInt64)
+		case outboxmessage.FieldRecipient, outboxmessage.FieldSubject, outboxmessage.FieldBody, outboxmessage.FieldLastError:
+			values[i] = new(sql.NullString)
+		case outboxmessage.FieldAvailableAt, outboxmessage.FieldSentAt, outboxmessage.FieldCreatedAt:
+			values[i] = new(sql.NullTime)
+		default:
+			values[i] = new(sql.UnknownType)
+		}
+	}
+	return values, nil
+}
+
+// assignValues assigns the values that were returned from sql.Rows (after scanning)
+// to the OutboxMessage fields.
+func (om *OutboxMessage) assignValues(columns []string, values []any) error {
+	if m, n := len(values), len(columns); m < n {
+		return fmt.Errorf("mismatch number of scan values: %d != %d", m, n)
+	}
+	for i := range columns {
+		switch columns[i] {
+		case outboxmessage.FieldID:
+			value, ok := values[i].(*sql.NullInt64)
+			if !ok {
+				return fmt.Errorf("unexpected type %T for field id", value)
+			}
+			om.ID = int(value.Int64)
+		case outboxmessage.FieldRecipient:
+			if value, ok := values[i].(*sql.NullString); !ok {
+				return fmt.Errorf("unexpected type %T for field recipient", values[i])
+			} else if value.Valid {
+				om.Recipient = value.String
+			}
+		case outboxmessage.FieldSubject:
+			if value, ok := values[i].(*sql.NullString); !ok {
+				return fmt.Errorf("unexpected type %T for field subject", values[i])
+			} else if value.Valid {
+				om.Subject = value.String
+			}
+		case outboxmessage.FieldBody:
+			if value, ok := values[i].(*sql.NullString); !ok {
+				return fmt.Errorf("unexpected type %T for field body", values[i])
+			} else if value.Valid {
+				om.Body = value.String
+			}
+		case outboxmessage.FieldAttempts:
+			if value, ok := values[i].(*sql.NullInt64); !ok {
+				return fmt.Errorf("unexpected type %T for field attempts", values[i])
+			} else if value.Valid {
+				om.Attempts = int(value.Int64)
+			}
+		case outboxmessage.FieldLastError:
+			if value, ok := values[i].(*sql.NullString); !ok {
+				return fmt.Errorf("unexpected type %T for field last_error", values[i])
+			} else if value.Valid {
+				om.LastError = new(string)
+				*om.LastError = value.String
+			}
+		case outboxmessage.FieldAvailableAt:
+			if value, ok := values[i].(*sql.NullTime); !ok {
+				return fmt.Errorf("unexpected type %T for field available_at", values[i])
+			} else if value.Valid {
+				om.AvailableAt = value.Time
+			}
+		case outboxmessage.FieldSentAt:
+			if value, ok := values[i].(*sql.NullTime); !ok {
+				return fmt.Errorf("unexpected type %T for field sent_at", values[i])
+			} else if value.Valid {
+				om.SentAt = new(time.Time)
+				*om.SentAt = value.Time
+			}
+		case outboxmessage.FieldCreatedAt:
+			if value, ok := values[i].(*sql.NullTime); !ok {
+				return fmt.Errorf("unexpected type %T for field created_at", values[i])
+			} else if value.Valid {
+				om.CreatedAt = value.Time
+			}
+		default:
+			om.selectValues.Set(columns[i], values[i])
+		}
+	}
+	return nil
+}
+
+// Value returns the ent.Value that was dynamically selected and assigned to the OutboxMessage.
+// This includes values selected through modifiers, order, etc.
+func (om *OutboxMessage) Value(name string) (ent.Value, error) {
+	return om.selectValues.Get(name)
+}
+
+// Update returns a builder for updating this OutboxMessage.
+// Note that you need to call OutboxMessage.Unwrap() before calling this method if this OutboxMessage
+// was returned from a transaction, and the transaction was committed or rolled back.
+func (om *OutboxMessage) Update() *OutboxMessageUpdateOne {
+	return NewOutboxMessageClient(om.config).UpdateOne(om)
+}
+
+// Unwrap unwraps the OutboxMessage entity that was returned from a transaction after it was closed,
+// so that all future queries will be executed through the driver which created the transaction.
+func (om *OutboxMessage) Unwrap() *OutboxMessage {
+	_tx, ok := om.config.driver.(*txDriver)
+	if !ok {
+		panic("generated: OutboxMessage is not a transactional entity")
+	}
+	om.config.driver = _tx.drv
+	return om
+}
+
+// String implements the fmt.Stringer.
+func (om *OutboxMessage) String() string {
+	var builder strings.Builder
+	builder.WriteString("OutboxMessage(")
+	builder.WriteString(fmt.Sprintf("id=%v, ", om.ID))
+	builder.WriteString("recipient=")
+	builder.WriteString(om.Recipient)
+	builder.WriteString(", ")
+	builder.WriteString("subject=")
+	builder.WriteString(om.Subject)
+	builder.WriteString(", ")
+	builder.WriteString("body=")
+	builder.WriteString(om.Body)
+	builder.WriteString(", ")
+	builder.WriteString("attempts=")
+	builder.WriteString(fmt.Sprintf("%v", om.Attempts))
+	builder.WriteString(", ")
+	if v := om.LastError; v != nil {
+		builder.WriteString("last_error=")
+		builder.WriteString(*v)
+	}
+	builder.WriteString(", ")
+	builder.WriteString("available_at=")
+	builder.WriteString(om.AvailableAt.Format(time.ANSIC))
+	builder.WriteString(", ")
+	if v := om.SentAt; v != nil {
+		builder.WriteString("sent_at=")
+		builder.WriteString(v.Format(time.ANSIC))
+	}
+	builder.WriteString(", ")
+	builder.WriteString("created_at=")
+	builder.WriteString(om.CreatedAt.Format(time.ANSIC))
+	builder.WriteByte(')')
+	return builder.String()
+}
+
+// OutboxMessages is a parsable slice of OutboxMessage.
+type OutboxMessages []*OutboxMessage
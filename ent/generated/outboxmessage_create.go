@@ -0,0 +1,332 @@
+// Code generated by ent, DO NOT EDIT.
+
+package generated
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"entgo.io/ent/dialect/sql/sqlgraph"
+	"entgo.io/ent/schema/field"
+	"github.com/ryuudan/golang-rest-api/ent/generated/outboxmessage"
+)
+
+// OutboxMessageCreate is the builder for creating a OutboxMessage entity.
+type OutboxMessageCreate struct {
+	config
+	mutation *OutboxMessageMutation
+	hooks    []Hook
+}
+
+// SetRecipient sets the "recipient" field.
+func (omc *OutboxMessageCreate) SetRecipient(s string) *OutboxMessageCreate {
+	omc.mutation.SetRecipient(s)
+	return omc
+}
+
+// SetSubject sets the "subject" field.
+func (omc *OutboxMessageCreate) SetSubject(s string) *OutboxMessageCreate {
+	omc.mutation.SetSubject(s)
+	return omc
+}
+
+// SetBody sets the "body" field.
+func (omc *OutboxMessageCreate) SetBody(s string) *OutboxMessageCreate {
+	omc.mutation.SetBody(s)
+	return omc
+}
+
+// SetAttempts sets the "attempts" field.
+func (omc *OutboxMessageCreate) SetAttempts(i int) *OutboxMessageCreate {
+	omc.mutation.SetAttempts(i)
+	return omc
+}
+
+// SetNillableAttempts sets the "attempts" field if the given value is not nil.
+func (omc *OutboxMessageCreate) SetNillableAttempts(i *int) *OutboxMessageCreate {
+	if i != nil {
+		omc.SetAttempts(*i)
+	}
+	return omc
+}
+
+// SetLastError sets the "last_error" field.
+func (omc *OutboxMessageCreate) SetLastError(s string) *OutboxMessageCreate {
+	omc.mutation.SetLastError(s)
+	return omc
+}
+
+// SetNillableLastError sets the "last_error" field if the given value is not nil.
+func (omc *OutboxMessageCreate) SetNillableLastError(s *string) *OutboxMessageCreate {
+	if s != nil {
+		omc.SetLastError(*s)
+	}
+	return omc
+}
+
+// SetAvailableAt sets the "available_at" field.
+func (omc *OutboxMessageCreate) SetAvailableAt(t time.Time) *OutboxMessageCreate {
+	omc.mutation.SetAvailableAt(t)
+	return omc
+}
+
+// SetNillableAvailableAt sets the "available_at" field if the given value is not nil.
+func (omc *OutboxMessageCreate) SetNillableAvailableAt(t *time.Time) *OutboxMessageCreate {
+	if t != nil {
+		omc.SetAvailableAt(*t)
+	}
+	return omc
+}
+
+// SetSentAt sets the "sent_at" field.
+func (omc *OutboxMessageCreate) SetSentAt(t time.Time) *OutboxMessageCreate {
+	omc.mutation.SetSentAt(t)
+	return omc
+}
+
+// SetNillableSentAt sets the "sent_at" field if the given value is not nil.
+func (omc *OutboxMessageCreate) SetNillableSentAt(t *time.Time) *OutboxMessageCreate {
+	if t != nil {
+		omc.SetSentAt(*t)
+	}
+	return omc
+}
+
+// SetCreatedAt sets the "created_at" field.
+func (omc *OutboxMessageCreate) SetCreatedAt(t time.Time) *OutboxMessageCreate {
+	omc.mutation.SetCreatedAt(t)
+	return omc
+}
+
+// SetNillableCreatedAt sets the "created_at" field if the given value is not nil.
+func (omc *OutboxMessageCreate) SetNillableCreatedAt(t *time.Time) *OutboxMessageCreate {
+	if t != nil {
+		omc.SetCreatedAt(*t)
+	}
+	return omc
+}
+
+// Mutation returns the OutboxMessageMutation object of the builder.
+func (omc *OutboxMessageCreate) Mutation() *OutboxMessageMutation {
+	return omc.mutation
+}
+
+// Save creates the OutboxMessage in the database.
+func (omc *OutboxMessageCreate) Save(ctx context.Context) (*OutboxMessage, error) {
+	omc.defaults()
+	return withHooks(ctx, omc.sqlSave, omc.mutation, omc.hooks)
+}
+
+// SaveX calls Save and panics if Save returns an error.
+func (omc *OutboxMessageCreate) SaveX(ctx context.Context) *OutboxMessage {
+	v, err := omc.Save(ctx)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+// Exec executes the query.
+func (omc *OutboxMessageCreate) Exec(ctx context.Context) error {
+	_, err := omc.Save(ctx)
+	return err
+}
+
+// ExecX is like Exec, but panics if an error occurs.
+func (omc *OutboxMessageCreate) ExecX(ctx context.Context) {
+	if err := omc.Exec(ctx); err != nil {
+		panic(err)
+	}
+}
+
+// defaults sets the default values of the builder before save.
+func (omc *OutboxMessageCreate) defaults() {
+	if _, ok := omc.mutation.Attempts(); !ok {
+		v := outboxmessage.DefaultAttempts
+		omc.mutation.SetAttempts(v)
+	}
+	if _, ok := omc.mutation.AvailableAt(); !ok {
+		v := outboxmessage.DefaultAvailableAt()
+		omc.mutation.SetAvailableAt(v)
+	}
+	if _, ok := omc.mutation.CreatedAt(); !ok {
+		v := outboxmessage.DefaultCreatedAt()
+		omc.mutation.SetCreatedAt(v)
+	}
+}
+
+// check runs all checks and user-defined validators on the builder.
+func (omc *OutboxMessageCreate) check() error {
+	if _, ok := omc.mutation.Recipient(); !ok {
+		return &ValidationError{Name: "recipient", err: errors.New(`generated: missing required field "OutboxMessage.recipient"`)}
+	}
+	if v, ok := omc.mutation.Recipient(); ok {
+		if err := outboxmessage.RecipientValidator(v); err != nil {
+			return &ValidationError{Name: "recipient", err: fmt.Errorf(`generated: validator failed for field "OutboxMessage.recipient": %w`, err)}
+		}
+	}
+	if _, ok := omc.mutation.Subject(); !ok {
+		return &ValidationError{Name: "subject", err: errors.New(`generated: missing required field "OutboxMessage.subject"`)}
+	}
+	if _, ok := omc.mutation.Body(); !ok {
+		return &ValidationError{Name: "body", err: errors.New(`generated: missing required field "OutboxMessage.body"`)}
+	}
+	if _, ok := omc.mutation.Attempts(); !ok {
+		return &ValidationError{Name: "attempts", err: errors.New(`generated: missing required field "OutboxMessage.attempts"`)}
+	}
+	if _, ok := omc.mutation.AvailableAt(); !ok {
+		return &ValidationError{Name: "available_at", err: errors.New(`generated: missing required field "OutboxMessage.available_at"`)}
+	}
+	if _, ok := omc.mutation.CreatedAt(); !ok {
+		return &ValidationError{Name: "created_at", err: errors.New(`generated: missing required field "OutboxMessage.created_at"`)}
+	}
+	return nil
+}
+
+func (omc *OutboxMessageCreate) sqlSave(ctx context.Context) (*OutboxMessage, error) {
+	if err := omc.check(); err != nil {
+		return nil, err
+	}
+	_node, _spec := omc.createSpec()
+	if err := sqlgraph.CreateNode(ctx, omc.driver, _spec); err != nil {
+		if sqlgraph.IsConstraintError(err) {
+			err = &ConstraintError{msg: err.Error(), wrap: err}
+		}
+		return nil, err
+	}
+	id := _spec.ID.Value.(int64)
+	_node.ID = int(id)
+	omc.mutation.id = &_node.ID
+	omc.mutation.done = true
+	return _node, nil
+}
+
+func (omc *OutboxMessageCreate) createSpec() (*OutboxMessage, *sqlgraph.CreateSpec) {
+	var (
+		_node = &OutboxMessage{config: omc.config}
+		_spec = sqlgraph.NewCreateSpec(outboxmessage.Table, sqlgraph.NewFieldSpec(outboxmessage.FieldID, field.TypeInt))
+	)
+	if value, ok := omc.mutation.Recipient(); ok {
+		_spec.SetField(outboxmessage.FieldRecipient, field.TypeString, value)
+		_node.Recipient = value
+	}
+	if value, ok := omc.mutation.Subject(); ok {
+		_spec.SetField(outboxmessage.FieldSubject, field.TypeString, value)
+		_node.Subject = value
+	}
+	if value, ok := omc.mutation.Body(); ok {
+		_spec.SetField(outboxmessage.FieldBody, field.TypeString, value)
+		_node.Body = value
+	}
+	if value, ok := omc.mutation.Attempts(); ok {
+		_spec.SetField(outboxmessage.FieldAttempts, field.TypeInt, value)
+		_node.Attempts = value
+	}
+	if value, ok := omc.mutation.LastError(); ok {
+		_spec.SetField(outboxmessage.FieldLastError, field.TypeString, value)
+		_node.LastError = &value
+	}
+	if value, ok := omc.mutation.AvailableAt(); ok {
+		_spec.SetField(outboxmessage.FieldAvailableAt, field.TypeTime, value)
+		_node.AvailableAt = value
+	}
+	if value, ok := omc.mutation.SentAt(); ok {
+		_spec.SetField(outboxmessage.FieldSentAt, field.TypeTime, value)
+		_node.SentAt = &value
+	}
+	if value, ok := omc.mutation.CreatedAt(); ok {
+		_spec.SetField(outboxmessage.FieldCreatedAt, field.TypeTime, value)
+		_node.CreatedAt = value
+	}
+	return _node, _spec
+}
+
+// OutboxMessageCreateBulk is the builder for creating many OutboxMessage entities in bulk.
+type OutboxMessageCreateBulk struct {
+	config
+	err      error
+	builders []*OutboxMessageCreate
+}
+
+// Save creates the OutboxMessage entities in the database.
+func (omcb *OutboxMessageCreateBulk) Save(ctx context.Context) ([]*OutboxMessage, error) {
+	if omcb.err != nil {
+		return nil, omcb.err
+	}
+	specs := make([]*sqlgraph.CreateSpec, len(omcb.builders))
+	nodes := make([]*OutboxMessage, len(omcb.builders))
+	mutators := make([]Mutator, len(omcb.builders))
+	for i := range omcb.builders {
+		func(i int, root context.Context) {
+			builder := omcb.builders[i]
+			builder.defaults()
+			var mut Mutator = MutateFunc(func(ctx context.Context, m Mutation) (Value, error) {
+				mutation, ok := m.(*OutboxMessageMutation)
+				if !ok {
+					return nil, fmt.Errorf("unexpected mutation type %T", m)
+				}
+				if err := builder.check(); err != nil {
+					return nil, err
+				}
+				builder.mutation = mutation
+				var err error
+				nodes[i], specs[i] = builder.createSpec()
+				if i < len(mutators)-1 {
+					_, err = mutators[i+1].Mutate(root, omcb.builders[i+1].mutation)
+				} else {
+					spec := &sqlgraph.BatchCreateSpec{Nodes: specs}
+					// Invoke the actual operation on the latest mutation in the chain.
+					if err = sqlgraph.BatchCreate(ctx, omcb.driver, spec); err != nil {
+						if sqlgraph.IsConstraintError(err) {
+							err = &ConstraintError{msg: err.Error(), wrap: err}
+						}
+					}
+				}
+				if err != nil {
+					return nil, err
+				}
+				mutation.id = &nodes[i].ID
+				if specs[i].ID.Value != nil {
+					id := specs[i].ID.Value.(int64)
+					nodes[i].ID = int(id)
+				}
+				mutation.done = true
+				return nodes[i], nil
+			})
+			for i := len(builder.hooks) - 1; i >= 0; i-- {
+				mut = builder.hooks[i](mut)
+			}
+			mutators[i] = mut
+		}(i, ctx)
+	}
+	if len(mutators) > 0 {
+		if _, err := mutators[0].Mutate(ctx, omcb.builders[0].mutation); err != nil {
+			return nil, err
+		}
+	}
+	return nodes, nil
+}
+
+// SaveX is like Save, but panics if an error occurs.
+func (omcb *OutboxMessageCreateBulk) SaveX(ctx context.Context) []*OutboxMessage {
+	v, err := omcb.Save(ctx)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+// Exec executes the query.
+func (omcb *OutboxMessageCreateBulk) Exec(ctx context.Context) error {
+	_, err := omcb.Save(ctx)
+	return err
+}
+
+// ExecX is like Exec, but panics if an error occurs.
+func (omcb *OutboxMessageCreateBulk) ExecX(ctx context.Context) {
+	if err := omcb.Exec(ctx); err != nil {
+		panic(err)
+	}
+}
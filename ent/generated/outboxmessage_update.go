@@ -0,0 +1,368 @@
+// Code generated by ent, DO NOT EDIT.
+
+package generated
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"entgo.io/ent/dialect/sql"
+	"entgo.io/ent/dialect/sql/sqlgraph"
+	"entgo.io/ent/schema/field"
+	"github.com/ryuudan/golang-rest-api/ent/generated/outboxmessage"
+	"github.com/ryuudan/golang-rest-api/ent/generated/predicate"
+)
+
+// OutboxMessageUpdate is the builder for updating OutboxMessage entities.
+type OutboxMessageUpdate struct {
+	config
+	hooks    []Hook
+	mutation *OutboxMessageMutation
+}
+
+// Where appends a list predicates to the OutboxMessageUpdate builder.
+func (omu *OutboxMessageUpdate) Where(ps ...predicate.OutboxMessage) *OutboxMessageUpdate {
+	omu.mutation.Where(ps...)
+	return omu
+}
+
+// SetAttempts sets the "attempts" field.
+func (omu *OutboxMessageUpdate) SetAttempts(i int) *OutboxMessageUpdate {
+	omu.mutation.ResetAttempts()
+	omu.mutation.SetAttempts(i)
+	return omu
+}
+
+// SetNillableAttempts sets the "attempts" field if the given value is not nil.
+func (omu *OutboxMessageUpdate) SetNillableAttempts(i *int) *OutboxMessageUpdate {
+	if i != nil {
+		omu.SetAttempts(*i)
+	}
+	return omu
+}
+
+// AddAttempts adds i to the "attempts" field.
+func (omu *OutboxMessageUpdate) AddAttempts(i int) *OutboxMessageUpdate {
+	omu.mutation.AddAttempts(i)
+	return omu
+}
+
+// SetLastError sets the "last_error" field.
+func (omu *OutboxMessageUpdate) SetLastError(s string) *OutboxMessageUpdate {
+	omu.mutation.SetLastError(s)
+	return omu
+}
+
+// SetNillableLastError sets the "last_error" field if the given value is not nil.
+func (omu *OutboxMessageUpdate) SetNillableLastError(s *string) *OutboxMessageUpdate {
+	if s != nil {
+		omu.SetLastError(*s)
+	}
+	return omu
+}
+
+// ClearLastError clears the value of the "last_error" field.
+func (omu *OutboxMessageUpdate) ClearLastError() *OutboxMessageUpdate {
+	omu.mutation.ClearLastError()
+	return omu
+}
+
+// SetAvailableAt sets the "available_at" field.
+func (omu *OutboxMessageUpdate) SetAvailableAt(t time.Time) *OutboxMessageUpdate {
+	omu.mutation.SetAvailableAt(t)
+	return omu
+}
+
+// SetNillableAvailableAt sets the "available_at" field if the given value is not nil.
+func (omu *OutboxMessageUpdate) SetNillableAvailableAt(t *time.Time) *OutboxMessageUpdate {
+	if t != nil {
+		omu.SetAvailableAt(*t)
+	}
+	return omu
+}
+
+// SetSentAt sets the "sent_at" field.
+func (omu *OutboxMessageUpdate) SetSentAt(t time.Time) *OutboxMessageUpdate {
+	omu.mutation.SetSentAt(t)
+	return omu
+}
+
+// SetNillableSentAt sets the "sent_at" field if the given value is not nil.
+func (omu *OutboxMessageUpdate) SetNillableSentAt(t *time.Time) *OutboxMessageUpdate {
+	if t != nil {
+		omu.SetSentAt(*t)
+	}
+	return omu
+}
+
+// ClearSentAt clears the value of the "sent_at" field.
+func (omu *OutboxMessageUpdate) ClearSentAt() *OutboxMessageUpdate {
+	omu.mutation.ClearSentAt()
+	return omu
+}
+
+// Mutation returns the OutboxMessageMutation object of the builder.
+func (omu *OutboxMessageUpdate) Mutation() *OutboxMessageMutation {
+	return omu.mutation
+}
+
+// Save executes the query and returns the number of nodes affected by the update operation.
+func (omu *OutboxMessageUpdate) Save(ctx context.Context) (int, error) {
+	return withHooks(ctx, omu.sqlSave, omu.mutation, omu.hooks)
+}
+
+// SaveX is like Save, but panics if an error occurs.
+func (omu *OutboxMessageUpdate) SaveX(ctx context.Context) int {
+	affected, err := omu.Save(ctx)
+	if err != nil {
+		panic(err)
+	}
+	return affected
+}
+
+// Exec executes the query.
+func (omu *OutboxMessageUpdate) Exec(ctx context.Context) error {
+	_, err := omu.Save(ctx)
+	return err
+}
+
+// ExecX is like Exec, but panics if an error occurs.
+func (omu *OutboxMessageUpdate) ExecX(ctx context.Context) {
+	if err := omu.Exec(ctx); err != nil {
+		panic(err)
+	}
+}
+
+func (omu *OutboxMessageUpdate) sqlSave(ctx context.Context) (n int, err error) {
+	_spec := sqlgraph.NewUpdateSpec(outboxmessage.Table, outboxmessage.Columns, sqlgraph.NewFieldSpec(outboxmessage.FieldID, field.TypeInt))
+	if ps := omu.mutation.predicates; len(ps) > 0 {
+		_spec.Predicate = func(selector *sql.Selector) {
+			for i := range ps {
+				ps[i](selector)
+			}
+		}
+	}
+	if value, ok := omu.mutation.Attempts(); ok {
+		_spec.SetField(outboxmessage.FieldAttempts, field.TypeInt, value)
+	}
+	if value, ok := omu.mutation.AddedAttempts(); ok {
+		_spec.AddField(outboxmessage.FieldAttempts, field.TypeInt, value)
+	}
+	if value, ok := omu.mutation.LastError(); ok {
+		_spec.SetField(outboxmessage.FieldLastError, field.TypeString, value)
+	}
+	if omu.mutation.LastErrorCleared() {
+		_spec.ClearField(outboxmessage.FieldLastError, field.TypeString)
+	}
+	if value, ok := omu.mutation.AvailableAt(); ok {
+		_spec.SetField(outboxmessage.FieldAvailableAt, field.TypeTime, value)
+	}
+	if value, ok := omu.mutation.SentAt(); ok {
+		_spec.SetField(outboxmessage.FieldSentAt, field.TypeTime, value)
+	}
+	if omu.mutation.SentAtCleared() {
+		_spec.ClearField(outboxmessage.FieldSentAt, field.TypeTime)
+	}
+	if n, err = sqlgraph.UpdateNodes(ctx, omu.driver, _spec); err != nil {
+		if _, ok := err.(*sqlgraph.NotFoundError); ok {
+			err = &NotFoundError{outboxmessage.Label}
+		} else if sqlgraph.IsConstraintError(err) {
+			err = &ConstraintError{msg: err.Error(), wrap: err}
+		}
+		return 0, err
+	}
+	omu.mutation.done = true
+	return n, nil
+}
+
+// OutboxMessageUpdateOne is the builder for updating a single OutboxMessage entity.
+type OutboxMessageUpdateOne struct {
+	config
+	fields   []string
+	hooks    []Hook
+	mutation *OutboxMessageMutation
+}
+
+// SetAttempts sets the "attempts" field.
+func (omuo *OutboxMessageUpdateOne) SetAttempts(i int) *OutboxMessageUpdateOne {
+	omuo.mutation.ResetAttempts()
+	omuo.mutation.SetAttempts(i)
+	return omuo
+}
+
+// SetNillableAttempts sets the "attempts" field if the given value is not nil.
+func (omuo *OutboxMessageUpdateOne) SetNillableAttempts(i *int) *OutboxMessageUpdateOne {
+	if i != nil {
+		omuo.SetAttempts(*i)
+	}
+	return omuo
+}
+
+// AddAttempts adds i to the "attempts" field.
+func (omuo *OutboxMessageUpdateOne) AddAttempts(i int) *OutboxMessageUpdateOne {
+	omuo.mutation.AddAttempts(i)
+	return omuo
+}
+
+// SetLastError sets the "last_error" field.
+func (omuo *OutboxMessageUpdateOne) SetLastError(s string) *OutboxMessageUpdateOne {
+	omuo.mutation.SetLastError(s)
+	return omuo
+}
+
+// SetNillableLastError sets the "last_error" field if the given value is not nil.
+func (omuo *OutboxMessageUpdateOne) SetNillableLastError(s *string) *OutboxMessageUpdateOne {
+	if s != nil {
+		omuo.SetLastError(*s)
+	}
+	return omuo
+}
+
+// ClearLastError clears the value of the "last_error" field.
+func (omuo *OutboxMessageUpdateOne) ClearLastError() *OutboxMessageUpdateOne {
+	omuo.mutation.ClearLastError()
+	return omuo
+}
+
+// SetAvailableAt sets the "available_at" field.
+func (omuo *OutboxMessageUpdateOne) SetAvailableAt(t time.Time) *OutboxMessageUpdateOne {
+	omuo.mutation.SetAvailableAt(t)
+	return omuo
+}
+
+// SetNillableAvailableAt sets the "available_at" field if the given value is not nil.
+func (omuo *OutboxMessageUpdateOne) SetNillableAvailableAt(t *time.Time) *OutboxMessageUpdateOne {
+	if t != nil {
+		omuo.SetAvailableAt(*t)
+	}
+	return omuo
+}
+
+// SetSentAt sets the "sent_at" field.
+func (omuo *OutboxMessageUpdateOne) SetSentAt(t time.Time) *OutboxMessageUpdateOne {
+	omuo.mutation.SetSentAt(t)
+	return omuo
+}
+
+// SetNillableSentAt sets the "sent_at" field if the given value is not nil.
+func (omuo *OutboxMessageUpdateOne) SetNillableSentAt(t *time.Time) *OutboxMessageUpdateOne {
+	if t != nil {
+		omuo.SetSentAt(*t)
+	}
+	return omuo
+}
+
+// ClearSentAt clears the value of the "sent_at" field.
+func (omuo *OutboxMessageUpdateOne) ClearSentAt() *OutboxMessageUpdateOne {
+	omuo.mutation.ClearSentAt()
+	return omuo
+}
+
+// Mutation returns the OutboxMessageMutation object of the builder.
+func (omuo *OutboxMessageUpdateOne) Mutation() *OutboxMessageMutation {
+	return omuo.mutation
+}
+
+// Where appends a list predicates to the OutboxMessageUpdate builder.
+func (omuo *OutboxMessageUpdateOne) Where(ps ...predicate.OutboxMessage) *OutboxMessageUpdateOne {
+	omuo.mutation.Where(ps...)
+	return omuo
+}
+
+// Select allows selecting one or more fields (columns) of the returned entity.
+// The default is selecting all fields defined in the entity schema.
+func (omuo *OutboxMessageUpdateOne) Select(field string, fields ...string) *OutboxMessageUpdateOne {
+	omuo.fields = append([]string{field}, fields...)
+	return omuo
+}
+
+// Save executes the query and returns the updated OutboxMessage entity.
+func (omuo *OutboxMessageUpdateOne) Save(ctx context.Context) (*OutboxMessage, error) {
+	return withHooks(ctx, omuo.sqlSave, omuo.mutation, omuo.hooks)
+}
+
+// SaveX is like Save, but panics if an error occurs.
+func (omuo *OutboxMessageUpdateOne) SaveX(ctx context.Context) *OutboxMessage {
+	node, err := omuo.Save(ctx)
+	if err != nil {
+		panic(err)
+	}
+	return node
+}
+
+// Exec executes the query on the entity.
+func (omuo *OutboxMessageUpdateOne) Exec(ctx context.Context) error {
+	_, err := omuo.Save(ctx)
+	return err
+}
+
+// ExecX is like Exec, but panics if an error occurs.
+func (omuo *OutboxMessageUpdateOne) ExecX(ctx context.Context) {
+	if err := omuo.Exec(ctx); err != nil {
+		panic(err)
+	}
+}
+
+func (omuo *OutboxMessageUpdateOne) sqlSave(ctx context.Context) (_node *OutboxMessage, err error) {
+	_spec := sqlgraph.NewUpdateSpec(outboxmessage.Table, outboxmessage.Columns, sqlgraph.NewFieldSpec(outboxmessage.FieldID, field.TypeInt))
+	id, ok := omuo.mutation.ID()
+	if !ok {
+		return nil, &ValidationError{Name: "id", err: errors.New(`generated: missing "OutboxMessage.id" for update`)}
+	}
+	_spec.Node.ID.Value = id
+	if fields := omuo.fields; len(fields) > 0 {
+		_spec.Node.Columns = make([]string, 0, len(fields))
+		_spec.Node.Columns = append(_spec.Node.Columns, outboxmessage.FieldID)
+		for _, f := range fields {
+			if !outboxmessage.ValidColumn(f) {
+				return nil, &ValidationError{Name: f, err: fmt.Errorf("generated: invalid field %q for query", f)}
+			}
+			if f != outboxmessage.FieldID {
+				_spec.Node.Columns = append(_spec.Node.Columns, f)
+			}
+		}
+	}
+	if ps := omuo.mutation.predicates; len(ps) > 0 {
+		_spec.Predicate = func(selector *sql.Selector) {
+			for i := range ps {
+				ps[i](selector)
+			}
+		}
+	}
+	if value, ok := omuo.mutation.Attempts(); ok {
+		_spec.SetField(outboxmessage.FieldAttempts, field.TypeInt, value)
+	}
+	if value, ok := omuo.mutation.AddedAttempts(); ok {
+		_spec.AddField(outboxmessage.FieldAttempts, field.TypeInt, value)
+	}
+	if value, ok := omuo.mutation.LastError(); ok {
+		_spec.SetField(outboxmessage.FieldLastError, field.TypeString, value)
+	}
+	if omuo.mutation.LastErrorCleared() {
+		_spec.ClearField(outboxmessage.FieldLastError, field.TypeString)
+	}
+	if value, ok := omuo.mutation.AvailableAt(); ok {
+		_spec.SetField(outboxmessage.FieldAvailableAt, field.TypeTime, value)
+	}
+	if value, ok := omuo.mutation.SentAt(); ok {
+		_spec.SetField(outboxmessage.FieldSentAt, field.TypeTime, value)
+	}
+	if omuo.mutation.SentAtCleared() {
+		_spec.ClearField(outboxmessage.FieldSentAt, field.TypeTime)
+	}
+	_node = &OutboxMessage{config: omuo.config}
+	_spec.Assign = _node.assignValues
+	_spec.ScanValues = _node.scanValues
+	if err = sqlgraph.UpdateNode(ctx, omuo.driver, _spec); err != nil {
+		if _, ok := err.(*sqlgraph.NotFoundError); ok {
+			err = &NotFoundError{outboxmessage.Label}
+		} else if sqlgraph.IsConstraintError(err) {
+			err = &ConstraintError{msg: err.Error(), wrap: err}
+		}
+		return nil, err
+	}
+	omuo.mutation.done = true
+	return _node, nil
+}
@@ -2,8 +2,4 @@
 
 package generated
 
-// The init function reads all schema descriptors with runtime code
-// (default values, validators, hooks and policies) and stitches it
-// to their package variables.
-func init() {
-}
+// The schema-stitching logic is generated in github.com/ryuudan/golang-rest-api/ent/generated/runtime/runtime.go
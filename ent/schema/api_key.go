@@ -0,0 +1,69 @@
+package schema
+
+import (
+	"time"
+
+	"entgo.io/ent"
+	"entgo.io/ent/schema/edge"
+	"entgo.io/ent/schema/field"
+)
+
+// APIKey is a personal key that lets integrations act on behalf of its user
+// without logging in, limited to its scopes. The prefix identifies the key and
+// is stored as is, of the secret only the SHA-256 hash is stored.
+type APIKey struct {
+	ent.Schema
+}
+
+// Fields of the APIKey.
+func (APIKey) Fields() []ent.Field {
+	return []ent.Field{
+		field.String("name").
+			NotEmpty().
+			StructTag(`json:"name"`),
+		field.String("prefix").
+			Unique().
+			NotEmpty().
+			Immutable().
+			StructTag(`json:"prefix"`),
+		field.String("secret_hash").
+			NotEmpty().
+			Immutable().
+			Sensitive(),
+		field.Strings("scopes").
+			Immutable().
+			StructTag(`json:"scopes"`),
+		field.Time("expires_at").
+			Optional().
+			Nillable().
+			Immutable().
+			StructTag(`json:"expires_at,omitempty"`),
+		field.Time("last_used_at").
+			Optional().
+			Nillable().
+			StructTag(`json:"last_used_at,omitempty"`),
+		field.Time("revoked_at").
+			Optional().
+			Nillable().
+			StructTag(`json:"revoked_at,omitempty"`),
+		field.Int("user_id").
+			Immutable().
+			StructTag(`json:"user_id"`),
+		field.Time("created_at").
+			Immutable().
+			Default(time.Now).
+			StructTag(`json:"created_at"`),
+	}
+}
+
+// Edges of the APIKey.
+func (APIKey) Edges() []ent.Edge {
+	return []ent.Edge{
+		edge.From("user", User.Type).
+			Ref("api_keys").
+			Field("user_id").
+			Unique().
+			Required().
+			Immutable(),
+	}
+}
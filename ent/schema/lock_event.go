@@ -0,0 +1,80 @@
+package schema
+
+import (
+	"time"
+
+	"entgo.io/ent"
+	"entgo.io/ent/schema/edge"
+	"entgo.io/ent/schema/field"
+	"entgo.io/ent/schema/index"
+)
+
+// LockEvent records every login lockout and every unlock, for auditing. Events
+// are never changed once written.
+type LockEvent struct {
+	ent.Schema
+}
+
+// Fields of the LockEvent.
+func (LockEvent) Fields() []ent.Field {
+	return []ent.Field{
+		field.Enum("scope").
+			Values("account", "ip").
+			Immutable().
+			StructTag(`json:"scope"`),
+		field.String("subject").
+			NotEmpty().
+			Immutable().
+			StructTag(`json:"subject"`),
+		field.Enum("action").
+			Values("locked", "unlocked").
+			Immutable().
+			StructTag(`json:"action"`),
+		field.Int("failures").
+			Default(0).
+			Immutable().
+			StructTag(`json:"failures"`),
+		field.Time("locked_until").
+			Optional().
+			Nillable().
+			Immutable().
+			StructTag(`json:"locked_until,omitempty"`),
+		field.String("ip").
+			Optional().
+			Nillable().
+			Immutable().
+			StructTag(`json:"ip,omitempty"`),
+		field.Int("user_id").
+			Optional().
+			Nillable().
+			Immutable().
+			StructTag(`json:"user_id,omitempty"`),
+		field.Int("actor_id").
+			Optional().
+			Nillable().
+			Immutable().
+			StructTag(`json:"actor_id,omitempty"`),
+		field.Time("created_at").
+			Immutable().
+			Default(time.Now).
+			StructTag(`json:"created_at"`),
+	}
+}
+
+// Edges of the LockEvent.
+func (LockEvent) Edges() []ent.Edge {
+	return []ent.Edge{
+		edge.From("user", User.Type).
+			Ref("lock_events").
+			Field("user_id").
+			Unique().
+			Immutable(),
+	}
+}
+
+// Indexes of the LockEvent.
+func (LockEvent) Indexes() []ent.Index {
+	return []ent.Index{
+		index.Fields("scope", "subject", "created_at"),
+	}
+}
@@ -0,0 +1,59 @@
+package schema
+
+import (
+	"time"
+
+	"entgo.io/ent"
+	"entgo.io/ent/dialect"
+	"entgo.io/ent/schema/field"
+	"entgo.io/ent/schema/index"
+)
+
+// OutboxMessage is an email waiting to be delivered. Messages are written in the
+// same transaction as the change they announce, so that they are sent if and
+// only if it is committed, and a worker delivers them afterwards.
+type OutboxMessage struct {
+	ent.Schema
+}
+
+// Fields of the OutboxMessage.
+func (OutboxMessage) Fields() []ent.Field {
+	return []ent.Field{
+		field.String("recipient").
+			NotEmpty().
+			Immutable().
+			StructTag(`json:"recipient"`),
+		field.String("subject").
+			Immutable().
+			StructTag(`json:"subject"`),
+		field.String("body").
+			Immutable().
+			SchemaType(map[string]string{dialect.Postgres: "text"}).
+			StructTag(`json:"body"`),
+		field.Int("attempts").
+			Default(0).
+			StructTag(`json:"attempts"`),
+		field.String("last_error").
+			Optional().
+			Nillable().
+			StructTag(`json:"last_error,omitempty"`),
+		field.Time("available_at").
+			Default(time.Now).
+			StructTag(`json:"available_at"`), // When the next delivery attempt is due
+		field.Time("sent_at").
+			Optional().
+			Nillable().
+			StructTag(`json:"sent_at,omitempty"`),
+		field.Time("created_at").
+			Immutable().
+			Default(time.Now).
+			StructTag(`json:"created_at"`),
+	}
+}
+
+// Indexes of the OutboxMessage.
+func (OutboxMessage) Indexes() []ent.Index {
+	return []ent.Index{
+		index.Fields("sent_at", "available_at"),
+	}
+}
@@ -0,0 +1,49 @@
+package schema
+
+import (
+	"time"
+
+	"entgo.io/ent"
+	"entgo.io/ent/schema/edge"
+	"entgo.io/ent/schema/field"
+)
+
+// RecoveryCode is a one-time code that stands in for a TOTP code when a user has
+// lost their authenticator. Only the SHA-256 hash of the code is stored.
+type RecoveryCode struct {
+	ent.Schema
+}
+
+// Fields of the RecoveryCode.
+func (RecoveryCode) Fields() []ent.Field {
+	return []ent.Field{
+		field.String("code_hash").
+			Unique().
+			NotEmpty().
+			Immutable().
+			Sensitive(),
+		field.Time("used_at").
+			Optional().
+			Nillable().
+			StructTag(`json:"used_at,omitempty"`),
+		field.Int("user_id").
+			Immutable().
+			StructTag(`json:"user_id"`),
+		field.Time("created_at").
+			Immutable().
+			Default(time.Now).
+			StructTag(`json:"created_at"`),
+	}
+}
+
+// Edges of the RecoveryCode.
+func (RecoveryCode) Edges() []ent.Edge {
+	return []ent.Edge{
+		edge.From("user", User.Type).
+			Ref("recovery_codes").
+			Field("user_id").
+			Unique().
+			Required().
+			Immutable(),
+	}
+}
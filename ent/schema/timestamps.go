@@ -0,0 +1,48 @@
+package schema
+
+import (
+	"context"
+	"time"
+
+	"entgo.io/ent"
+	"entgo.io/ent/schema/field"
+	"entgo.io/ent/schema/mixin"
+	"github.com/ryuudan/golang-rest-api/ent/generated/hook"
+)
+
+// TimeMixin adds created_at and updated_at timestamps to a schema. Both are set
+// when an entity is created, created_at can never change afterwards and
+// updated_at is refreshed by a hook on every update.
+type TimeMixin struct {
+	mixin.Schema
+}
+
+// Fields of the TimeMixin.
+func (TimeMixin) Fields() []ent.Field {
+	return []ent.Field{
+		field.Time("created_at").
+			Immutable().
+			Default(time.Now).
+			StructTag(`json:"created_at"`),
+		field.Time("updated_at").
+			Default(time.Now).
+			StructTag(`json:"updated_at"`),
+	}
+}
+
+// Hooks of the TimeMixin.
+func (TimeMixin) Hooks() []ent.Hook {
+	return []ent.Hook{
+		hook.On(
+			func(next ent.Mutator) ent.Mutator {
+				return ent.MutateFunc(func(ctx context.Context, m ent.Mutation) (ent.Value, error) {
+					if mx, ok := m.(interface{ SetUpdatedAt(time.Time) }); ok {
+						mx.SetUpdatedAt(time.Now())
+					}
+					return next.Mutate(ctx, m)
+				})
+			},
+			ent.OpUpdate|ent.OpUpdateOne,
+		),
+	}
+}
@@ -2,6 +2,8 @@ package schema
 
 import (
 	"entgo.io/ent"
+	"entgo.io/ent/dialect/entsql"
+	"entgo.io/ent/schema/edge"
 	"entgo.io/ent/schema/field"
 )
 
@@ -10,6 +12,14 @@ type User struct {
 	ent.Schema
 }
 
+// Mixin of the User.
+func (User) Mixin() []ent.Mixin {
+	return []ent.Mixin{
+		TimeMixin{},
+		SoftDeleteMixin{},
+	}
+}
+
 // Fields of the User.
 func (User) Fields() []ent.Field {
 	return []ent.Field{
@@ -33,11 +43,36 @@ func (User) Fields() []ent.Field {
 			Nillable().
 			StructTag(`json:"phone_number" validate:"e164"`),
 		field.String("password").
-			StructTag(`json:"password" validate:"required,min=3"`),
+			Sensitive(),
+		field.Time("email_verified_at").
+			Optional().
+			Nillable().
+			StructTag(`json:"email_verified_at"`),
+		field.String("totp_secret").
+			Optional().
+			Nillable().
+			Sensitive().
+			Comment("Encrypted with MFA_ENCRYPTION_KEY, never stored in plain text"),
+		field.Time("totp_enabled_at").
+			Optional().
+			Nillable().
+			StructTag(`json:"totp_enabled_at"`),
+		field.Enum("plan").
+			Values("free", "partner").
+			Default("free").
+			StructTag(`json:"plan"`),
 	}
 }
 
 // Edges of the User.
 func (User) Edges() []ent.Edge {
-	return nil
+	return []ent.Edge{
+		edge.To("roles", Role.Type),
+		edge.To("lock_events", LockEvent.Type).
+			Annotations(entsql.OnDelete(entsql.Cascade)),
+		edge.To("recovery_codes", RecoveryCode.Type).
+			Annotations(entsql.OnDelete(entsql.Cascade)),
+		edge.To("api_keys", APIKey.Type).
+			Annotations(entsql.OnDelete(entsql.Cascade)),
+	}
 }
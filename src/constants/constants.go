@@ -3,4 +3,131 @@ package constants
 import "time"
 
 const INVALID_FORMAT_ID = "Invalid or Malformed ID format"
+const EMAIL_IN_USE = "Email already in use"
+const EMAIL_HELD_BY_DELETED_USER = "Email belongs to a deleted account, restore or purge that account first"
+const PHONE_NUMBER_IN_USE = "Phone number already in use"
 const DEFAULT_CACHE_EXPIRATION = 7 * 24 * time.Hour // Seven days in hours
+const USERS_CACHE_NAMESPACE = "users"
+const USERS_LIST_CACHE_NAMESPACE = "users:list"
+const USER_PLANS_CACHE_NAMESPACE = "user_plans" // Read by the quota middleware on every request
+const USER_PLANS_CACHE_EXPIRATION = 10 * time.Minute
+const USERS_CACHE_TAG = "users" // Carried by every cached result that depends on more than one user
+const LIST_CACHE_EXPIRATION = 5 * time.Minute
+const CACHE_LOCK_EXPIRATION = 3 * time.Second           // How long one instance may take to refill a missing entry
+const CACHE_LOCK_RETRY_INTERVAL = 50 * time.Millisecond // How often waiting instances check whether it is done
+const CACHE_EARLY_REFRESH_BETA = 1.0                    // XFetch beta, higher values refresh entries earlier
+const CACHE_INVALIDATION_CHANNEL = "cache:invalidations"
+const REVOKED_TOKENS_CACHE_NAMESPACE = "revoked_tokens" // Never kept in the local cache, see RedisCache.EnableLocalCache
+const REVOKED_USERS_CACHE_NAMESPACE = "revoked_users"
+const DEFAULT_LOCAL_CACHE_EXPIRATION = 30 * time.Second
+const DEFAULT_CACHE_READ_TIMEOUT = 100 * time.Millisecond
+const DEFAULT_CACHE_WRITE_TIMEOUT = 200 * time.Millisecond
+const DEFAULT_CACHE_COMPRESSION_THRESHOLD = 1024 // Bytes, smaller values are not worth compressing
+
+// Password hashing defaults, see passwords.FromEnv for overriding them
+const ARGON2_MEMORY = 64 * 1024 // KiB
+const ARGON2_ITERATIONS = 3
+const ARGON2_PARALLELISM = 2
+const ARGON2_SALT_LENGTH = 16
+const ARGON2_KEY_LENGTH = 32
+const BCRYPT_COST = 10
+
+// Bounds of the Argon2id parameters a hash may have, both when decoding stored
+// hashes and when overriding the defaults
+const ARGON2_MAX_MEMORY = 4 * 1024 * 1024 // KiB
+const ARGON2_MAX_ITERATIONS = 64
+const ARGON2_MIN_SALT_LENGTH = 8
+const ARGON2_MAX_SALT_LENGTH = 64
+const ARGON2_MIN_KEY_LENGTH = 16
+const ARGON2_MAX_KEY_LENGTH = 64
+
+// Password rules defaults, see passwords.RulesFromEnv for overriding them
+const PASSWORD_MIN_LENGTH = 10
+const PASSWORD_MAX_LENGTH = 128
+const PASSWORD_MIN_SCORE = 3                                 // zxcvbn-style, from 0 to 4
+const PASSWORD_BREACHED_FILE = "data/breached_passwords.txt" // Sorted SHA-1 hashes, relative to the working directory
+
+const INVALID_CREDENTIALS = "Invalid email or password"
+const UNAUTHORIZED = "Missing or invalid access token"
+const ACCESS_TOKEN_EXPIRATION = 15 * time.Minute
+const INVALID_REFRESH_TOKEN = "Invalid or expired refresh token"
+const REFRESH_TOKEN_EXPIRATION = 30 * 24 * time.Hour // Thirty days in hours
+const INVALID_RESET_TOKEN = "Invalid or expired password reset token"
+const PASSWORD_RESET_TOKEN_EXPIRATION = 15 * time.Minute
+const INVALID_VERIFICATION_TOKEN = "Invalid or expired email verification token"
+const EMAIL_VERIFICATION_TOKEN_EXPIRATION = 24 * time.Hour
+
+// Delivery of queued outbox mail
+const OUTBOX_POLL_INTERVAL = 5 * time.Second
+const OUTBOX_BATCH_SIZE = 20
+const OUTBOX_MAX_ATTEMPTS = 10
+const OUTBOX_RETRY_BACKOFF = 30 * time.Second // Doubles after every failed attempt
+const OUTBOX_LEASE = 5 * time.Minute          // How long a claimed batch is kept from other workers while it is sent
+const SMTP_TIMEOUT = 30 * time.Second         // For delivering a single message, from dialing to QUIT
+
+// Failed logins per account; the first few are free, later ones back off exponentially
+const LOGIN_LOCKED = "Too many failed login attempts, please try again later"
+const ACCOUNT_LOGIN_FREE_FAILURES = 3
+const ACCOUNT_LOGIN_MAX_FAILURES = 10 // Failures before the account is locked out
+const ACCOUNT_LOCKOUT_DURATION = 15 * time.Minute
+
+// Failed logins per client IP, high enough for users sharing a NAT
+const IP_LOGIN_FREE_FAILURES = 20
+const IP_LOGIN_MAX_FAILURES = 100
+const IP_LOCKOUT_DURATION = time.Hour
+
+const LOGIN_FAILURE_WINDOW = time.Hour // How long failed logins are remembered
+const LOGIN_BASE_BACKOFF = time.Second
+const LOGIN_MAX_BACKOFF = 5 * time.Minute
+
+// Two-factor authentication
+const MFA_ISSUER = "golang-rest-api" // Shown next to the account in authenticator apps
+const MFA_TOKEN_EXPIRATION = 5 * time.Minute
+const MFA_RECOVERY_CODE_COUNT = 10
+const INVALID_MFA_TOKEN = "Invalid or expired MFA token"
+const INVALID_MFA_CODE = "Invalid authentication code"
+const INVALID_MFA_LOGIN_CODE = "Invalid authentication code, please log in again" // The MFA token is used up by a wrong code
+const MFA_ALREADY_ENABLED = "Two-factor authentication is already enabled"
+const MFA_NOT_ENROLLED = "Two-factor authentication was not set up"
+const MFA_NOT_ENABLED = "Two-factor authentication is not enabled"
+const MFA_REQUIRED = "Two-factor authentication must be enabled for admin accounts"
+
+// Personal API keys, shown to users as gra_<prefix>_<secret>
+const API_KEY_PREFIX = "gra_"
+const API_KEY_PREFIX_BYTES = 6
+const API_KEY_SECRET_BYTES = 32
+const API_KEY_LAST_USED_INTERVAL = time.Minute // How often the last use of a busy key is recorded
+const API_KEY_MAX_PER_USER = 20                // Active keys, revoked and expired ones do not count
+const API_KEY_NOT_FOUND = "API key not found"
+const SCOPE_NOT_GRANTED = "API keys can only be granted permissions you hold"
+const TOO_MANY_API_KEYS = "You have too many active API keys, revoke one before creating another"
+const INSUFFICIENT_SCOPE = "The API key is not allowed to perform this action"
+const SESSION_REQUIRED = "This action requires logging in and cannot be performed with an API key"
+
+const TOO_MANY_REQUESTS = "Too many requests, please try again later"
+const PUBLIC_RATE_LIMIT = 100 // Requests per client IP and period
+const PUBLIC_RATE_LIMIT_PERIOD = time.Minute
+const PRIVATE_RATE_LIMIT = 300 // Requests per client IP and period
+const PRIVATE_RATE_LIMIT_PERIOD = time.Minute
+
+// Limits of each plan, per authenticated user or API key
+const FREE_PLAN_HOURLY_LIMIT = 1_000
+const FREE_PLAN_DAILY_QUOTA = 10_000
+const FREE_PLAN_MONTHLY_QUOTA = 100_000
+const PARTNER_PLAN_HOURLY_LIMIT = 10_000
+const PARTNER_PLAN_DAILY_QUOTA = 150_000
+const PARTNER_PLAN_MONTHLY_QUOTA = 3_000_000
+
+const FORBIDDEN = "You do not have permission to perform this action"
+
+// Roles seeded on startup
+const ROLE_ADMIN = "admin"
+const ROLE_USER = "user"
+
+// Permissions seeded on startup
+const PERMISSION_USERS_READ = "users:read"
+const PERMISSION_USERS_WRITE = "users:write"
+const PERMISSION_USERS_DELETE = "users:delete"
+const PERMISSION_USERS_PURGE = "users:purge"
+
+const MAX_PAGE_LIMIT = 100
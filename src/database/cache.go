@@ -0,0 +1,311 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/ryuudan/golang-rest-api/src/constants"
+	"github.com/ryuudan/golang-rest-api/src/utils/tokens"
+	"golang.org/x/sync/singleflight"
+)
+
+// Namespace groups related cache entries under a common key prefix, so that an
+// entity with ID 42 in the "users" namespace is stored under "users:42".
+type Namespace struct {
+	cache  *RedisCache
+	prefix string
+}
+
+// Key returns the cache key of the entry with the given ID.
+func (n *Namespace) Key(id any) string {
+	return fmt.Sprintf("%s:%v", n.prefix, id)
+}
+
+// Invalidate drops the entries with the given IDs.
+func (n *Namespace) Invalidate(ctx context.Context, ids ...any) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	keys := make([]string, 0, len(ids))
+	for _, id := range ids {
+		keys = append(keys, n.Key(id))
+	}
+
+	return n.cache.del(ctx, keys...)
+}
+
+// unlockScript releases a lock only if it is still held by the given token, so
+// that an instance whose lock expired cannot release the lock of another one.
+var unlockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// Loader fetches a value from the source of truth when it is not cached.
+type Loader[T any] func(ctx context.Context) (T, error)
+
+type CacheOption func(*cacheOptions)
+
+type cacheOptions struct {
+	beta float64
+	tags []string
+}
+
+// WithEarlyRefresh enables probabilistic early refresh (XFetch). Every read may
+// reload an entry before it expires, with a probability that grows as expiry
+// gets closer and the longer the value takes to load, so that hot entries are
+// refreshed by a single request instead of expiring under load. Higher beta
+// values refresh earlier, 1 is a sensible default.
+func WithEarlyRefresh(beta float64) CacheOption {
+	return func(options *cacheOptions) {
+		options.beta = beta
+	}
+}
+
+// WithTags stores every entry of the cache with the given tags, so that they can
+// all be dropped at once with RedisCache.InvalidateTag.
+func WithTags(tags ...string) CacheOption {
+	return func(options *cacheOptions) {
+		options.tags = append(options.tags, tags...)
+	}
+}
+
+// entry is the stored form of a cached value. Delta is how long the value took to
+// load and Expiry when the entry expires, both in milliseconds, which is what
+// early refresh needs to decide when to reload it.
+type entry[T any] struct {
+	Value  T     `json:"v"`
+	Delta  int64 `json:"d,omitempty"`
+	Expiry int64 `json:"e,omitempty"`
+}
+
+// Cache is a typed cache-aside layer on top of a Namespace. Values are encoded with
+// the encoder of the RedisCache and decoded back into T, so callers never handle
+// raw bytes.
+//
+// Misses are protected against stampedes: concurrent fetches of the same entry in
+// one process share a single load, and across instances only the holder of a
+// short Redis lock loads it while the others wait for the result.
+//
+// Example:
+//
+//	users := NewCache[*models.UserResponse](cache.Namespace("users"), time.Hour)
+//	user, err := users.Fetch(ctx, 42, func(ctx context.Context) (*models.UserResponse, error) {
+//		...
+//	})
+type Cache[T any] struct {
+	*Namespace
+	expiration time.Duration
+	options    cacheOptions
+	group      singleflight.Group
+}
+
+func NewCache[T any](namespace *Namespace, expiration time.Duration, options ...CacheOption) *Cache[T] {
+	cache := &Cache[T]{
+		Namespace:  namespace,
+		expiration: expiration,
+	}
+
+	for _, option := range options {
+		option(&cache.options)
+	}
+
+	return cache
+}
+
+// Get returns the cached value with the given ID. It returns redis.Nil when
+// there is none.
+func (c *Cache[T]) Get(ctx context.Context, id any) (T, error) {
+	cached, err := c.get(ctx, id)
+	if err != nil {
+		var value T
+		return value, err
+	}
+
+	return cached.Value, nil
+}
+
+// Set caches a value under the given ID.
+func (c *Cache[T]) Set(ctx context.Context, id any, value T) error {
+	return c.set(ctx, id, value, 0)
+}
+
+// Fetch returns the cached value with the given ID, calling load and caching its
+// result on a miss. Entries that cannot be decoded, for example because they were
+// written by an older version of T, are treated as misses and overwritten. Errors
+// of load are returned as is.
+//
+// The cache is only an optimization, so when Redis fails or is too slow the value
+// is loaded directly and the failure is logged rather than returned.
+func (c *Cache[T]) Fetch(ctx context.Context, id any, load Loader[T]) (T, error) {
+	cached, err := c.get(ctx, id)
+	if err == nil && !c.shouldRefresh(cached) {
+		return cached.Value, nil
+	}
+
+	if err != nil && !isMiss(err) {
+		log.Printf("❌ Failed to read %s from the cache, loading it directly: %v", c.Key(id), err)
+		return load(ctx)
+	}
+
+	// The load is shared by every caller waiting on it, so it must not be
+	// cancelled along with the request that happened to start it
+	shared, err, _ := c.group.Do(c.Key(id), func() (interface{}, error) {
+		return c.refill(context.WithoutCancel(ctx), id, cached, load)
+	})
+
+	value, _ := shared.(T)
+	return value, err
+}
+
+// refill loads an entry while holding its Redis lock. When another instance holds
+// it, the current value is served if there is one, otherwise the caller waits for
+// the entry to appear and only loads it itself if that takes too long.
+func (c *Cache[T]) refill(ctx context.Context, id any, current *entry[T], load Loader[T]) (T, error) {
+	var value T
+
+	lockKey := fmt.Sprintf("lock:%s", c.Key(id))
+
+	token, err := tokens.RandomString(16)
+	if err != nil {
+		return value, err
+	}
+
+	locked, err := c.lock(ctx, lockKey, token)
+	if err != nil {
+		log.Printf("❌ Failed to lock %s, loading it without the lock: %v", c.Key(id), err)
+		return load(ctx)
+	}
+
+	if !locked {
+		if current != nil {
+			return current.Value, nil
+		}
+
+		if cached, err := c.wait(ctx, id); err == nil {
+			return cached.Value, nil
+		}
+
+		return c.load(ctx, id, load)
+	}
+
+	defer c.unlock(ctx, lockKey, token)
+
+	// The previous lock holder may have filled the entry just before we got the lock
+	if current == nil {
+		if cached, err := c.get(ctx, id); err == nil {
+			return cached.Value, nil
+		}
+	}
+
+	return c.load(ctx, id, load)
+}
+
+// wait polls for an entry being loaded by another instance until the lock it
+// holds would have expired.
+func (c *Cache[T]) wait(ctx context.Context, id any) (*entry[T], error) {
+	deadline := time.Now().Add(constants.CACHE_LOCK_EXPIRATION)
+
+	for time.Now().Before(deadline) {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(constants.CACHE_LOCK_RETRY_INTERVAL):
+		}
+
+		cached, err := c.get(ctx, id)
+		if !errors.Is(err, redis.Nil) {
+			return cached, err
+		}
+	}
+
+	return nil, redis.Nil
+}
+
+func (c *Cache[T]) load(ctx context.Context, id any, load Loader[T]) (T, error) {
+	start := time.Now()
+
+	value, err := load(ctx)
+	if err != nil {
+		return value, err
+	}
+
+	if err := c.set(ctx, id, value, time.Since(start)); err != nil {
+		log.Printf("❌ Failed to cache %s: %v", c.Key(id), err)
+	}
+
+	return value, nil
+}
+
+func (c *Cache[T]) lock(ctx context.Context, key string, token string) (bool, error) {
+	ctx, cancel := withTimeout(ctx, c.cache.timeouts.Write)
+	defer cancel()
+
+	return c.cache.client.SetNX(ctx, key, token, constants.CACHE_LOCK_EXPIRATION).Result()
+}
+
+func (c *Cache[T]) unlock(ctx context.Context, key string, token string) {
+	ctx, cancel := withTimeout(ctx, c.cache.timeouts.Write)
+	defer cancel()
+
+	// A lock that cannot be released simply expires
+	unlockScript.Run(ctx, c.cache.client, []string{key}, token)
+}
+
+func (c *Cache[T]) get(ctx context.Context, id any) (*entry[T], error) {
+	data, err := c.cache.get(ctx, c.Key(id))
+	if err != nil {
+		return nil, err
+	}
+
+	var cached entry[T]
+	if err := c.cache.encoder.Decode(data, &cached); err != nil {
+		return nil, err
+	}
+
+	return &cached, nil
+}
+
+func (c *Cache[T]) set(ctx context.Context, id any, value T, delta time.Duration) error {
+	cached := entry[T]{
+		Value: value,
+		Delta: delta.Milliseconds(),
+	}
+
+	if c.expiration > 0 {
+		cached.Expiry = time.Now().Add(c.expiration).UnixMilli()
+	}
+
+	data, err := c.cache.encoder.Encode(cached)
+	if err != nil {
+		return err
+	}
+
+	return c.cache.set(ctx, c.Key(id), data, c.expiration, c.options.tags...)
+}
+
+// shouldRefresh implements the XFetch check: an entry is refreshed early once
+// now - delta * beta * ln(rand) reaches its expiry.
+func (c *Cache[T]) shouldRefresh(cached *entry[T]) bool {
+	if c.options.beta <= 0 || cached.Expiry == 0 {
+		return false
+	}
+
+	gap := float64(cached.Delta) * c.options.beta * -math.Log(rand.Float64())
+
+	return float64(time.Now().UnixMilli())+gap >= float64(cached.Expiry)
+}
+
+// isMiss reports whether err means that the entry has to be loaded again.
+func isMiss(err error) bool {
+	return errors.Is(err, redis.Nil) || errors.Is(err, ErrUndecodable)
+}
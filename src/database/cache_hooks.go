@@ -0,0 +1,107 @@
+package database
+
+import (
+	"context"
+	"log"
+
+	"entgo.io/ent"
+	"github.com/ryuudan/golang-rest-api/ent/generated"
+	"github.com/ryuudan/golang-rest-api/ent/generated/hook"
+)
+
+// identifiable is implemented by every generated mutation of an entity with int IDs.
+type identifiable interface {
+	IDs(ctx context.Context) ([]int, error)
+	Tx() (*generated.Tx, error)
+}
+
+// InvalidateOnMutation returns a hook that drops the cached entries of every
+// entity changed by an update or a delete, whichever code path makes it. Inside a
+// transaction the entries are dropped once it commits, so that a concurrent read
+// cannot cache the old row again in the meantime.
+//
+// Example:
+//
+//	client.User.Use(database.InvalidateOnMutation(cache.Namespace("users")))
+func InvalidateOnMutation(namespace *Namespace) ent.Hook {
+	return hook.On(
+		invalidateAfter(namespace.prefix, func(ctx context.Context, ids []int) error {
+			keys := make([]any, 0, len(ids))
+			for _, id := range ids {
+				keys = append(keys, id)
+			}
+			return namespace.Invalidate(ctx, keys...)
+		}),
+		ent.OpUpdate|ent.OpUpdateOne|ent.OpDelete|ent.OpDeleteOne,
+	)
+}
+
+// InvalidateTagsOnMutation returns a hook that drops every cache entry tagged
+// with one of the tags returned for the changed entities, after any mutation.
+// Creates pass no IDs, since the entities do not exist yet. Like
+// InvalidateOnMutation, it waits for the transaction to commit if there is one.
+//
+// Example:
+//
+//	client.User.Use(database.InvalidateTagsOnMutation(cache, func(ids []int) []string {
+//		return []string{"users"}
+//	}))
+func InvalidateTagsOnMutation(cache *RedisCache, tags func(ids []int) []string) ent.Hook {
+	return invalidateAfter("tagged", func(ctx context.Context, ids []int) error {
+		return cache.InvalidateTag(ctx, tags(ids)...)
+	})
+}
+
+// invalidateAfter returns a hook that resolves the IDs touched by a mutation and
+// calls invalidate with them once the mutation is stored.
+func invalidateAfter(name string, invalidate func(ctx context.Context, ids []int) error) ent.Hook {
+	return func(next ent.Mutator) ent.Mutator {
+		return ent.MutateFunc(func(ctx context.Context, m ent.Mutation) (ent.Value, error) {
+			mx, ok := m.(identifiable)
+			if !ok {
+				return next.Mutate(ctx, m)
+			}
+
+			var ids []int
+
+			// Resolve the IDs first, deleted rows cannot be looked up afterwards
+			if !m.Op().Is(ent.OpCreate) {
+				var err error
+				if ids, err = mx.IDs(ctx); err != nil {
+					return nil, err
+				}
+			}
+
+			value, err := next.Mutate(ctx, m)
+			if err != nil {
+				return nil, err
+			}
+
+			// Failing the mutation once it is stored would misreport it, so errors
+			// are only logged and the entries expire on their own
+			run := func(ctx context.Context) {
+				if err := invalidate(ctx, ids); err != nil {
+					log.Printf("❌ Failed to invalidate %s cache entries %v: %v", name, ids, err)
+				}
+			}
+
+			tx, err := mx.Tx()
+			if err != nil {
+				run(ctx)
+				return value, nil
+			}
+
+			tx.OnCommit(func(next generated.Committer) generated.Committer {
+				return generated.CommitFunc(func(ctx context.Context, tx *generated.Tx) error {
+					if err := next.Commit(ctx, tx); err != nil {
+						return err
+					}
+					run(ctx)
+					return nil
+				})
+			})
+
+			return value, nil
+		})
+	}
+}
@@ -0,0 +1,233 @@
+package database
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/ryuudan/golang-rest-api/src/constants"
+)
+
+// newTestCache returns a cache backed by a fresh miniredis server.
+func newTestCache(t *testing.T) (*RedisCache, *miniredis.Miniredis) {
+	t.Helper()
+
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	timeouts := CacheTimeouts{Read: time.Second, Write: time.Second}
+
+	return NewRedisCache(client, timeouts, NewEncoder(JSONCodec{}, CompressionNone, 0)), server
+}
+
+func TestFetchCoalescesConcurrentMisses(t *testing.T) {
+	cache, _ := newTestCache(t)
+	users := NewCache[string](cache.Namespace("users"), time.Minute)
+
+	var calls atomic.Int32
+	release := make(chan struct{})
+
+	load := func(ctx context.Context) (string, error) {
+		calls.Add(1)
+		<-release
+		return "ada", nil
+	}
+
+	const callers = 20
+
+	var wg sync.WaitGroup
+	results := make([]string, callers)
+
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			value, err := users.Fetch(context.Background(), 1, load)
+			if err != nil {
+				t.Errorf("Fetch: %v", err)
+			}
+			results[i] = value
+		}(i)
+	}
+
+	// Give every caller the time to join the load in flight
+	time.Sleep(100 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if got := calls.Load(); got != 1 {
+		t.Errorf("load called %d times, want 1", got)
+	}
+
+	for i, value := range results {
+		if value != "ada" {
+			t.Errorf("caller %d got %q, want %q", i, value, "ada")
+		}
+	}
+
+	if cached, err := users.Get(context.Background(), 1); err != nil || cached != "ada" {
+		t.Errorf("Get = %q, %v, want the loaded value", cached, err)
+	}
+}
+
+func TestFetchWaitsForTheLockHolder(t *testing.T) {
+	cache, server := newTestCache(t)
+	users := NewCache[string](cache.Namespace("users"), time.Minute)
+
+	// Another instance is loading the entry
+	server.Set("lock:users:1", "other-instance")
+
+	go func() {
+		time.Sleep(2 * constants.CACHE_LOCK_RETRY_INTERVAL)
+		if err := users.Set(context.Background(), 1, "from the other instance"); err != nil {
+			t.Errorf("Set: %v", err)
+		}
+	}()
+
+	value, err := users.Fetch(context.Background(), 1, func(ctx context.Context) (string, error) {
+		t.Error("load must not be called while another instance fills the entry")
+		return "loaded", nil
+	})
+
+	if err != nil || value != "from the other instance" {
+		t.Errorf("Fetch = %q, %v, want the value of the lock holder", value, err)
+	}
+
+	// The lock of the other instance is left alone
+	if holder, _ := server.Get("lock:users:1"); holder != "other-instance" {
+		t.Errorf("lock = %q, want it still held by the other instance", holder)
+	}
+}
+
+func TestFetchLoadsWhenTheLockHolderGivesUp(t *testing.T) {
+	if testing.Short() {
+		t.Skip("waits for the lock to expire")
+	}
+
+	cache, server := newTestCache(t)
+	users := NewCache[string](cache.Namespace("users"), time.Minute)
+
+	server.Set("lock:users:1", "crashed-instance")
+
+	var calls atomic.Int32
+
+	start := time.Now()
+	value, err := users.Fetch(context.Background(), 1, func(ctx context.Context) (string, error) {
+		calls.Add(1)
+		return "loaded", nil
+	})
+
+	if err != nil || value != "loaded" {
+		t.Fatalf("Fetch = %q, %v, want the loaded value", value, err)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("load called %d times, want 1", calls.Load())
+	}
+	if waited := time.Since(start); waited < constants.CACHE_LOCK_EXPIRATION {
+		t.Errorf("loaded after %v, want it to wait for the lock to expire", waited)
+	}
+}
+
+func TestFetchReleasesItsLock(t *testing.T) {
+	cache, server := newTestCache(t)
+	users := NewCache[string](cache.Namespace("users"), time.Minute)
+
+	_, err := users.Fetch(context.Background(), 1, func(ctx context.Context) (string, error) {
+		if !server.Exists("lock:users:1") {
+			t.Error("load should run while holding the lock")
+		}
+		return "loaded", nil
+	})
+
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if server.Exists("lock:users:1") {
+		t.Error("lock still held after the load")
+	}
+}
+
+func TestShouldRefresh(t *testing.T) {
+	now := time.Now().UnixMilli()
+
+	tests := []struct {
+		name  string
+		beta  float64
+		entry entry[string]
+		want  bool
+	}{
+		{"disabled", 0, entry[string]{Delta: 1e9, Expiry: now + 1000}, false},
+		{"no expiry", 1, entry[string]{Delta: 1e9}, false},
+		{"far from expiry", 1, entry[string]{Delta: 1, Expiry: now + int64(time.Hour/time.Millisecond)}, false},
+		{"expired", 1, entry[string]{Delta: 0, Expiry: now - 1}, true},
+		{"slow load close to expiry", 1, entry[string]{Delta: 1e12, Expiry: now + 1000}, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cache := &Cache[string]{options: cacheOptions{beta: test.beta}}
+
+			if got := cache.shouldRefresh(&test.entry); got != test.want {
+				t.Errorf("shouldRefresh = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestFetchRefreshesEarly(t *testing.T) {
+	cache, server := newTestCache(t)
+	users := NewCache[string](cache.Namespace("users"), time.Minute, WithEarlyRefresh(1))
+
+	// A value that took very long to load and expires soon is due for a refresh
+	stale, err := cache.encoder.Encode(entry[string]{
+		Value:  "stale",
+		Delta:  1e12,
+		Expiry: time.Now().Add(time.Second).UnixMilli(),
+	})
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	server.Set("users:1", string(stale))
+
+	value, err := users.Fetch(context.Background(), 1, func(ctx context.Context) (string, error) {
+		return "fresh", nil
+	})
+
+	if err != nil || value != "fresh" {
+		t.Errorf("Fetch = %q, %v, want the refreshed value", value, err)
+	}
+
+	if cached, _ := users.Get(context.Background(), 1); cached != "fresh" {
+		t.Errorf("cached value = %q, want the refreshed value", cached)
+	}
+}
+
+func TestFetchServesStaleValueWhileAnotherInstanceRefreshes(t *testing.T) {
+	cache, server := newTestCache(t)
+	users := NewCache[string](cache.Namespace("users"), time.Minute, WithEarlyRefresh(1))
+
+	stale, err := cache.encoder.Encode(entry[string]{
+		Value:  "stale",
+		Delta:  1e12,
+		Expiry: time.Now().Add(time.Second).UnixMilli(),
+	})
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	server.Set("users:1", string(stale))
+	server.Set("lock:users:1", "other-instance")
+
+	value, err := users.Fetch(context.Background(), 1, func(ctx context.Context) (string, error) {
+		t.Error("load must not be called while another instance refreshes the entry")
+		return "fresh", nil
+	})
+
+	if err != nil || value != "stale" {
+		t.Errorf("Fetch = %q, %v, want the current value", value, err)
+	}
+}
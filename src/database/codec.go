@@ -0,0 +1,281 @@
+package database
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/ryuudan/golang-rest-api/src/constants"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// ErrUndecodable is returned for cache entries that cannot be decoded, because
+// they are corrupt or were written in a format this version does not know.
+var ErrUndecodable = errors.New("cache entry cannot be decoded")
+
+// Codec serializes cache values. Every codec has a unique ID, which is stored in
+// the header of each entry so that entries written with another codec can still
+// be read after the configured codec changes.
+type Codec interface {
+	ID() byte
+	Marshal(value interface{}) ([]byte, error)
+	Unmarshal(data []byte, value interface{}) error
+}
+
+type JSONCodec struct{}
+
+func (JSONCodec) ID() byte { return 1 }
+
+func (JSONCodec) Marshal(value interface{}) ([]byte, error) {
+	return json.Marshal(value)
+}
+
+func (JSONCodec) Unmarshal(data []byte, value interface{}) error {
+	return json.Unmarshal(data, value)
+}
+
+// MessagePackCodec encodes values as MessagePack. It honours the json struct tags,
+// so values are shaped the same as with JSONCodec, only smaller and faster.
+type MessagePackCodec struct{}
+
+func (MessagePackCodec) ID() byte { return 2 }
+
+func (MessagePackCodec) Marshal(value interface{}) ([]byte, error) {
+	var buffer bytes.Buffer
+
+	encoder := msgpack.NewEncoder(&buffer)
+	encoder.SetCustomStructTag("json")
+
+	if err := encoder.Encode(value); err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+func (MessagePackCodec) Unmarshal(data []byte, value interface{}) error {
+	decoder := msgpack.NewDecoder(bytes.NewReader(data))
+	decoder.SetCustomStructTag("json")
+
+	return decoder.Decode(value)
+}
+
+// GobCodec encodes values with encoding/gob. It only suits values whose fields
+// are all exported, since gob ignores struct tags.
+type GobCodec struct{}
+
+func (GobCodec) ID() byte { return 3 }
+
+func (GobCodec) Marshal(value interface{}) ([]byte, error) {
+	var buffer bytes.Buffer
+
+	if err := gob.NewEncoder(&buffer).Encode(value); err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+func (GobCodec) Unmarshal(data []byte, value interface{}) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(value)
+}
+
+var codecs = map[byte]Codec{}
+
+func init() {
+	for _, codec := range []Codec{JSONCodec{}, MessagePackCodec{}, GobCodec{}} {
+		codecs[codec.ID()] = codec
+	}
+}
+
+type Compression byte
+
+const (
+	CompressionNone Compression = iota
+	CompressionGzip
+	CompressionZstd
+)
+
+// The zstd encoder and decoder are safe for concurrent use through EncodeAll and
+// DecodeAll, and expensive to create, so they are shared.
+var (
+	zstdEncoder = mustZstdEncoder()
+	zstdDecoder = mustZstdDecoder()
+)
+
+// mustZstdEncoder only fails for invalid options, which is a programming error.
+func mustZstdEncoder() *zstd.Encoder {
+	encoder, err := zstd.NewWriter(nil)
+	if err != nil {
+		panic(fmt.Sprintf("zstd encoder: %v", err))
+	}
+	return encoder
+}
+
+func mustZstdDecoder() *zstd.Decoder {
+	decoder, err := zstd.NewReader(nil)
+	if err != nil {
+		panic(fmt.Sprintf("zstd decoder: %v", err))
+	}
+	return decoder
+}
+
+func compress(compression Compression, data []byte) ([]byte, error) {
+	switch compression {
+	case CompressionGzip:
+		var buffer bytes.Buffer
+
+		writer := gzip.NewWriter(&buffer)
+		if _, err := writer.Write(data); err != nil {
+			return nil, err
+		}
+		if err := writer.Close(); err != nil {
+			return nil, err
+		}
+
+		return buffer.Bytes(), nil
+	case CompressionZstd:
+		return zstdEncoder.EncodeAll(data, nil), nil
+	default:
+		return data, nil
+	}
+}
+
+func decompress(compression Compression, data []byte) ([]byte, error) {
+	switch compression {
+	case CompressionNone:
+		return data, nil
+	case CompressionGzip:
+		reader, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		defer reader.Close()
+
+		return io.ReadAll(reader)
+	case CompressionZstd:
+		return zstdDecoder.DecodeAll(data, nil)
+	default:
+		return nil, fmt.Errorf("unknown compression %d", compression)
+	}
+}
+
+// formatVersion is the first byte of every entry. Entries that do not start with
+// it are read as the plain JSON written before entries had a header.
+const formatVersion byte = 1
+
+// Encoder turns values into cache entries and back. Entries start with a header
+// of three bytes, the format version, the codec ID and the compression, followed
+// by the payload, which is only compressed when it is larger than the threshold.
+type Encoder struct {
+	codec       Codec
+	compression Compression
+	threshold   int
+}
+
+func NewEncoder(codec Codec, compression Compression, threshold int) *Encoder {
+	return &Encoder{
+		codec:       codec,
+		compression: compression,
+		threshold:   threshold,
+	}
+}
+
+// EncoderFromEnv configures an encoder from CACHE_CODEC (json, msgpack or gob),
+// CACHE_COMPRESSION (none, gzip or zstd) and CACHE_COMPRESSION_THRESHOLD (bytes).
+// It defaults to uncompressed JSON.
+func EncoderFromEnv() (*Encoder, error) {
+	var codec Codec
+
+	switch name := os.Getenv("CACHE_CODEC"); name {
+	case "", "json":
+		codec = JSONCodec{}
+	case "msgpack":
+		codec = MessagePackCodec{}
+	case "gob":
+		codec = GobCodec{}
+	default:
+		return nil, fmt.Errorf("unknown CACHE_CODEC %q", name)
+	}
+
+	var compression Compression
+
+	switch name := os.Getenv("CACHE_COMPRESSION"); name {
+	case "", "none":
+		compression = CompressionNone
+	case "gzip":
+		compression = CompressionGzip
+	case "zstd":
+		compression = CompressionZstd
+	default:
+		return nil, fmt.Errorf("unknown CACHE_COMPRESSION %q", name)
+	}
+
+	threshold := constants.DEFAULT_CACHE_COMPRESSION_THRESHOLD
+
+	if value := os.Getenv("CACHE_COMPRESSION_THRESHOLD"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CACHE_COMPRESSION_THRESHOLD: %w", err)
+		}
+		threshold = parsed
+	}
+
+	return NewEncoder(codec, compression, threshold), nil
+}
+
+func (e *Encoder) Encode(value interface{}) ([]byte, error) {
+	payload, err := e.codec.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	compression := CompressionNone
+
+	if e.compression != CompressionNone && len(payload) > e.threshold {
+		if payload, err = compress(e.compression, payload); err != nil {
+			return nil, err
+		}
+		compression = e.compression
+	}
+
+	return append([]byte{formatVersion, e.codec.ID(), byte(compression)}, payload...), nil
+}
+
+// Decode reads an entry written by any encoder, whatever its codec and
+// compression. It returns ErrUndecodable when that fails.
+func (e *Encoder) Decode(data []byte, value interface{}) error {
+	if len(data) == 0 || data[0] != formatVersion {
+		return undecodable(json.Unmarshal(data, value))
+	}
+
+	if len(data) < 3 {
+		return ErrUndecodable
+	}
+
+	codec, ok := codecs[data[1]]
+	if !ok {
+		return fmt.Errorf("%w: unknown codec %d", ErrUndecodable, data[1])
+	}
+
+	payload, err := decompress(Compression(data[2]), data[3:])
+	if err != nil {
+		return undecodable(err)
+	}
+
+	return undecodable(codec.Unmarshal(payload, value))
+}
+
+func undecodable(err error) error {
+	if err == nil {
+		return nil
+	}
+	return fmt.Errorf("%w: %v", ErrUndecodable, err)
+}
@@ -0,0 +1,126 @@
+package database
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+type codecValue struct {
+	Name  string   `json:"name"`
+	Count int      `json:"count"`
+	Tags  []string `json:"tags"`
+}
+
+func TestEncoderRoundTrip(t *testing.T) {
+	small := codecValue{Name: "ada", Count: 1, Tags: []string{"a"}}
+	large := codecValue{Name: string(bytes.Repeat([]byte("lovelace "), 200)), Count: 2}
+
+	tests := []struct {
+		name        string
+		codec       Codec
+		compression Compression
+		value       codecValue
+		compressed  bool
+	}{
+		{"json", JSONCodec{}, CompressionNone, small, false},
+		{"msgpack", MessagePackCodec{}, CompressionNone, small, false},
+		{"gob", GobCodec{}, CompressionNone, small, false},
+		{"gzip below threshold", JSONCodec{}, CompressionGzip, small, false},
+		{"gzip", JSONCodec{}, CompressionGzip, large, true},
+		{"zstd", MessagePackCodec{}, CompressionZstd, large, true},
+		{"gob zstd", GobCodec{}, CompressionZstd, large, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			encoder := NewEncoder(test.codec, test.compression, 64)
+
+			data, err := encoder.Encode(test.value)
+			if err != nil {
+				t.Fatalf("Encode: %v", err)
+			}
+
+			wantCompression := CompressionNone
+			if test.compressed {
+				wantCompression = test.compression
+			}
+
+			header := []byte{formatVersion, test.codec.ID(), byte(wantCompression)}
+			if !bytes.Equal(data[:3], header) {
+				t.Errorf("header = %v, want %v", data[:3], header)
+			}
+
+			var decoded codecValue
+			if err := encoder.Decode(data, &decoded); err != nil {
+				t.Fatalf("Decode: %v", err)
+			}
+
+			if decoded.Name != test.value.Name || decoded.Count != test.value.Count || len(decoded.Tags) != len(test.value.Tags) {
+				t.Errorf("decoded = %+v, want %+v", decoded, test.value)
+			}
+		})
+	}
+}
+
+// Entries are read whatever the configured codec, so changing CACHE_CODEC or
+// CACHE_COMPRESSION does not invalidate what is already cached.
+func TestDecodeEntriesOfOtherEncoders(t *testing.T) {
+	value := codecValue{Name: string(bytes.Repeat([]byte("grace "), 100)), Count: 3}
+
+	written, err := NewEncoder(GobCodec{}, CompressionZstd, 0).Encode(value)
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+
+	var decoded codecValue
+	if err := NewEncoder(JSONCodec{}, CompressionNone, 0).Decode(written, &decoded); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+
+	if decoded.Name != value.Name || decoded.Count != value.Count {
+		t.Errorf("decoded = %+v, want %+v", decoded, value)
+	}
+}
+
+func TestDecodeLegacyAndCorruptEntries(t *testing.T) {
+	encoder := NewEncoder(MessagePackCodec{}, CompressionZstd, 0)
+
+	tests := []struct {
+		name    string
+		data    []byte
+		want    codecValue
+		wantErr bool
+	}{
+		{"legacy json", []byte(`{"name":"ada","count":1}`), codecValue{Name: "ada", Count: 1}, false},
+		{"empty", []byte{}, codecValue{}, true},
+		{"legacy garbage", []byte("not json"), codecValue{}, true},
+		{"truncated header", []byte{formatVersion, 1}, codecValue{}, true},
+		{"unknown codec", []byte{formatVersion, 99, 0, '{', '}'}, codecValue{}, true},
+		{"unknown compression", []byte{formatVersion, 1, 99, '{', '}'}, codecValue{}, true},
+		{"corrupt gzip", []byte{formatVersion, 1, byte(CompressionGzip), 1, 2, 3}, codecValue{}, true},
+		{"corrupt zstd", []byte{formatVersion, 1, byte(CompressionZstd), 1, 2, 3}, codecValue{}, true},
+		{"corrupt payload", []byte{formatVersion, 1, 0, '{'}, codecValue{}, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var decoded codecValue
+			err := encoder.Decode(test.data, &decoded)
+
+			if test.wantErr {
+				if !errors.Is(err, ErrUndecodable) {
+					t.Errorf("err = %v, want ErrUndecodable", err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Decode: %v", err)
+			}
+			if decoded.Name != test.want.Name || decoded.Count != test.want.Count {
+				t.Errorf("decoded = %+v, want %+v", decoded, test.want)
+			}
+		})
+	}
+}
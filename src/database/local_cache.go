@@ -0,0 +1,148 @@
+package database
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// LocalCache is a bounded in-process LRU cache of raw values. It is meant to sit
+// in front of Redis as a first level cache, so entries only live for a short TTL
+// and are dropped as soon as another instance broadcasts that they changed.
+type LocalCache struct {
+	mu         sync.Mutex
+	size       int
+	ttl        time.Duration
+	items      map[string]*list.Element
+	order      *list.List // Most recently used entries are at the front
+	generation uint64     // Incremented by every invalidation, see Fill
+}
+
+type localItem struct {
+	key       string
+	value     []byte
+	expiresAt time.Time
+}
+
+// NewLocalCache returns a cache holding at most size entries, each for at most ttl.
+func NewLocalCache(size int, ttl time.Duration) *LocalCache {
+	return &LocalCache{
+		size:  size,
+		ttl:   ttl,
+		items: make(map[string]*list.Element, size),
+		order: list.New(),
+	}
+}
+
+// Get returns the value stored under key, if it is there and has not expired.
+func (l *LocalCache) Get(key string) ([]byte, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	element, ok := l.items[key]
+	if !ok {
+		return nil, false
+	}
+
+	item := element.Value.(*localItem)
+	if time.Now().After(item.expiresAt) {
+		l.remove(element)
+		return nil, false
+	}
+
+	l.order.MoveToFront(element)
+
+	return item.value, true
+}
+
+// Set stores a value under key, evicting the least recently used entry when the
+// cache is full. The entry expires after the TTL of the cache, or after ttl when
+// that is positive and shorter, so that it never outlives its Redis counterpart.
+func (l *LocalCache) Set(key string, value []byte, ttl time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.set(key, value, ttl)
+}
+
+// Generation returns the current generation of the cache, to be passed to Fill.
+func (l *LocalCache) Generation() uint64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.generation
+}
+
+// Fill stores a value read from Redis like Set, unless any entry was invalidated
+// since generation was read. A value read before an invalidation may be the one it
+// replaced, and writing it back would serve it until it expires.
+//
+// Example:
+//
+//	generation := local.Generation()
+//	value, err := client.Get(ctx, key).Bytes()
+//	...
+//	local.Fill(key, value, ttl, generation)
+func (l *LocalCache) Fill(key string, value []byte, ttl time.Duration, generation uint64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.generation != generation {
+		return
+	}
+
+	l.set(key, value, ttl)
+}
+
+func (l *LocalCache) set(key string, value []byte, ttl time.Duration) {
+	if ttl <= 0 || ttl > l.ttl {
+		ttl = l.ttl
+	}
+
+	if element, ok := l.items[key]; ok {
+		item := element.Value.(*localItem)
+		item.value = value
+		item.expiresAt = time.Now().Add(ttl)
+		l.order.MoveToFront(element)
+		return
+	}
+
+	l.items[key] = l.order.PushFront(&localItem{
+		key:       key,
+		value:     value,
+		expiresAt: time.Now().Add(ttl),
+	})
+
+	for l.order.Len() > l.size {
+		l.remove(l.order.Back())
+	}
+}
+
+// Delete drops the entries stored under the given keys.
+func (l *LocalCache) Delete(keys ...string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.generation++
+
+	for _, key := range keys {
+		if element, ok := l.items[key]; ok {
+			l.remove(element)
+		}
+	}
+}
+
+// Clear drops every entry.
+func (l *LocalCache) Clear() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.generation++
+	l.items = make(map[string]*list.Element, l.size)
+	l.order.Init()
+}
+
+func (l *LocalCache) remove(element *list.Element) {
+	l.order.Remove(element)
+	delete(l.items, element.Value.(*localItem).key)
+}
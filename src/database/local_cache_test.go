@@ -0,0 +1,76 @@
+package database
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLocalCacheFill(t *testing.T) {
+	tests := []struct {
+		name       string
+		invalidate func(local *LocalCache)
+		want       bool
+	}{
+		{"unchanged", func(local *LocalCache) {}, true},
+		{"same key deleted", func(local *LocalCache) { local.Delete("users:1") }, false},
+		{"other key deleted", func(local *LocalCache) { local.Delete("users:2") }, false},
+		{"cleared", func(local *LocalCache) { local.Clear() }, false},
+		{"unrelated set", func(local *LocalCache) { local.Set("users:2", []byte("grace"), 0) }, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			local := NewLocalCache(10, time.Minute)
+
+			generation := local.Generation()
+			test.invalidate(local)
+			local.Fill("users:1", []byte("ada"), 0, generation)
+
+			if _, ok := local.Get("users:1"); ok != test.want {
+				t.Errorf("filled = %v, want %v", ok, test.want)
+			}
+		})
+	}
+}
+
+func TestLocalCacheTTL(t *testing.T) {
+	tests := []struct {
+		name string
+		ttl  time.Duration
+		want time.Duration
+	}{
+		{"default", 0, time.Minute},
+		{"shorter", time.Second, time.Second},
+		{"longer is capped", time.Hour, time.Minute},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			local := NewLocalCache(10, time.Minute)
+
+			before := time.Now()
+			local.Set("users:1", []byte("ada"), test.ttl)
+
+			expiresAt := local.items["users:1"].Value.(*localItem).expiresAt
+			if got := expiresAt.Sub(before); got < test.want || got > test.want+time.Second {
+				t.Errorf("entry expires after %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestLocalCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	local := NewLocalCache(2, time.Minute)
+
+	local.Set("a", []byte("1"), 0)
+	local.Set("b", []byte("2"), 0)
+	local.Get("a")
+	local.Set("c", []byte("3"), 0)
+
+	if _, ok := local.Get("b"); ok {
+		t.Error("b should have been evicted")
+	}
+	if _, ok := local.Get("a"); !ok {
+		t.Error("a should have been kept")
+	}
+}
@@ -0,0 +1,23 @@
+CREATE TABLE "roles" (
+    "id" serial PRIMARY KEY,
+    "name" character varying NOT NULL UNIQUE,
+    "description" character varying
+);
+
+CREATE TABLE "permissions" (
+    "id" serial PRIMARY KEY,
+    "name" character varying NOT NULL UNIQUE,
+    "description" character varying
+);
+
+CREATE TABLE "role_permissions" (
+    "role_id" integer NOT NULL REFERENCES "roles" ("id") ON DELETE CASCADE,
+    "permission_id" integer NOT NULL REFERENCES "permissions" ("id") ON DELETE CASCADE,
+    PRIMARY KEY ("role_id", "permission_id")
+);
+
+CREATE TABLE "user_roles" (
+    "user_id" integer NOT NULL REFERENCES "users" ("id") ON DELETE CASCADE,
+    "role_id" integer NOT NULL REFERENCES "roles" ("id") ON DELETE CASCADE,
+    PRIMARY KEY ("user_id", "role_id")
+);
@@ -0,0 +1,5 @@
+UPDATE "users" SET "created_at" = current_timestamp WHERE "created_at" IS NULL;
+UPDATE "users" SET "updated_at" = "created_at" WHERE "updated_at" IS NULL;
+ALTER TABLE "users" ALTER COLUMN "created_at" SET NOT NULL, ALTER COLUMN "updated_at" SET NOT NULL;
+ALTER TABLE "roles" ADD COLUMN "created_at" timestamp with time zone NOT NULL DEFAULT current_timestamp, ADD COLUMN "updated_at" timestamp with time zone NOT NULL DEFAULT current_timestamp;
+ALTER TABLE "permissions" ADD COLUMN "created_at" timestamp with time zone NOT NULL DEFAULT current_timestamp, ADD COLUMN "updated_at" timestamp with time zone NOT NULL DEFAULT current_timestamp;
@@ -0,0 +1 @@
+ALTER TABLE "users" ADD COLUMN "plan" character varying NOT NULL DEFAULT 'free';
@@ -0,0 +1,14 @@
+CREATE TABLE "lock_events" (
+    "id" serial PRIMARY KEY,
+    "scope" character varying NOT NULL,
+    "subject" character varying NOT NULL,
+    "action" character varying NOT NULL,
+    "failures" integer NOT NULL DEFAULT 0,
+    "locked_until" timestamp with time zone,
+    "ip" character varying,
+    "actor_id" integer,
+    "created_at" timestamp with time zone NOT NULL,
+    "user_id" integer REFERENCES "users" ("id") ON DELETE CASCADE
+);
+
+CREATE INDEX "lockevent_scope_subject_created_at" ON "lock_events" ("scope", "subject", "created_at");
@@ -0,0 +1,15 @@
+ALTER TABLE "users" ADD COLUMN "email_verified_at" timestamp with time zone;
+
+CREATE TABLE "outbox_messages" (
+    "id" serial PRIMARY KEY,
+    "recipient" character varying NOT NULL,
+    "subject" character varying NOT NULL,
+    "body" text NOT NULL,
+    "attempts" integer NOT NULL DEFAULT 0,
+    "last_error" character varying,
+    "available_at" timestamp with time zone NOT NULL,
+    "sent_at" timestamp with time zone,
+    "created_at" timestamp with time zone NOT NULL
+);
+
+CREATE INDEX "outboxmessage_sent_at_available_at" ON "outbox_messages" ("sent_at", "available_at");
@@ -0,0 +1,9 @@
+ALTER TABLE "users" ADD COLUMN "totp_secret" character varying, ADD COLUMN "totp_enabled_at" timestamp with time zone;
+
+CREATE TABLE "recovery_codes" (
+    "id" serial PRIMARY KEY,
+    "code_hash" character varying NOT NULL UNIQUE,
+    "used_at" timestamp with time zone,
+    "created_at" timestamp with time zone NOT NULL,
+    "user_id" integer NOT NULL REFERENCES "users" ("id") ON DELETE CASCADE
+);
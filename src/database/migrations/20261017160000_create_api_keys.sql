@@ -0,0 +1,12 @@
+CREATE TABLE "api_keys" (
+    "id" serial PRIMARY KEY,
+    "name" character varying NOT NULL,
+    "prefix" character varying NOT NULL UNIQUE,
+    "secret_hash" character varying NOT NULL,
+    "scopes" jsonb NOT NULL,
+    "expires_at" timestamp with time zone,
+    "last_used_at" timestamp with time zone,
+    "revoked_at" timestamp with time zone,
+    "created_at" timestamp with time zone NOT NULL,
+    "user_id" integer NOT NULL REFERENCES "users" ("id") ON DELETE CASCADE
+);
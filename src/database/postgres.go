@@ -6,6 +6,7 @@ import (
 
 	_ "github.com/lib/pq" // Import the pq driver
 	"github.com/ryuudan/golang-rest-api/ent/generated"
+	_ "github.com/ryuudan/golang-rest-api/ent/generated/runtime" // Register schema hooks and interceptors
 )
 
 func PostgresClient() (*generated.Client, error) {
@@ -3,41 +3,93 @@ package database
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"log"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/redis/go-redis/v9"
+	"github.com/ryuudan/golang-rest-api/src/constants"
 )
 
+// CacheTimeouts bound how long a single cache operation may take, so that a slow
+// Redis delays requests by at most that long instead of blocking them.
+type CacheTimeouts struct {
+	Read  time.Duration
+	Write time.Duration
+}
+
+// CacheTimeoutsFromEnv reads the timeouts from REDIS_READ_TIMEOUT and
+// REDIS_WRITE_TIMEOUT (e.g. "150ms"), falling back to the defaults.
+func CacheTimeoutsFromEnv() CacheTimeouts {
+	timeouts := CacheTimeouts{
+		Read:  constants.DEFAULT_CACHE_READ_TIMEOUT,
+		Write: constants.DEFAULT_CACHE_WRITE_TIMEOUT,
+	}
+
+	if timeout, err := time.ParseDuration(os.Getenv("REDIS_READ_TIMEOUT")); err == nil {
+		timeouts.Read = timeout
+	}
+
+	if timeout, err := time.ParseDuration(os.Getenv("REDIS_WRITE_TIMEOUT")); err == nil {
+		timeouts.Write = timeout
+	}
+
+	return timeouts
+}
+
 type RedisCache struct {
-	client *redis.Client
+	client   *redis.Client
+	local    *LocalCache
+	remote   []string // Namespaces always read from Redis, see EnableLocalCache
+	timeouts CacheTimeouts
+	encoder  *Encoder
 }
 
-func Cache(client *redis.Client) *RedisCache {
+func NewRedisCache(client *redis.Client, timeouts CacheTimeouts, encoder *Encoder) *RedisCache {
 	return &RedisCache{
-		client: client,
+		client:   client,
+		timeouts: timeouts,
+		encoder:  encoder,
 	}
 }
 
-func (c *RedisCache) GetCache(key string) (string, error) {
+// EnableLocalCache puts an in-process cache in front of Redis. Every write made
+// through this cache is broadcast on a Redis channel, and the local entries of
+// the written keys are dropped on all instances listening to it until ctx is done.
+// Writes are only broadcast once it is enabled, so it has to be enabled on every
+// instance sharing the Redis, or none.
+//
+// Keys in any of the remote namespaces skip the local cache, for entries such as
+// revocation markers that must take effect on every instance at once.
+func (c *RedisCache) EnableLocalCache(ctx context.Context, local *LocalCache, remote ...string) {
+	c.local = local
+	c.remote = remote
 
-	val, err := c.client.Get(ctx, key).Result()
+	go c.listenForInvalidations(ctx)
+}
+
+// GetCache decodes the value cached under key into value. It returns redis.Nil
+// when there is none.
+func (c *RedisCache) GetCache(ctx context.Context, key string, value interface{}) error {
+
+	data, err := c.get(ctx, key)
 	if err != nil {
-		return "", err
+		return err
 	}
 
-	return val, nil
+	return c.encoder.Decode(data, value)
 }
 
-func (c *RedisCache) SetCache(key string, value interface{}, expiration time.Duration) error {
-	jsonValue, err := json.Marshal(value)
+func (c *RedisCache) SetCache(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
+	data, err := c.encoder.Encode(value)
 	if err != nil {
 		return err
 	}
 
-	// Set the JSON string value in the cache
-	err = c.client.Set(ctx, key, jsonValue, expiration).Err()
+	// Set the encoded value in the cache
+	err = c.set(ctx, key, data, expiration)
 	if err != nil {
 		return err
 	}
@@ -46,8 +98,8 @@ func (c *RedisCache) SetCache(key string, value interface{}, expiration time.Dur
 }
 
 // Clear a cache by key
-func (c *RedisCache) ClearCache(key string) error {
-	err := c.client.Del(ctx, key).Err()
+func (c *RedisCache) ClearCache(ctx context.Context, key string) error {
+	err := c.del(ctx, key)
 	if err != nil {
 		return err
 	}
@@ -55,7 +107,251 @@ func (c *RedisCache) ClearCache(key string) error {
 	return nil
 }
 
-var ctx = context.Background()
+// Namespace returns a view of the cache whose keys all start with prefix.
+func (c *RedisCache) Namespace(prefix string) *Namespace {
+	return &Namespace{
+		cache:  c,
+		prefix: prefix,
+	}
+}
+
+func (c *RedisCache) get(ctx context.Context, key string) ([]byte, error) {
+	readCtx, cancel := withTimeout(ctx, c.timeouts.Read)
+	defer cancel()
+
+	if !c.cachesLocally(key) {
+		return c.client.Get(readCtx, key).Bytes()
+	}
+
+	if value, ok := c.local.Get(key); ok {
+		return value, nil
+	}
+
+	generation := c.local.Generation()
+
+	// The TTL is read along with the value, so that the local copy never
+	// outlives the entry in Redis
+	var value *redis.StringCmd
+	var ttl *redis.DurationCmd
+
+	_, err := c.client.TxPipelined(readCtx, func(pipe redis.Pipeliner) error {
+		value = pipe.Get(readCtx, key)
+		ttl = pipe.PTTL(readCtx, key)
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := value.Bytes()
+	if err != nil {
+		return nil, err
+	}
+
+	// PTTL is -1 for keys without an expiry, which live locally for the local TTL
+	if remaining := ttl.Val(); remaining > 0 || remaining == -1 {
+		c.local.Fill(key, data, max(remaining, 0), generation)
+	}
+
+	return data, nil
+}
+
+// cachesLocally reports whether key may be kept in the local cache.
+func (c *RedisCache) cachesLocally(key string) bool {
+	if c.local == nil {
+		return false
+	}
+
+	for _, namespace := range c.remote {
+		if strings.HasPrefix(key, namespace+":") {
+			return false
+		}
+	}
+
+	return true
+}
+
+// InvalidateTag drops every entry stored with any of the given tags, in a single
+// round trip.
+//
+// Example:
+//
+//	err := cache.InvalidateTag(ctx, "users", "user:42")
+func (c *RedisCache) InvalidateTag(ctx context.Context, tags ...string) error {
+	if len(tags) == 0 {
+		return nil
+	}
+
+	writeCtx, cancel := withTimeout(ctx, c.timeouts.Write)
+	defer cancel()
+
+	keys, err := invalidateTagsScript.Run(writeCtx, c.client, tagKeys(tags)).StringSlice()
+	if err != nil {
+		return err
+	}
+
+	if len(keys) == 0 {
+		return nil
+	}
+
+	if c.local != nil {
+		c.local.Delete(keys...)
+	}
+
+	c.broadcast(ctx, keys...)
+
+	return nil
+}
+
+// setTaggedScript stores a value and adds its key to the set of every tag. A tag
+// set lives as long as its longest lived member, so it never expires while it
+// still has entries to invalidate.
+var setTaggedScript = redis.NewScript(`
+local expiration = tonumber(ARGV[2])
+if expiration > 0 then
+	redis.call("SET", KEYS[1], ARGV[1], "PX", expiration)
+else
+	redis.call("SET", KEYS[1], ARGV[1])
+end
+for i = 2, #KEYS do
+	local ttl = redis.call("PTTL", KEYS[i])
+	redis.call("SADD", KEYS[i], KEYS[1])
+	if expiration == 0 then
+		redis.call("PERSIST", KEYS[i])
+	elseif ttl == -2 or (ttl ~= -1 and ttl < expiration) then
+		redis.call("PEXPIRE", KEYS[i], expiration)
+	end
+end
+return 1
+`)
+
+// invalidateTagsScript deletes the members of the given tag sets along with the
+// sets themselves, and returns the deleted member keys.
+var invalidateTagsScript = redis.NewScript(`
+local deleted = {}
+for _, tag in ipairs(KEYS) do
+	for _, key in ipairs(redis.call("SMEMBERS", tag)) do
+		redis.call("DEL", key)
+		table.insert(deleted, key)
+	end
+	redis.call("DEL", tag)
+end
+return deleted
+`)
+
+func tagKeys(tags []string) []string {
+	keys := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		keys = append(keys, fmt.Sprintf("tags:%s", tag))
+	}
+	return keys
+}
+
+func (c *RedisCache) set(ctx context.Context, key string, value []byte, expiration time.Duration, tags ...string) error {
+	writeCtx, cancel := withTimeout(ctx, c.timeouts.Write)
+	defer cancel()
+
+	var err error
+
+	if len(tags) > 0 {
+		keys := append([]string{key}, tagKeys(tags)...)
+		err = setTaggedScript.Run(writeCtx, c.client, keys, value, expiration.Milliseconds()).Err()
+	} else {
+		err = c.client.Set(writeCtx, key, value, expiration).Err()
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if c.local != nil {
+		c.local.Delete(key)
+	}
+
+	c.broadcast(ctx, key)
+
+	return nil
+}
+
+func (c *RedisCache) del(ctx context.Context, keys ...string) error {
+	writeCtx, cancel := withTimeout(ctx, c.timeouts.Write)
+	defer cancel()
+
+	if err := c.client.Del(writeCtx, keys...).Err(); err != nil {
+		return err
+	}
+
+	if c.local != nil {
+		c.local.Delete(keys...)
+	}
+
+	c.broadcast(ctx, keys...)
+
+	return nil
+}
+
+// broadcast tells every other instance to drop its local copy of the given keys.
+// Nothing is sent without a local cache, so either every instance enables it or
+// none does.
+func (c *RedisCache) broadcast(ctx context.Context, keys ...string) {
+	if c.local == nil {
+		return
+	}
+
+	writeCtx, cancel := withTimeout(ctx, c.timeouts.Write)
+	defer cancel()
+
+	message, err := json.Marshal(keys)
+	if err == nil {
+		err = c.client.Publish(writeCtx, constants.CACHE_INVALIDATION_CHANNEL, message).Err()
+	}
+
+	if err != nil {
+		// The local entries expire on their own soon enough
+		log.Printf("❌ Failed to broadcast cache invalidation of %v: %v", keys, err)
+	}
+}
+
+func (c *RedisCache) listenForInvalidations(ctx context.Context) {
+	pubsub := c.client.Subscribe(ctx, constants.CACHE_INVALIDATION_CHANNEL)
+	defer pubsub.Close()
+
+	messages := pubsub.ChannelWithSubscriptions()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case message, ok := <-messages:
+			if !ok {
+				return
+			}
+
+			switch message := message.(type) {
+			case *redis.Subscription:
+				// Invalidations sent while the connection was down are lost
+				c.local.Clear()
+			case *redis.Message:
+				var keys []string
+				if err := json.Unmarshal([]byte(message.Payload), &keys); err != nil {
+					log.Printf("❌ Invalid cache invalidation message %q: %v", message.Payload, err)
+					continue
+				}
+				c.local.Delete(keys...)
+			}
+		}
+	}
+}
+
+// withTimeout bounds ctx by timeout, unless timeout is zero.
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return ctx, func() {}
+	}
+
+	return context.WithTimeout(ctx, timeout)
+}
 
 func RedisClient() *redis.Client {
 	url := os.Getenv("REDIS_URL")
@@ -66,6 +362,9 @@ func RedisClient() *redis.Client {
 
 	rdb := redis.NewClient(opts)
 
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
 	// Ping the Redis server to check the connection
 	pong, err := rdb.Ping(ctx).Result()
 	if err != nil {
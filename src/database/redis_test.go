@@ -0,0 +1,146 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/ryuudan/golang-rest-api/src/constants"
+)
+
+func TestLocalCopyNeverOutlivesRedis(t *testing.T) {
+	tests := []struct {
+		name       string
+		expiration time.Duration
+		want       time.Duration
+	}{
+		{"shorter in redis", 2 * time.Second, 2 * time.Second},
+		{"longer in redis", time.Hour, time.Minute},
+		{"no expiry", 0, time.Minute},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cache, _ := newTestCache(t)
+			local := NewLocalCache(10, time.Minute)
+			cache.local = local
+
+			if err := cache.SetCache(context.Background(), "users:1", "ada", test.expiration); err != nil {
+				t.Fatalf("SetCache: %v", err)
+			}
+
+			before := time.Now()
+
+			var value string
+			if err := cache.GetCache(context.Background(), "users:1", &value); err != nil {
+				t.Fatalf("GetCache: %v", err)
+			}
+
+			element, ok := local.items["users:1"]
+			if !ok {
+				t.Fatal("value was not cached locally")
+			}
+
+			expiresAt := element.Value.(*localItem).expiresAt
+			if got := expiresAt.Sub(before); got > test.want+100*time.Millisecond || got < test.want-time.Second {
+				t.Errorf("local copy expires after %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestRemoteNamespacesSkipTheLocalCache(t *testing.T) {
+	cache, server := newTestCache(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	cache.EnableLocalCache(ctx, NewLocalCache(10, time.Minute), "revoked_users")
+
+	for _, key := range []string{"revoked_users:1", "users:1"} {
+		if err := cache.SetCache(ctx, key, 1, time.Hour); err != nil {
+			t.Fatalf("SetCache: %v", err)
+		}
+
+		var value int
+		if err := cache.GetCache(ctx, key, &value); err != nil {
+			t.Fatalf("GetCache: %v", err)
+		}
+	}
+
+	if _, ok := cache.local.Get("revoked_users:1"); ok {
+		t.Error("revoked_users:1 was cached locally")
+	}
+	if _, ok := cache.local.Get("users:1"); !ok {
+		t.Error("users:1 was not cached locally")
+	}
+
+	// A marker deleted behind the cache's back is gone at once
+	server.Del("revoked_users:1")
+
+	var value int
+	if err := cache.GetCache(ctx, "revoked_users:1", &value); err == nil {
+		t.Error("GetCache served a deleted marker")
+	}
+}
+
+func TestWritesAreBroadcastOnlyWithALocalCache(t *testing.T) {
+	tests := []struct {
+		name  string
+		local bool
+		want  int
+	}{
+		{"without a local cache", false, 0},
+		{"with a local cache", true, 2},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cache, server := newTestCache(t)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			// Listens like another instance would
+			listener := redis.NewClient(&redis.Options{Addr: server.Addr()})
+			t.Cleanup(func() { listener.Close() })
+
+			pubsub := listener.Subscribe(ctx, constants.CACHE_INVALIDATION_CHANNEL)
+			defer pubsub.Close()
+
+			if _, err := pubsub.Receive(ctx); err != nil {
+				t.Fatalf("Subscribe: %v", err)
+			}
+
+			if test.local {
+				cache.EnableLocalCache(ctx, NewLocalCache(10, time.Minute))
+			}
+
+			if err := cache.SetCache(ctx, "users:1", "ada", time.Hour); err != nil {
+				t.Fatalf("SetCache: %v", err)
+			}
+			if err := cache.ClearCache(ctx, "users:1"); err != nil {
+				t.Fatalf("ClearCache: %v", err)
+			}
+
+			received := 0
+			messages := pubsub.Channel()
+			timeout := time.After(200 * time.Millisecond)
+
+		wait:
+			for {
+				select {
+				case <-messages:
+					received++
+				case <-timeout:
+					break wait
+				}
+			}
+
+			if received != test.want {
+				t.Errorf("received %d invalidations, want %d", received, test.want)
+			}
+		})
+	}
+}
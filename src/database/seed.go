@@ -0,0 +1,161 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/ryuudan/golang-rest-api/ent/generated"
+	"github.com/ryuudan/golang-rest-api/ent/generated/permission"
+	"github.com/ryuudan/golang-rest-api/ent/generated/role"
+	"github.com/ryuudan/golang-rest-api/ent/generated/user"
+	"github.com/ryuudan/golang-rest-api/ent/schema"
+	"github.com/ryuudan/golang-rest-api/src/constants"
+	"github.com/ryuudan/golang-rest-api/src/utils/passwords"
+)
+
+// Permissions granted to each seeded role. Seeding replaces the permissions of
+// these roles, so this map is the source of truth for them.
+var rolePermissions = map[string][]string{
+	constants.ROLE_ADMIN: {
+		constants.PERMISSION_USERS_READ,
+		constants.PERMISSION_USERS_WRITE,
+		constants.PERMISSION_USERS_DELETE,
+		constants.PERMISSION_USERS_PURGE,
+	},
+	// Users only ever see their own account, through /api/me
+	constants.ROLE_USER: {},
+}
+
+// SeedRoles makes sure the default roles and permissions exist. It is safe to
+// run on every startup.
+//
+// When ADMIN_EMAIL is set, the matching user is granted the admin role. If that
+// user does not exist yet and ADMIN_PASSWORD is set, it is created first, which
+// is how the very first administrator gets into a fresh database.
+func SeedRoles(ctx context.Context, client *generated.Client, hasher *passwords.Policy) error {
+	tx, err := client.Tx(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := seedRoles(ctx, tx); err != nil {
+		return rollback(tx, err)
+	}
+
+	if err := seedAdmin(ctx, tx, hasher); err != nil {
+		return rollback(tx, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	log.Println("✅ Sucessfully seeded roles and permissions!")
+
+	return nil
+}
+
+func seedRoles(ctx context.Context, tx *generated.Tx) error {
+	permissions := map[string]*generated.Permission{}
+
+	for roleName, permissionNames := range rolePermissions {
+		var granted []*generated.Permission
+
+		for _, name := range permissionNames {
+			if _, ok := permissions[name]; !ok {
+				p, err := tx.Permission.Query().Where(permission.NameEQ(name)).Only(ctx)
+				if generated.IsNotFound(err) {
+					p, err = tx.Permission.Create().SetName(name).Save(ctx)
+				}
+				if err != nil {
+					return err
+				}
+				permissions[name] = p
+			}
+			granted = append(granted, permissions[name])
+		}
+
+		r, err := tx.Role.Query().Where(role.NameEQ(roleName)).Only(ctx)
+		if generated.IsNotFound(err) {
+			r, err = tx.Role.Create().SetName(roleName).Save(ctx)
+		}
+		if err != nil {
+			return err
+		}
+
+		err = r.Update().
+			ClearPermissions().
+			AddPermissions(granted...).
+			Exec(ctx)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// seedAdmin makes sure the user ADMIN_EMAIL exists and is an admin. A soft-deleted
+// user with that email still holds it, and was most likely deleted on purpose, so
+// it is neither restored nor replaced: seeding fails until it is restored or
+// ADMIN_EMAIL is changed.
+func seedAdmin(ctx context.Context, tx *generated.Tx, hasher *passwords.Policy) error {
+	email := os.Getenv("ADMIN_EMAIL")
+	if email == "" {
+		return nil
+	}
+
+	admin, err := tx.User.Query().Where(user.EmailEQ(email)).Only(schema.SkipSoftDelete(ctx))
+
+	if err == nil && admin.DeletedAt != nil {
+		return fmt.Errorf("ADMIN_EMAIL %s belongs to user %d, which was deleted: restore it or set another ADMIN_EMAIL", email, admin.ID)
+	}
+
+	if generated.IsNotFound(err) {
+		password := os.Getenv("ADMIN_PASSWORD")
+		if password == "" {
+			log.Printf("ADMIN_EMAIL %s does not exist and no ADMIN_PASSWORD is set, skipping admin seed", email)
+			return nil
+		}
+
+		hashed, err := hasher.Hash(password)
+		if err != nil {
+			return err
+		}
+
+		admin, err = tx.User.Create().
+			SetEmail(email).
+			SetFirstName("Admin").
+			SetLastName("Admin").
+			SetPassword(hashed).
+			Save(ctx)
+
+		if err != nil {
+			return err
+		}
+	} else if err != nil {
+		return err
+	}
+
+	isAdmin, err := admin.QueryRoles().Where(role.NameEQ(constants.ROLE_ADMIN)).Exist(ctx)
+	if err != nil || isAdmin {
+		return err
+	}
+
+	adminRole, err := tx.Role.Query().Where(role.NameEQ(constants.ROLE_ADMIN)).Only(ctx)
+	if err != nil {
+		return err
+	}
+
+	return admin.Update().AddRoles(adminRole).Exec(ctx)
+}
+
+func rollback(tx *generated.Tx, err error) error {
+	if rerr := tx.Rollback(); rerr != nil {
+		err = fmt.Errorf("%w: %v", err, rerr)
+	}
+	return err
+}
@@ -0,0 +1,78 @@
+package database
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/ryuudan/golang-rest-api/ent/generated"
+	"github.com/ryuudan/golang-rest-api/ent/generated/enttest"
+	"github.com/ryuudan/golang-rest-api/ent/generated/user"
+	"github.com/ryuudan/golang-rest-api/ent/schema"
+	"github.com/ryuudan/golang-rest-api/src/utils/passwords"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func newTestClient(t *testing.T) *generated.Client {
+	t.Helper()
+
+	client := enttest.Open(t, "sqlite3", "file:"+t.Name()+"?mode=memory&cache=shared&_fk=1")
+	t.Cleanup(func() { client.Close() })
+
+	return client
+}
+
+func TestSeedAdmin(t *testing.T) {
+	hasher := passwords.NewPolicy(&passwords.Bcrypt{Cost: 4})
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		setup   func(t *testing.T, client *generated.Client)
+		wantErr string // Empty when seeding succeeds
+	}{
+		{"creates the admin", func(t *testing.T, client *generated.Client) {}, ""},
+		{"keeps an existing admin", func(t *testing.T, client *generated.Client) {
+			if err := SeedRoles(ctx, client, hasher); err != nil {
+				t.Fatalf("SeedRoles: %v", err)
+			}
+		}, ""},
+		{"refuses a deleted admin", func(t *testing.T, client *generated.Client) {
+			if err := SeedRoles(ctx, client, hasher); err != nil {
+				t.Fatalf("SeedRoles: %v", err)
+			}
+			if _, err := client.User.Delete().Where(user.EmailEQ("admin@example.com")).Exec(ctx); err != nil {
+				t.Fatalf("Delete: %v", err)
+			}
+		}, "was deleted"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Setenv("ADMIN_EMAIL", "admin@example.com")
+			t.Setenv("ADMIN_PASSWORD", "correct horse battery staple")
+
+			client := newTestClient(t)
+			test.setup(t, client)
+
+			err := SeedRoles(ctx, client, hasher)
+
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("err = %v, want it to mention %q", err, test.wantErr)
+				}
+			} else if err != nil {
+				t.Fatalf("SeedRoles: %v", err)
+			}
+
+			count, err := client.User.Query().Where(user.EmailEQ("admin@example.com")).Count(schema.SkipSoftDelete(ctx))
+			if err != nil {
+				t.Fatalf("Count: %v", err)
+			}
+			if count != 1 {
+				t.Errorf("%d users hold the admin email, want 1", count)
+			}
+		})
+	}
+}
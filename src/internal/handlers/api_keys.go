@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/ryuudan/golang-rest-api/src/constants"
+	"github.com/ryuudan/golang-rest-api/src/internal/middlewares"
+	"github.com/ryuudan/golang-rest-api/src/internal/models"
+	"github.com/ryuudan/golang-rest-api/src/internal/services"
+	"github.com/ryuudan/golang-rest-api/src/utils"
+	"github.com/ryuudan/golang-rest-api/src/utils/render"
+)
+
+type APIKeyHandler struct {
+	keys services.APIKeyService
+}
+
+func NewAPIKeyHandler(apiKeyService services.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{
+		keys: apiKeyService,
+	}
+}
+
+// Create answers with the full key, the only time it is shown.
+func (handler *APIKeyHandler) Create(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middlewares.UserIDFromContext(r.Context())
+	if !ok {
+		render.Error(w, r, http.StatusUnauthorized, constants.UNAUTHORIZED)
+		return
+	}
+
+	validate := render.Validator()
+
+	var body models.CreateAPIKeyRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		render.Error(w, r, http.StatusUnprocessableEntity, "Invalid JSON: "+err.Error())
+		return
+	}
+
+	if err := validate.Struct(body); err != nil {
+		render.ValidationError(w, r, err)
+		return
+	}
+
+	created, err := handler.keys.Create(r.Context(), userID, &body)
+
+	if err != nil {
+		if errors.Is(err, services.ErrScopeNotGranted) {
+			render.Error(w, r, http.StatusForbidden, constants.SCOPE_NOT_GRANTED)
+			return
+		}
+		if errors.Is(err, services.ErrTooManyAPIKeys) {
+			render.Error(w, r, http.StatusConflict, constants.TOO_MANY_API_KEYS)
+			return
+		}
+		render.Error(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	render.JSON(w, http.StatusCreated, created)
+}
+
+func (handler *APIKeyHandler) List(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middlewares.UserIDFromContext(r.Context())
+	if !ok {
+		render.Error(w, r, http.StatusUnauthorized, constants.UNAUTHORIZED)
+		return
+	}
+
+	keys, err := handler.keys.List(r.Context(), userID)
+	if err != nil {
+		render.Error(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	render.JSON(w, http.StatusOK, models.NewAPIKeyResponses(keys))
+}
+
+func (handler *APIKeyHandler) Revoke(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middlewares.UserIDFromContext(r.Context())
+	if !ok {
+		render.Error(w, r, http.StatusUnauthorized, constants.UNAUTHORIZED)
+		return
+	}
+
+	id, err := utils.StringToInt(chi.URLParam(r, "id"))
+
+	if err != nil {
+		render.Error(w, r, http.StatusBadRequest, constants.INVALID_FORMAT_ID)
+		return
+	}
+
+	if err := handler.keys.Revoke(r.Context(), userID, id); err != nil {
+		if errors.Is(err, services.ErrAPIKeyNotFound) {
+			render.Error(w, r, http.StatusNotFound, constants.API_KEY_NOT_FOUND)
+			return
+		}
+		render.Error(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
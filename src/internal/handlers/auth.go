@@ -0,0 +1,282 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/ryuudan/golang-rest-api/src/constants"
+	"github.com/ryuudan/golang-rest-api/src/internal/middlewares"
+	"github.com/ryuudan/golang-rest-api/src/internal/models"
+	"github.com/ryuudan/golang-rest-api/src/internal/services"
+	"github.com/ryuudan/golang-rest-api/src/utils/render"
+)
+
+type AuthHandler struct {
+	auth         services.AuthService
+	verification services.VerificationService
+}
+
+func NewAuthHandler(authService services.AuthService, verificationService services.VerificationService) *AuthHandler {
+	return &AuthHandler{
+		auth:         authService,
+		verification: verificationService,
+	}
+}
+
+func (handler *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
+	validate := render.Validator()
+
+	var credentials models.LoginRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&credentials); err != nil {
+		render.Error(w, r, http.StatusUnprocessableEntity, "Invalid JSON: "+err.Error())
+		return
+	}
+
+	if err := validate.Struct(credentials); err != nil {
+		render.ValidationError(w, r, err)
+		return
+	}
+
+	tokens, err := handler.auth.Login(r.Context(), credentials.Email, credentials.Password, middlewares.KeyByIP(r))
+
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidCredentials) {
+			render.Error(w, r, http.StatusUnauthorized, constants.INVALID_CREDENTIALS)
+			return
+		}
+
+		var challenge *services.MFARequiredError
+		if errors.As(err, &challenge) {
+			render.JSON(w, http.StatusAccepted, challenge.Challenge)
+			return
+		}
+
+		var locked *services.LoginLockedError
+		if errors.As(err, &locked) {
+			middlewares.SetRetryAfter(w, locked.RetryAfter)
+			render.Error(w, r, http.StatusTooManyRequests, constants.LOGIN_LOCKED)
+			return
+		}
+		render.Error(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	render.JSON(w, http.StatusOK, tokens)
+}
+
+// VerifyMFA exchanges the MFA token of a login answered with a 202, together
+// with a TOTP or recovery code, for a token pair.
+func (handler *AuthHandler) VerifyMFA(w http.ResponseWriter, r *http.Request) {
+	validate := render.Validator()
+
+	var body models.MFALoginRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		render.Error(w, r, http.StatusUnprocessableEntity, "Invalid JSON: "+err.Error())
+		return
+	}
+
+	if err := validate.Struct(body); err != nil {
+		render.ValidationError(w, r, err)
+		return
+	}
+
+	tokens, err := handler.auth.VerifyMFA(r.Context(), body.MFAToken, body.Code, middlewares.KeyByIP(r))
+
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidMFAToken) {
+			render.Error(w, r, http.StatusUnauthorized, constants.INVALID_MFA_TOKEN)
+			return
+		}
+
+		if errors.Is(err, services.ErrInvalidMFACode) {
+			render.Error(w, r, http.StatusUnauthorized, constants.INVALID_MFA_LOGIN_CODE)
+			return
+		}
+
+		var locked *services.LoginLockedError
+		if errors.As(err, &locked) {
+			middlewares.SetRetryAfter(w, locked.RetryAfter)
+			render.Error(w, r, http.StatusTooManyRequests, constants.LOGIN_LOCKED)
+			return
+		}
+		render.Error(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	render.JSON(w, http.StatusOK, tokens)
+}
+
+func (handler *AuthHandler) Refresh(w http.ResponseWriter, r *http.Request) {
+	validate := render.Validator()
+
+	var body models.RefreshRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		render.Error(w, r, http.StatusUnprocessableEntity, "Invalid JSON: "+err.Error())
+		return
+	}
+
+	if err := validate.Struct(body); err != nil {
+		render.ValidationError(w, r, err)
+		return
+	}
+
+	tokens, err := handler.auth.Refresh(r.Context(), body.RefreshToken)
+
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidRefreshToken) {
+			render.Error(w, r, http.StatusUnauthorized, constants.INVALID_REFRESH_TOKEN)
+			return
+		}
+		render.Error(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	render.JSON(w, http.StatusOK, tokens)
+}
+
+// ForgotPassword always answers with a 202, whether or not a user has the email,
+// so that it cannot be used to find out which accounts exist.
+func (handler *AuthHandler) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	validate := render.Validator()
+
+	var body models.ForgotPasswordRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		render.Error(w, r, http.StatusUnprocessableEntity, "Invalid JSON: "+err.Error())
+		return
+	}
+
+	if err := validate.Struct(body); err != nil {
+		render.ValidationError(w, r, err)
+		return
+	}
+
+	if err := handler.auth.ForgotPassword(r.Context(), body.Email); err != nil {
+		render.Error(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (handler *AuthHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	validate := render.Validator()
+
+	var body models.ResetPasswordRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		render.Error(w, r, http.StatusUnprocessableEntity, "Invalid JSON: "+err.Error())
+		return
+	}
+
+	if err := validate.Struct(body); err != nil {
+		render.ValidationError(w, r, err)
+		return
+	}
+
+	if err := handler.auth.ResetPassword(r.Context(), body.Token, body.Password); err != nil {
+		if errors.Is(err, services.ErrInvalidResetToken) {
+			render.Error(w, r, http.StatusBadRequest, constants.INVALID_RESET_TOKEN)
+			return
+		}
+
+		var weak *services.WeakPasswordError
+		if errors.As(err, &weak) {
+			render.CustomValidationError(w, r, weak.Details)
+			return
+		}
+		render.Error(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Verify is linked to from verification mails, so the token comes in the query.
+func (handler *AuthHandler) Verify(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+	if token == "" {
+		render.Error(w, r, http.StatusBadRequest, constants.INVALID_VERIFICATION_TOKEN)
+		return
+	}
+
+	user, err := handler.verification.Verify(r.Context(), token)
+
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidVerificationToken) {
+			render.Error(w, r, http.StatusBadRequest, constants.INVALID_VERIFICATION_TOKEN)
+			return
+		}
+		render.Error(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	render.JSON(w, http.StatusOK, models.NewUserResponse(user))
+}
+
+// ResendVerification always answers with a 202, like ForgotPassword.
+func (handler *AuthHandler) ResendVerification(w http.ResponseWriter, r *http.Request) {
+	validate := render.Validator()
+
+	var body models.ResendVerificationRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		render.Error(w, r, http.StatusUnprocessableEntity, "Invalid JSON: "+err.Error())
+		return
+	}
+
+	if err := validate.Struct(body); err != nil {
+		render.ValidationError(w, r, err)
+		return
+	}
+
+	if err := handler.verification.Resend(r.Context(), body.Email); err != nil {
+		render.Error(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (handler *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middlewares.ClaimsFromContext(r.Context())
+	if !ok {
+		render.Error(w, r, http.StatusUnauthorized, constants.UNAUTHORIZED)
+		return
+	}
+
+	// The body is optional, an empty one only revokes the access token
+	var body models.LogoutRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil && !errors.Is(err, io.EOF) {
+		render.Error(w, r, http.StatusUnprocessableEntity, "Invalid JSON: "+err.Error())
+		return
+	}
+
+	if err := handler.auth.Logout(r.Context(), claims, body.RefreshToken); err != nil {
+		render.Error(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (handler *AuthHandler) LogoutAll(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middlewares.UserIDFromContext(r.Context())
+	if !ok {
+		render.Error(w, r, http.StatusUnauthorized, constants.UNAUTHORIZED)
+		return
+	}
+
+	if err := handler.auth.LogoutAll(r.Context(), userID); err != nil {
+		render.Error(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/ryuudan/golang-rest-api/ent/generated"
+	"github.com/ryuudan/golang-rest-api/src/constants"
+	"github.com/ryuudan/golang-rest-api/src/internal/middlewares"
+	"github.com/ryuudan/golang-rest-api/src/internal/services"
+	"github.com/ryuudan/golang-rest-api/src/utils"
+	"github.com/ryuudan/golang-rest-api/src/utils/render"
+)
+
+type LockoutHandler struct {
+	lockouts services.LockoutService
+}
+
+func NewLockoutHandler(lockoutService services.LockoutService) *LockoutHandler {
+	return &LockoutHandler{
+		lockouts: lockoutService,
+	}
+}
+
+// Unlock lets a user log in again right away after failed logins locked their
+// account out. A lockout of the client IP they logged in from is left in place.
+func (handler *LockoutHandler) Unlock(w http.ResponseWriter, r *http.Request) {
+	actorID, ok := middlewares.UserIDFromContext(r.Context())
+	if !ok {
+		render.Error(w, r, http.StatusUnauthorized, constants.UNAUTHORIZED)
+		return
+	}
+
+	id, err := utils.StringToInt(chi.URLParam(r, "id"))
+
+	if err != nil {
+		render.Error(w, r, http.StatusBadRequest, constants.INVALID_FORMAT_ID)
+		return
+	}
+
+	if err := handler.lockouts.Unlock(r.Context(), id, actorID); err != nil {
+		if generated.IsNotFound(err) {
+			render.Error(w, r, http.StatusNotFound, "user not found")
+			return
+		}
+		render.Error(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/ryuudan/golang-rest-api/src/constants"
+	"github.com/ryuudan/golang-rest-api/src/internal/middlewares"
+	"github.com/ryuudan/golang-rest-api/src/internal/models"
+	"github.com/ryuudan/golang-rest-api/src/internal/services"
+	"github.com/ryuudan/golang-rest-api/src/utils/render"
+)
+
+type MFAHandler struct {
+	mfa services.MFAService
+}
+
+func NewMFAHandler(mfaService services.MFAService) *MFAHandler {
+	return &MFAHandler{
+		mfa: mfaService,
+	}
+}
+
+func (handler *MFAHandler) Enroll(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middlewares.UserIDFromContext(r.Context())
+	if !ok {
+		render.Error(w, r, http.StatusUnauthorized, constants.UNAUTHORIZED)
+		return
+	}
+
+	enrollment, err := handler.mfa.Enroll(r.Context(), userID)
+
+	if err != nil {
+		handler.error(w, r, err)
+		return
+	}
+
+	render.JSON(w, http.StatusOK, enrollment)
+}
+
+// Confirm answers with the recovery codes, the only time they are shown.
+func (handler *MFAHandler) Confirm(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middlewares.UserIDFromContext(r.Context())
+	if !ok {
+		render.Error(w, r, http.StatusUnauthorized, constants.UNAUTHORIZED)
+		return
+	}
+
+	body, ok := decodeMFACode(w, r)
+	if !ok {
+		return
+	}
+
+	codes, err := handler.mfa.Confirm(r.Context(), userID, body.Code, middlewares.KeyByIP(r))
+
+	if err != nil {
+		handler.error(w, r, err)
+		return
+	}
+
+	render.JSON(w, http.StatusOK, codes)
+}
+
+func (handler *MFAHandler) Disable(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middlewares.UserIDFromContext(r.Context())
+	if !ok {
+		render.Error(w, r, http.StatusUnauthorized, constants.UNAUTHORIZED)
+		return
+	}
+
+	body, ok := decodeMFACode(w, r)
+	if !ok {
+		return
+	}
+
+	if err := handler.mfa.Disable(r.Context(), userID, body.Code, middlewares.KeyByIP(r)); err != nil {
+		handler.error(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (handler *MFAHandler) error(w http.ResponseWriter, r *http.Request, err error) {
+	var locked *services.LoginLockedError
+
+	switch {
+	case errors.As(err, &locked):
+		middlewares.SetRetryAfter(w, locked.RetryAfter)
+		render.Error(w, r, http.StatusTooManyRequests, constants.LOGIN_LOCKED)
+	case errors.Is(err, services.ErrInvalidMFACode):
+		render.Error(w, r, http.StatusBadRequest, constants.INVALID_MFA_CODE)
+	case errors.Is(err, services.ErrMFAAlreadyEnabled):
+		render.Error(w, r, http.StatusConflict, constants.MFA_ALREADY_ENABLED)
+	case errors.Is(err, services.ErrMFANotEnrolled):
+		render.Error(w, r, http.StatusConflict, constants.MFA_NOT_ENROLLED)
+	case errors.Is(err, services.ErrMFANotEnabled):
+		render.Error(w, r, http.StatusConflict, constants.MFA_NOT_ENABLED)
+	default:
+		render.Error(w, r, http.StatusInternalServerError, err.Error())
+	}
+}
+
+func decodeMFACode(w http.ResponseWriter, r *http.Request) (*models.MFACodeRequest, bool) {
+	validate := render.Validator()
+
+	var body models.MFACodeRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		render.Error(w, r, http.StatusUnprocessableEntity, "Invalid JSON: "+err.Error())
+		return nil, false
+	}
+
+	if err := validate.Struct(body); err != nil {
+		render.ValidationError(w, r, err)
+		return nil, false
+	}
+
+	return &body, true
+}
@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/ryuudan/golang-rest-api/src/constants"
+	"github.com/ryuudan/golang-rest-api/src/internal/middlewares"
+	"github.com/ryuudan/golang-rest-api/src/internal/services"
+	"github.com/ryuudan/golang-rest-api/src/utils/render"
+)
+
+type UsageHandler struct {
+	quotas services.QuotaService
+}
+
+func NewUsageHandler(quotaService services.QuotaService) *UsageHandler {
+	return &UsageHandler{
+		quotas: quotaService,
+	}
+}
+
+func (handler *UsageHandler) Usage(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middlewares.UserIDFromContext(r.Context())
+	if !ok {
+		render.Error(w, r, http.StatusUnauthorized, constants.UNAUTHORIZED)
+		return
+	}
+
+	usage, err := handler.quotas.Usage(r.Context(), userID)
+	if err != nil {
+		render.Error(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	render.JSON(w, http.StatusOK, usage)
+}
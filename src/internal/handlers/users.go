@@ -1,29 +1,39 @@
 package handlers
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"log"
 	"net/http"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/ryuudan/golang-rest-api/ent/generated"
 	"github.com/ryuudan/golang-rest-api/src/constants"
 	"github.com/ryuudan/golang-rest-api/src/database"
+	"github.com/ryuudan/golang-rest-api/src/internal/middlewares"
+	"github.com/ryuudan/golang-rest-api/src/internal/models"
+	"github.com/ryuudan/golang-rest-api/src/internal/repositories"
 	"github.com/ryuudan/golang-rest-api/src/internal/services"
 	"github.com/ryuudan/golang-rest-api/src/utils"
 	"github.com/ryuudan/golang-rest-api/src/utils/render"
-	"golang.org/x/crypto/bcrypt"
+	"github.com/ryuudan/golang-rest-api/src/utils/tokens"
 )
 
 type UserHandler struct {
-	user  services.UserService
-	cache *database.RedisCache
+	user      services.UserService
+	cache     *database.Cache[*models.UserResponse]
+	listCache *database.Cache[*models.UserPage]
 }
 
-func NewUserHandler(userService services.UserService, cache *database.RedisCache) *UserHandler {
+func NewUserHandler(userService services.UserService, cache *database.Cache[*models.UserResponse], listCache *database.Cache[*models.UserPage]) *UserHandler {
 	return &UserHandler{
-		user:  userService,
-		cache: cache,
+		user:      userService,
+		cache:     cache,
+		listCache: listCache,
 	}
 }
 
@@ -31,70 +41,204 @@ func (handler *UserHandler) Create(w http.ResponseWriter, r *http.Request) {
 	// Create a validator instance for input validation
 	validate := render.Validator()
 
-	var user generated.User
-	var validationErrors []render.ValidationErrorDetails
+	var request models.CreateUserRequest
 
-	// Decode the JSON request body into the user struct
-	if err := json.NewDecoder(r.Body).Decode(&user); err != nil {
+	// Decode the JSON request body into the request struct
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
 		render.Error(w, r, http.StatusUnprocessableEntity, "Invalid JSON: "+err.Error())
 		return
 	}
 
-	// Struct level validation of the user object
-	if err := validate.Struct(user); err != nil {
+	// Struct level validation of the request object
+	if err := validate.Struct(request); err != nil {
 		render.ValidationError(w, r, err)
 		return
 	}
 
-	// Check if a user with the same email already exists
-	existingUser, _ := handler.user.GetUserByEmail(r.Context(), user.Email)
+	// Register the user in the system, which hashes the password. Taken emails and
+	// phone numbers come back as conflicts.
+	newUser, err := handler.user.CreateUser(r.Context(), request.ToUser())
+
+	if err != nil {
+		var weak *services.WeakPasswordError
+		if errors.As(err, &weak) {
+			render.CustomValidationError(w, r, weak.Details)
+			return
+		}
+
+		if status, message, ok := uniqueConflict(err); ok {
+			render.Error(w, r, status, message)
+			return
+		}
+		render.Error(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	response := models.NewUserResponse(newUser)
+
+	// The user is stored already, a cold cache only costs a query later on
+	if err := handler.cache.Set(r.Context(), newUser.ID, response); err != nil {
+		log.Printf("❌ Failed to cache user %d: %v", newUser.ID, err)
+	}
+
+	render.JSON(w, http.StatusOK, response)
+}
+
+func (handler *UserHandler) GetOneByID(w http.ResponseWriter, r *http.Request) {
+	id, err := utils.StringToInt(chi.URLParam(r, "id"))
+
+	if err != nil {
+		render.Error(w, r, http.StatusBadRequest, constants.INVALID_FORMAT_ID)
+		return
+	}
+
+	handler.renderUser(w, r, id)
+}
+
+// Me returns the authenticated user, which needs no permission.
+func (handler *UserHandler) Me(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middlewares.UserIDFromContext(r.Context())
+	if !ok {
+		render.Error(w, r, http.StatusUnauthorized, constants.UNAUTHORIZED)
+		return
+	}
+
+	handler.renderUser(w, r, userID)
+}
+
+// renderUser responds with the user, from the cache when possible.
+func (handler *UserHandler) renderUser(w http.ResponseWriter, r *http.Request, id int) {
+	user, err := handler.cache.Fetch(r.Context(), id, func(ctx context.Context) (*models.UserResponse, error) {
+		user, err := handler.user.GetUserByID(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		return models.NewUserResponse(user), nil
+	})
+
+	if err != nil {
+		if generated.IsNotFound(err) {
+			render.Error(w, r, http.StatusNotFound, "user not found")
+			return
+		} else {
+			render.Error(w, r, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+
+	render.JSON(w, http.StatusOK, user)
+}
+
+// List returns a page of users. By default pages are addressed by number and come
+// with totals; passing a cursor parameter switches to keyset pagination instead.
+func (handler *UserHandler) List(w http.ResponseWriter, r *http.Request) {
+	params, err := render.ParseQueryFilterParams(r.URL.RawQuery)
+
+	if err != nil {
+		render.Error(w, r, http.StatusBadRequest, "Invalid query parameters: "+err.Error())
+		return
+	}
+
+	var validationErrors []render.ValidationErrorDetails
+
+	if params.Page < 1 && !params.UseCursor {
+		validationErrors = append(validationErrors, render.ValidationErrorDetails{
+			Field:   "page",
+			Message: "page should be greater than or equal to 1",
+		})
+	}
 
-	if existingUser != nil {
+	if params.Limit < 1 || params.Limit > constants.MAX_PAGE_LIMIT {
 		validationErrors = append(validationErrors, render.ValidationErrorDetails{
-			Field:   "email",
-			Message: "email already exists, please try another one",
+			Field:   "limit",
+			Message: fmt.Sprintf("limit should be between 1 and %d", constants.MAX_PAGE_LIMIT),
 		})
 	}
 
-	// If there are validation errors, return a custom validation error response
+	allowedOrderFields := repositories.UserOrderFields
+	if params.UseCursor {
+		allowedOrderFields = repositories.UserCursorOrderFields
+	}
+
+	orders, err := render.ParseOrderString(params.Order)
+
+	if err != nil {
+		validationErrors = append(validationErrors, render.ValidationErrorDetails{
+			Field:   "order",
+			Message: err.Error(),
+		})
+	} else {
+		validationErrors = append(validationErrors, render.ValidateOrderFields(orders, allowedOrderFields)...)
+	}
+
 	if len(validationErrors) > 0 {
 		render.CustomValidationError(w, r, validationErrors)
 		return
 	}
 
-	// Generate a salted and hashed password
-	password, err := bcrypt.GenerateFromPassword([]byte(user.Password), bcrypt.DefaultCost)
-	if err != nil {
-		render.Error(w, r, http.StatusInternalServerError, "failed to generate hashed password")
+	if params.UseCursor {
+		handler.listByCursor(w, r, params, orders)
 		return
 	}
 
-	// Replace the plaintext password with the hashed password
-	user.Password = string(password)
+	// Pages are cached under a hash of everything that selects them and dropped
+	// whenever any user changes
+	pageID := tokens.Hash(fmt.Sprintf("%d|%d|%s|%s", params.Page, params.Limit, params.Order, params.Query))
 
-	// Register the user in the system
-	newUser, err := handler.user.CreateUser(r.Context(), &user)
+	page, err := handler.listCache.Fetch(r.Context(), pageID, func(ctx context.Context) (*models.UserPage, error) {
+		users, total, err := handler.user.ListUsers(ctx, params, orders)
+		if err != nil {
+			return nil, err
+		}
+		return &models.UserPage{Users: models.NewUserResponses(users), Total: total}, nil
+	})
 
 	if err != nil {
 		render.Error(w, r, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	err = handler.cache.SetCache(
-		fmt.Sprintf("users:%d", newUser.ID),
-		newUser,
-		constants.DEFAULT_CACHE_EXPIRATION,
-	)
+	render.JSON(w, http.StatusOK, render.PaginatedResults{
+		Meta:    render.GenerateMeta(page.Total, params, len(page.Users)),
+		Results: page.Users,
+	})
+}
+
+func (handler *UserHandler) listByCursor(w http.ResponseWriter, r *http.Request, params *render.QueryParams, orders []*render.OrderFields) {
+	users, next, err := handler.user.ListUsersByCursor(r.Context(), params, orders)
 
 	if err != nil {
+		if errors.Is(err, render.ErrInvalidCursor) {
+			render.CustomValidationError(w, r, []render.ValidationErrorDetails{{
+				Field:   "cursor",
+				Message: "cursor is invalid or was issued for a different order or query",
+			}})
+			return
+		}
 		render.Error(w, r, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	render.JSON(w, http.StatusOK, newUser)
+	var nextCursor string
+
+	if next != nil {
+		nextCursor, err = render.EncodeCursor(next)
+		if err != nil {
+			render.Error(w, r, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+
+	render.SetCursorLinks(w, r, nextCursor)
+	render.JSON(w, http.StatusOK, render.CursorPaginatedResults{
+		Meta:    render.GenerateCursorMeta(params, len(users), nextCursor),
+		Results: models.NewUserResponses(users),
+	})
 }
 
-func (handler *UserHandler) GetOneByID(w http.ResponseWriter, r *http.Request) {
+// Update replaces the whole profile of a user. Nullable fields missing from the
+// body are cleared.
+func (handler *UserHandler) Update(w http.ResponseWriter, r *http.Request) {
 	id, err := utils.StringToInt(chi.URLParam(r, "id"))
 
 	if err != nil {
@@ -102,14 +246,34 @@ func (handler *UserHandler) GetOneByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// check cache
-	cachedUser, err := handler.cache.GetCache(fmt.Sprintf("users:%d", id))
-	if err == nil {
-		var user []generated.User
-		if err := json.Unmarshal([]byte(cachedUser), &user); err == nil {
-			render.JSON(w, http.StatusOK, user)
-			return
-		}
+	var update models.UpdateUserRequest
+
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(&update); err != nil {
+		render.Error(w, r, http.StatusUnprocessableEntity, "Invalid JSON: "+err.Error())
+		return
+	}
+
+	handler.saveUpdate(w, r, id, &update)
+}
+
+// Patch partially updates a user following JSON Merge Patch (RFC 7396) semantics:
+// members missing from the body are left alone and members set to null are cleared.
+func (handler *UserHandler) Patch(w http.ResponseWriter, r *http.Request) {
+	id, err := utils.StringToInt(chi.URLParam(r, "id"))
+
+	if err != nil {
+		render.Error(w, r, http.StatusBadRequest, constants.INVALID_FORMAT_ID)
+		return
+	}
+
+	patch, err := io.ReadAll(r.Body)
+
+	if err != nil {
+		render.Error(w, r, http.StatusBadRequest, err.Error())
+		return
 	}
 
 	user, err := handler.user.GetUserByID(r.Context(), id)
@@ -118,23 +282,144 @@ func (handler *UserHandler) GetOneByID(w http.ResponseWriter, r *http.Request) {
 		if generated.IsNotFound(err) {
 			render.Error(w, r, http.StatusNotFound, "user not found")
 			return
-		} else {
+		}
+		render.Error(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	current, err := json.Marshal(models.NewUpdateUserRequest(user))
+
+	if err != nil {
+		render.Error(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	merged, err := utils.MergePatch(current, patch)
+
+	if err != nil {
+		render.Error(w, r, http.StatusUnprocessableEntity, "Invalid JSON: "+err.Error())
+		return
+	}
+
+	var update models.UpdateUserRequest
+
+	decoder := json.NewDecoder(bytes.NewReader(merged))
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(&update); err != nil {
+		render.Error(w, r, http.StatusUnprocessableEntity, "Invalid JSON: "+err.Error())
+		return
+	}
+
+	handler.saveUpdate(w, r, id, &update)
+}
+
+// saveUpdate validates and stores an update of a user.
+func (handler *UserHandler) saveUpdate(w http.ResponseWriter, r *http.Request, id int, update *models.UpdateUserRequest) {
+	validate := render.Validator()
+
+	if err := validate.Struct(update); err != nil {
+		render.ValidationError(w, r, err)
+		return
+	}
+
+	user, err := handler.user.UpdateUser(r.Context(), id, update)
+
+	if err != nil {
+		switch {
+		case generated.IsNotFound(err):
+			render.Error(w, r, http.StatusNotFound, "user not found")
+		default:
+			if status, message, ok := uniqueConflict(err); ok {
+				render.Error(w, r, status, message)
+				return
+			}
 			render.Error(w, r, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	render.JSON(w, http.StatusOK, models.NewUserResponse(user))
+}
+
+// Delete soft deletes a user, it can be undone with Restore.
+func (handler *UserHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, err := utils.StringToInt(chi.URLParam(r, "id"))
+
+	if err != nil {
+		render.Error(w, r, http.StatusBadRequest, constants.INVALID_FORMAT_ID)
+		return
+	}
+
+	if err := handler.user.DeleteUser(r.Context(), id); err != nil {
+		if generated.IsNotFound(err) {
+			render.Error(w, r, http.StatusNotFound, "user not found")
+			return
+		}
+		render.Error(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (handler *UserHandler) Restore(w http.ResponseWriter, r *http.Request) {
+	id, err := utils.StringToInt(chi.URLParam(r, "id"))
+
+	if err != nil {
+		render.Error(w, r, http.StatusBadRequest, constants.INVALID_FORMAT_ID)
+		return
+	}
+
+	user, err := handler.user.RestoreUser(r.Context(), id)
+
+	if err != nil {
+		if generated.IsNotFound(err) {
+			render.Error(w, r, http.StatusNotFound, "deleted user not found")
 			return
 		}
+		render.Error(w, r, http.StatusInternalServerError, err.Error())
+		return
 	}
 
-	// set cache
-	err = handler.cache.SetCache(
-		fmt.Sprintf("users:%d", user.ID),
-		user,
-		constants.DEFAULT_CACHE_EXPIRATION,
-	)
+	render.JSON(w, http.StatusOK, models.NewUserResponse(user))
+}
+
+// Purge permanently deletes a user, soft deleted or not. It cannot be undone.
+func (handler *UserHandler) Purge(w http.ResponseWriter, r *http.Request) {
+	id, err := utils.StringToInt(chi.URLParam(r, "id"))
 
 	if err != nil {
+		render.Error(w, r, http.StatusBadRequest, constants.INVALID_FORMAT_ID)
+		return
+	}
+
+	if err := handler.user.PurgeUser(r.Context(), id); err != nil {
+		if generated.IsNotFound(err) {
+			render.Error(w, r, http.StatusNotFound, "user not found")
+			return
+		}
 		render.Error(w, r, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	render.JSON(w, http.StatusOK, user)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// uniqueConflict maps the conflicts of saving a user to a status and a fixed
+// message, so that database errors never reach the client. The email or phone
+// number may still belong to a soft deleted user.
+func uniqueConflict(err error) (int, string, bool) {
+	switch {
+	case errors.Is(err, services.ErrEmailTaken):
+		return http.StatusConflict, constants.EMAIL_IN_USE, true
+	case errors.Is(err, services.ErrEmailHeldByDeletedUser):
+		return http.StatusConflict, constants.EMAIL_HELD_BY_DELETED_USER, true
+	case errors.Is(err, services.ErrPhoneNumberTaken):
+		return http.StatusConflict, constants.PHONE_NUMBER_IN_USE, true
+	case generated.IsConstraintError(err):
+		return http.StatusConflict, constants.EMAIL_IN_USE, true
+	}
+
+	return 0, "", false
 }
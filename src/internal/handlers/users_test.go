@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/ryuudan/golang-rest-api/ent/generated"
+	"github.com/ryuudan/golang-rest-api/src/internal/models"
+	"github.com/ryuudan/golang-rest-api/src/internal/services"
+)
+
+// takenEmails answers every write as if another user held the email already.
+type takenEmails struct {
+	services.UserService
+}
+
+func (takenEmails) CreateUser(ctx context.Context, newUser *generated.User) (*generated.User, error) {
+	return nil, services.ErrEmailTaken
+}
+
+func (takenEmails) UpdateUser(ctx context.Context, id int, update *models.UpdateUserRequest) (*generated.User, error) {
+	return nil, services.ErrEmailTaken
+}
+
+func TestTakenEmailIsAConflict(t *testing.T) {
+	handler := NewUserHandler(takenEmails{}, nil, nil)
+	router := chi.NewRouter()
+	router.Post("/users", handler.Create)
+	router.Put("/users/{id}", handler.Update)
+
+	requests := map[string]*http.Request{
+		"create": httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(
+			`{"first_name":"Ada","last_name":"Lovelace","birthday":"1815-12-10T00:00:00Z","email":"ada@example.com","password":"x"}`)),
+		"update": httptest.NewRequest(http.MethodPut, "/users/1", strings.NewReader(
+			`{"first_name":"Ada","last_name":"Lovelace","email":"ada@example.com"}`)),
+	}
+
+	for name, request := range requests {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+
+		if recorder.Code != http.StatusConflict {
+			t.Errorf("%s: got status %d, want %d: %s", name, recorder.Code, http.StatusConflict, recorder.Body.String())
+		}
+	}
+}
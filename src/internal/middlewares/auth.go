@@ -0,0 +1,150 @@
+package middlewares
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/ryuudan/golang-rest-api/src/constants"
+	"github.com/ryuudan/golang-rest-api/src/internal/models"
+	"github.com/ryuudan/golang-rest-api/src/utils/render"
+	"github.com/ryuudan/golang-rest-api/src/utils/tokens"
+)
+
+type contextKey string
+
+const userIDKey contextKey = "user_id"
+const claimsKey contextKey = "claims"
+const apiKeyKey contextKey = "api_key"
+
+// RevocationChecker reports whether an otherwise valid access token was revoked
+// by a logout.
+type RevocationChecker interface {
+	IsRevoked(ctx context.Context, claims *tokens.AccessClaims) (bool, error)
+}
+
+// APIKeyAuthenticator resolves a personal API key to who it acts as, or to nil
+// when the key cannot be used.
+type APIKeyAuthenticator interface {
+	Authenticate(ctx context.Context, key string) (*models.APIKeyPrincipal, error)
+}
+
+// Authenticate verifies the bearer access token or the API key of every request
+// and stores the authenticated user's ID in the request context, together with
+// the token claims or the API key. API keys are sent in an X-API-Key header or
+// as the bearer token, and act as their user within the key's scopes. Requests
+// with a missing, invalid, expired or revoked token or key are rejected with a
+// 401.
+func Authenticate(secret string, revocations RevocationChecker, apiKeys APIKeyAuthenticator) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			tokenString, ok := bearerToken(r)
+
+			if key := r.Header.Get("X-API-Key"); key != "" || strings.HasPrefix(tokenString, constants.API_KEY_PREFIX) {
+				if key == "" {
+					key = tokenString
+				}
+
+				principal, err := apiKeys.Authenticate(r.Context(), key)
+				if err != nil {
+					render.Error(w, r, http.StatusInternalServerError, err.Error())
+					return
+				}
+
+				if principal == nil {
+					render.Error(w, r, http.StatusUnauthorized, constants.UNAUTHORIZED)
+					return
+				}
+
+				ctx := WithUserID(r.Context(), principal.UserID)
+				ctx = context.WithValue(ctx, apiKeyKey, principal)
+
+				next.ServeHTTP(w, r.WithContext(ctx))
+				return
+			}
+
+			if !ok {
+				render.Error(w, r, http.StatusUnauthorized, constants.UNAUTHORIZED)
+				return
+			}
+
+			claims, err := tokens.ParseAccessToken(tokenString, secret)
+			if err != nil {
+				render.Error(w, r, http.StatusUnauthorized, constants.UNAUTHORIZED)
+				return
+			}
+
+			userID, err := claims.UserID()
+			if err != nil {
+				render.Error(w, r, http.StatusUnauthorized, constants.UNAUTHORIZED)
+				return
+			}
+
+			revoked, err := revocations.IsRevoked(r.Context(), claims)
+			if err != nil {
+				render.Error(w, r, http.StatusInternalServerError, err.Error())
+				return
+			}
+
+			if revoked {
+				render.Error(w, r, http.StatusUnauthorized, constants.UNAUTHORIZED)
+				return
+			}
+
+			ctx := WithUserID(r.Context(), userID)
+			ctx = context.WithValue(ctx, claimsKey, claims)
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// WithUserID returns a copy of ctx that carries the authenticated user's ID.
+func WithUserID(ctx context.Context, userID int) context.Context {
+	return context.WithValue(ctx, userIDKey, userID)
+}
+
+// UserIDFromContext returns the authenticated user's ID set by Authenticate.
+func UserIDFromContext(ctx context.Context) (int, bool) {
+	userID, ok := ctx.Value(userIDKey).(int)
+	return userID, ok
+}
+
+// ClaimsFromContext returns the claims of the access token verified by Authenticate.
+func ClaimsFromContext(ctx context.Context) (*tokens.AccessClaims, bool) {
+	claims, ok := ctx.Value(claimsKey).(*tokens.AccessClaims)
+	return claims, ok
+}
+
+// APIKeyFromContext returns the API key the request was authenticated with by
+// Authenticate, if it was not authenticated with an access token.
+func APIKeyFromContext(ctx context.Context) (*models.APIKeyPrincipal, bool) {
+	principal, ok := ctx.Value(apiKeyKey).(*models.APIKeyPrincipal)
+	return principal, ok
+}
+
+// RequireSession rejects requests authenticated with an API key with a 403, for
+// actions only a logged in user may take, like managing their credentials. It
+// must be mounted after Authenticate.
+func RequireSession(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := APIKeyFromContext(r.Context()); ok {
+			render.Error(w, r, http.StatusForbidden, constants.SESSION_REQUIRED)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// bearerToken extracts the token from an "Authorization: Bearer <token>" header.
+func bearerToken(r *http.Request) (string, bool) {
+	header := r.Header.Get("Authorization")
+	scheme, token, found := strings.Cut(header, " ")
+
+	if !found || !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return "", false
+	}
+
+	return strings.TrimSpace(token), true
+}
@@ -0,0 +1,47 @@
+package middlewares
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/ryuudan/golang-rest-api/src/constants"
+	"github.com/ryuudan/golang-rest-api/src/utils/render"
+)
+
+// MFAPolicyChecker reports whether a user meets the two-factor authentication
+// policy for their roles.
+type MFAPolicyChecker interface {
+	IsCompliant(ctx context.Context, userID int) (bool, error)
+}
+
+// RequireMFA rejects requests of users that should have two-factor
+// authentication enabled but have not with a 403. It must be mounted after
+// Authenticate.
+//
+// Example:
+//
+//	private.With(middlewares.RequireMFA(mfaService)).Route("/users", ...)
+func RequireMFA(checker MFAPolicyChecker) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userID, ok := UserIDFromContext(r.Context())
+			if !ok {
+				render.Error(w, r, http.StatusUnauthorized, constants.UNAUTHORIZED)
+				return
+			}
+
+			compliant, err := checker.IsCompliant(r.Context(), userID)
+			if err != nil {
+				render.Error(w, r, http.StatusInternalServerError, err.Error())
+				return
+			}
+
+			if !compliant {
+				render.Error(w, r, http.StatusForbidden, constants.MFA_REQUIRED)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
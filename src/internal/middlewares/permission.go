@@ -0,0 +1,62 @@
+package middlewares
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/ryuudan/golang-rest-api/src/constants"
+	"github.com/ryuudan/golang-rest-api/src/utils/render"
+)
+
+// PermissionChecker reports whether a user was granted a permission through any of their roles.
+type PermissionChecker interface {
+	HasPermission(ctx context.Context, id int, permission string) (bool, error)
+}
+
+type Authorizer struct {
+	permissions PermissionChecker
+}
+
+func NewAuthorizer(permissions PermissionChecker) *Authorizer {
+	return &Authorizer{
+		permissions: permissions,
+	}
+}
+
+// RequirePermission only lets requests of authenticated users holding the given
+// permission through and rejects everyone else with a 403. Requests made with an
+// API key also need the permission among the key's scopes. It must be mounted
+// after Authenticate.
+//
+// Example:
+//
+//	r.With(authorizer.RequirePermission("users:write")).Post("/", userHandler.Create)
+func (authorizer *Authorizer) RequirePermission(permission string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userID, ok := UserIDFromContext(r.Context())
+			if !ok {
+				render.Error(w, r, http.StatusUnauthorized, constants.UNAUTHORIZED)
+				return
+			}
+
+			allowed, err := authorizer.permissions.HasPermission(r.Context(), userID, permission)
+			if err != nil {
+				render.Error(w, r, http.StatusInternalServerError, err.Error())
+				return
+			}
+
+			if !allowed {
+				render.Error(w, r, http.StatusForbidden, constants.FORBIDDEN)
+				return
+			}
+
+			if key, ok := APIKeyFromContext(r.Context()); ok && !key.Allows(permission) {
+				render.Error(w, r, http.StatusForbidden, constants.INSUFFICIENT_SCOPE)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package middlewares
+
+import (
+	"context"
+	"log"
+	"net/http"
+
+	"github.com/ryuudan/golang-rest-api/src/constants"
+	"github.com/ryuudan/golang-rest-api/src/internal/models"
+	"github.com/ryuudan/golang-rest-api/src/utils/render"
+)
+
+// QuotaConsumer counts requests against the limits of the plan of a user.
+type QuotaConsumer interface {
+	Consume(ctx context.Context, userID int) (*models.RateLimit, error)
+}
+
+// Quota enforces the hourly limit and the daily and monthly quotas of the
+// authenticated user's plan, across all instances. Requests made with any of the
+// user's API keys are counted against the user. It must be mounted after
+// Authenticate. Like RateLimit, it sets the RateLimit-* headers, rejects requests
+// over a limit with a 429, and lets requests through when the check itself fails.
+//
+// Example:
+//
+//	r.Use(middlewares.Quota(quotaService))
+func Quota(quotas QuotaConsumer) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userID, ok := UserIDFromContext(r.Context())
+			if !ok {
+				render.Error(w, r, http.StatusUnauthorized, constants.UNAUTHORIZED)
+				return
+			}
+
+			result, err := quotas.Consume(r.Context(), userID)
+			if err != nil {
+				log.Printf("❌ Failed to check the quota of user %d, letting the request through: %v", userID, err)
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			SetRateLimitHeaders(w, result)
+
+			if !result.Allowed {
+				render.Error(w, r, http.StatusTooManyRequests, constants.TOO_MANY_REQUESTS)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
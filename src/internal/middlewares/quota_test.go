@@ -0,0 +1,78 @@
+package middlewares
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/ryuudan/golang-rest-api/ent/generated"
+	"github.com/ryuudan/golang-rest-api/ent/generated/user"
+	"github.com/ryuudan/golang-rest-api/src/database"
+	"github.com/ryuudan/golang-rest-api/src/internal/models"
+	"github.com/ryuudan/golang-rest-api/src/internal/repositories"
+	"github.com/ryuudan/golang-rest-api/src/internal/services"
+)
+
+// freeUsers finds every user on the free plan.
+type freeUsers struct {
+	repositories.UserRepository
+}
+
+func (freeUsers) GetByID(ctx context.Context, id int) (*generated.User, error) {
+	return &generated.User{ID: id, Plan: user.PlanFree}, nil
+}
+
+func TestQuotaIsSharedByTheKeysOfAUser(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	cache := database.NewRedisCache(client, database.CacheTimeouts{Read: time.Second, Write: time.Second}, database.NewEncoder(database.JSONCodec{}, database.CompressionNone, 0))
+	plans := database.NewCache[user.Plan](cache.Namespace("user_plans"), time.Minute)
+	quotas := services.NewQuotaService(freeUsers{}, repositories.NewRateLimitRepository(client), repositories.NewQuotaRepository(client), plans)
+
+	handler := Quota(quotas)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	// Two requests of user 1, each made with another of their API keys
+	remaining := make([]int, 0, 2)
+	for _, keyID := range []int{1, 2} {
+		ctx := WithUserID(context.Background(), 1)
+		ctx = context.WithValue(ctx, apiKeyKey, &models.APIKeyPrincipal{ID: keyID, UserID: 1})
+
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx))
+
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("key %d: status = %d, want 200", keyID, recorder.Code)
+		}
+
+		left, err := strconv.Atoi(recorder.Header().Get("RateLimit-Remaining"))
+		if err != nil {
+			t.Fatalf("key %d: RateLimit-Remaining: %v", keyID, err)
+		}
+		remaining = append(remaining, left)
+	}
+
+	if remaining[1] != remaining[0]-1 {
+		t.Errorf("remaining = %v, want the second key to see the request of the first", remaining)
+	}
+
+	day := fmt.Sprintf("quotas:user:1:day:%s", time.Now().UTC().Format("2006-01-02"))
+	if count, _ := server.Get(day); count != "2" {
+		t.Errorf("daily count of the user = %q, want 2", count)
+	}
+
+	usage, err := quotas.Usage(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("Usage: %v", err)
+	}
+	if usage.Daily.Remaining != usage.Daily.Limit-2 {
+		t.Errorf("daily remaining = %d of %d, want both requests counted", usage.Daily.Remaining, usage.Daily.Limit)
+	}
+}
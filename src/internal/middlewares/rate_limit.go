@@ -0,0 +1,91 @@
+package middlewares
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ryuudan/golang-rest-api/src/constants"
+	"github.com/ryuudan/golang-rest-api/src/internal/models"
+	"github.com/ryuudan/golang-rest-api/src/utils/render"
+)
+
+// RateLimiter counts requests against limits shared by every API instance.
+type RateLimiter interface {
+	Allow(ctx context.Context, key string, limit int, period time.Duration) (*models.RateLimit, error)
+}
+
+// KeyFunc returns what a request is counted against, e.g. the client IP.
+type KeyFunc func(r *http.Request) string
+
+// KeyByIP counts requests per client IP. Mount it after proxies.RealIP so that
+// clients behind a proxy are told apart.
+func KeyByIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// RateLimit allows limit requests per period for every key, across all instances.
+// The group names the limit, so that route groups with their own limits do not
+// share counters. Every response carries the RateLimit-* headers, and requests
+// over the limit are rejected with a 429 and a Retry-After header.
+//
+// When the limiter itself fails the request is let through, since an outage of
+// Redis should not take the whole API down with it.
+//
+// Example:
+//
+//	r.Use(middlewares.RateLimit(limiter, "public", 100, time.Minute, middlewares.KeyByIP))
+func RateLimit(limiter RateLimiter, group string, limit int, period time.Duration, key KeyFunc) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			result, err := limiter.Allow(r.Context(), fmt.Sprintf("%s:%s", group, key(r)), limit, period)
+			if err != nil {
+				log.Printf("❌ Failed to check the %s rate limit, letting the request through: %v", group, err)
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			SetRateLimitHeaders(w, result)
+
+			if !result.Allowed {
+				render.Error(w, r, http.StatusTooManyRequests, constants.TOO_MANY_REQUESTS)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// SetRateLimitHeaders describes the state of a limit to the client, following the
+// IETF RateLimit header fields draft. Durations are rounded up to whole seconds.
+// Limits checked later in the chain replace the headers of earlier ones, so the
+// client is told about the most specific limit that applies to it.
+func SetRateLimitHeaders(w http.ResponseWriter, result *models.RateLimit) {
+	w.Header().Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d", result.Limit, int(result.Period.Seconds())))
+	w.Header().Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+	w.Header().Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	w.Header().Set("RateLimit-Reset", seconds(result.ResetAfter))
+
+	if !result.Allowed {
+		SetRetryAfter(w, result.RetryAfter)
+	}
+}
+
+// SetRetryAfter tells the client how long to wait before trying again.
+func SetRetryAfter(w http.ResponseWriter, retryAfter time.Duration) {
+	w.Header().Set("Retry-After", seconds(retryAfter))
+}
+
+func seconds(duration time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(duration.Seconds())))
+}
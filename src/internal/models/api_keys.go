@@ -0,0 +1,78 @@
+package models
+
+import (
+	"time"
+
+	"github.com/ryuudan/golang-rest-api/ent/generated"
+)
+
+// CreateAPIKeyRequest is the payload accepted when creating an API key. Scopes
+// are permission names, and only permissions the user holds can be granted.
+type CreateAPIKeyRequest struct {
+	Name      string     `json:"name" validate:"required,max=100"`
+	Scopes    []string   `json:"scopes" validate:"required,min=1,dive,required"`
+	ExpiresAt *time.Time `json:"expires_at" validate:"omitempty,gt"` // Never expires when omitted
+}
+
+// APIKeyResponse is the public representation of an API key. It never carries
+// the secret.
+type APIKeyResponse struct {
+	ID         int        `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// NewAPIKeyResponse maps an API key entity to its public representation.
+func NewAPIKeyResponse(key *generated.APIKey) *APIKeyResponse {
+	return &APIKeyResponse{
+		ID:         key.ID,
+		Name:       key.Name,
+		Prefix:     key.Prefix,
+		Scopes:     key.Scopes,
+		ExpiresAt:  key.ExpiresAt,
+		LastUsedAt: key.LastUsedAt,
+		RevokedAt:  key.RevokedAt,
+		CreatedAt:  key.CreatedAt,
+	}
+}
+
+// NewAPIKeyResponses maps a list of API key entities. It never returns nil, so
+// an empty list is rendered as [] rather than null.
+func NewAPIKeyResponses(keys []*generated.APIKey) []*APIKeyResponse {
+	responses := make([]*APIKeyResponse, 0, len(keys))
+
+	for _, key := range keys {
+		responses = append(responses, NewAPIKeyResponse(key))
+	}
+
+	return responses
+}
+
+// CreatedAPIKeyResponse is returned once when an API key is created. It is the
+// only time the full key is shown, only its hash is kept.
+type CreatedAPIKeyResponse struct {
+	*APIKeyResponse
+	Key string `json:"key"`
+}
+
+// APIKeyPrincipal is who a request authenticated with an API key acts as.
+type APIKeyPrincipal struct {
+	ID     int
+	UserID int
+	Scopes []string
+}
+
+// Allows reports whether the key was granted the permission.
+func (principal *APIKeyPrincipal) Allows(permission string) bool {
+	for _, scope := range principal.Scopes {
+		if scope == permission {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,59 @@
+package models
+
+// LoginRequest is the payload accepted by the login endpoint.
+type LoginRequest struct {
+	Email    string `json:"email" validate:"required,email"`
+	Password string `json:"password" validate:"required"`
+}
+
+// RefreshRequest is the payload accepted by the refresh endpoint.
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+// LogoutRequest is the optional payload of the logout endpoint. When a refresh
+// token is given, its whole token family is revoked along with the access token.
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// ForgotPasswordRequest is the payload of the forgot password endpoint.
+type ForgotPasswordRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+// ResetPasswordRequest is the payload of the reset password endpoint. The token
+// is the one mailed by the forgot password endpoint.
+type ResetPasswordRequest struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required"` // Checked against the password rules
+}
+
+// ResendVerificationRequest is the payload of the resend verification endpoint.
+type ResendVerificationRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+// TokenResponse is returned whenever the API issues a new set of credentials.
+type TokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"` // Lifetime of the access token in seconds
+}
+
+// RefreshToken is the server side record of an issued refresh token. Tokens
+// rotated from the same login share a FamilyID, and Uses counts how many times
+// the token was presented so that a replayed token can be detected.
+type RefreshToken struct {
+	UserID   int    `redis:"user_id"`
+	FamilyID string `redis:"family_id"`
+	IssuedAt int64  `redis:"issued_at"` // Unix milliseconds of the issuance
+	Uses     int    `redis:"uses"`
+}
+
+// PasswordResetToken is the server side record of an issued password reset token.
+type PasswordResetToken struct {
+	UserID   int   `redis:"user_id"`
+	IssuedAt int64 `redis:"issued_at"` // Unix milliseconds of the issuance
+}
@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+// LockoutPolicy decides how failed logins of one subject, an account or a client
+// IP, slow it down. After FreeFailures every further failure doubles the wait
+// before the next attempt, from BaseBackoff up to MaxBackoff, and MaxFailures
+// lock the subject out for LockDuration. Failures are forgotten after Window.
+type LockoutPolicy struct {
+	FreeFailures int
+	MaxFailures  int
+	Window       time.Duration
+	BaseBackoff  time.Duration
+	MaxBackoff   time.Duration
+	LockDuration time.Duration
+}
+
+// LoginSubject is what failed logins are counted against, an account or a client
+// IP, along with its policy.
+type LoginSubject struct {
+	Name   string
+	Policy *LockoutPolicy
+}
+
+// LoginFailure is the outcome of counting a failed login against a subject.
+type LoginFailure struct {
+	Failures   int
+	Locked     bool          // Whether this failure locked the subject out
+	RetryAfter time.Duration // Until the subject may try again, zero when it may right away
+}
+
+// LoginReservation is the outcome of reserving a login attempt against several
+// subjects at once. An allowed attempt is counted as failed against every one of
+// them right away, in the order of the subjects, until it is refunded.
+type LoginReservation struct {
+	Allowed    bool
+	RetryAfter time.Duration   // Until every subject may try again, when not allowed
+	Failures   []*LoginFailure // One per subject, when allowed
+}
@@ -0,0 +1,32 @@
+package models
+
+// MFACodeRequest carries a TOTP code, or a recovery code where one is accepted.
+type MFACodeRequest struct {
+	Code string `json:"code" validate:"required"`
+}
+
+// MFALoginRequest completes a login of a user with two-factor authentication.
+type MFALoginRequest struct {
+	MFAToken string `json:"mfa_token" validate:"required"`
+	Code     string `json:"code" validate:"required"` // A TOTP code or an unused recovery code
+}
+
+// MFAChallengeResponse is returned instead of tokens when the password of a user
+// with two-factor authentication was correct. The MFA token has to be exchanged
+// together with a code for the actual tokens.
+type MFAChallengeResponse struct {
+	MFAToken  string `json:"mfa_token"`
+	ExpiresIn int    `json:"expires_in"` // Lifetime of the MFA token in seconds
+}
+
+// MFAEnrollmentResponse holds a new TOTP secret, to be added to an authenticator
+// app, either typed in or scanned from the otpauth:// URL as a QR code.
+type MFAEnrollmentResponse struct {
+	Secret string `json:"secret"`
+	URL    string `json:"otpauth_url"`
+}
+
+// RecoveryCodesResponse lists new recovery codes. They are only ever shown once.
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
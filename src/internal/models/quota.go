@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// Plan holds the limits of a plan tier. The hourly limit is enforced as a rate,
+// the daily and monthly quotas as counters that reset at the start of every UTC
+// day and month.
+type Plan struct {
+	HourlyLimit  int
+	DailyQuota   int
+	MonthlyQuota int
+}
+
+// QuotaCounts are the requests a principal made in the current day and month.
+type QuotaCounts struct {
+	Allowed         bool // Whether the request was counted, false once a quota is used up
+	Daily           int
+	Monthly         int
+	DailyResetsAt   time.Time
+	MonthlyResetsAt time.Time
+}
+
+// QuotaWindow reports the state of one of the limits of a plan.
+type QuotaWindow struct {
+	Limit     int       `json:"limit"`
+	Remaining int       `json:"remaining"`
+	ResetsAt  time.Time `json:"resets_at"`
+}
+
+// UsageResponse is what GET /api/me/usage reports about the caller's limits.
+type UsageResponse struct {
+	Plan    string       `json:"plan"`
+	Hourly  *QuotaWindow `json:"hourly"`
+	Daily   *QuotaWindow `json:"daily"`
+	Monthly *QuotaWindow `json:"monthly"`
+}
@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// RateLimit is the outcome of counting a request against a rate limit.
+type RateLimit struct {
+	Allowed    bool
+	Limit      int
+	Period     time.Duration
+	Remaining  int
+	ResetAfter time.Duration // Until the whole limit is available again
+	RetryAfter time.Duration // Until the next request is allowed, zero when this one was
+}
@@ -1 +1,110 @@
 package models
+
+import (
+	"time"
+
+	"github.com/ryuudan/golang-rest-api/ent/generated"
+)
+
+// CreateUserRequest is the payload accepted when registering a new user.
+type CreateUserRequest struct {
+	FirstName   string     `json:"first_name" validate:"required,min=1"`
+	LastName    string     `json:"last_name" validate:"required,min=1"`
+	MiddleName  *string    `json:"middle_name" validate:"omitempty,min=1"`
+	Birthday    *time.Time `json:"birthday" validate:"required"`
+	Email       string     `json:"email" validate:"required,email"`
+	PhoneNumber *string    `json:"phone_number" validate:"omitempty,e164"`
+	Password    string     `json:"password" validate:"required"` // Checked against the password rules by UserService.CreateUser
+}
+
+// ToUser maps the request to a user entity ready to be stored. The password is
+// copied as is, UserService.CreateUser hashes it.
+func (request *CreateUserRequest) ToUser() *generated.User {
+	return &generated.User{
+		FirstName:   request.FirstName,
+		LastName:    request.LastName,
+		MiddleName:  request.MiddleName,
+		Birthday:    request.Birthday,
+		Email:       request.Email,
+		PhoneNumber: request.PhoneNumber,
+		Password:    request.Password,
+	}
+}
+
+// UpdateUserRequest is the full representation of a user's editable profile. A
+// PUT replaces all of it, so omitted nullable fields are cleared. The password is
+// not part of the profile and cannot be changed here.
+type UpdateUserRequest struct {
+	FirstName   string     `json:"first_name" validate:"required,min=1"`
+	LastName    string     `json:"last_name" validate:"required,min=1"`
+	MiddleName  *string    `json:"middle_name" validate:"omitempty,min=1"`
+	Birthday    *time.Time `json:"birthday"`
+	Email       string     `json:"email" validate:"required,email"`
+	PhoneNumber *string    `json:"phone_number" validate:"omitempty,e164"`
+}
+
+// NewUpdateUserRequest returns the editable profile of an existing user, which
+// is the document a JSON merge patch is applied to.
+func NewUpdateUserRequest(user *generated.User) *UpdateUserRequest {
+	return &UpdateUserRequest{
+		FirstName:   user.FirstName,
+		LastName:    user.LastName,
+		MiddleName:  user.MiddleName,
+		Birthday:    user.Birthday,
+		Email:       user.Email,
+		PhoneNumber: user.PhoneNumber,
+	}
+}
+
+// UserResponse is the public representation of a user. It is the only shape a
+// user is ever rendered or cached in, so it must never carry the password hash.
+type UserResponse struct {
+	ID              int        `json:"id"`
+	FirstName       string     `json:"first_name"`
+	LastName        string     `json:"last_name"`
+	MiddleName      *string    `json:"middle_name"`
+	Birthday        *time.Time `json:"birthday"`
+	Email           string     `json:"email"`
+	PhoneNumber     *string    `json:"phone_number"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`
+	Plan            string     `json:"plan"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+	DeletedAt       *time.Time `json:"deleted_at,omitempty"`
+}
+
+// NewUserResponse maps a user entity to its public representation.
+func NewUserResponse(user *generated.User) *UserResponse {
+	return &UserResponse{
+		ID:              user.ID,
+		FirstName:       user.FirstName,
+		LastName:        user.LastName,
+		MiddleName:      user.MiddleName,
+		Birthday:        user.Birthday,
+		Email:           user.Email,
+		PhoneNumber:     user.PhoneNumber,
+		EmailVerifiedAt: user.EmailVerifiedAt,
+		Plan:            string(user.Plan),
+		CreatedAt:       user.CreatedAt,
+		UpdatedAt:       user.UpdatedAt,
+		DeletedAt:       user.DeletedAt,
+	}
+}
+
+// NewUserResponses maps a list of user entities. It never returns nil, so an
+// empty list is rendered as [] rather than null.
+func NewUserResponses(users []*generated.User) []*UserResponse {
+	responses := make([]*UserResponse, 0, len(users))
+
+	for _, user := range users {
+		responses = append(responses, NewUserResponse(user))
+	}
+
+	return responses
+}
+
+// UserPage is a cached page of a user listing.
+type UserPage struct {
+	Users []*UserResponse `json:"users"`
+	Total int             `json:"total"`
+}
@@ -0,0 +1,109 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/ryuudan/golang-rest-api/ent/generated"
+	"github.com/ryuudan/golang-rest-api/ent/generated/apikey"
+	"github.com/ryuudan/golang-rest-api/ent/generated/user"
+)
+
+type APIKeyRepository interface {
+	Create(ctx context.Context, key *generated.APIKey) (*generated.APIKey, error)
+	GetByPrefix(ctx context.Context, prefix string) (*generated.APIKey, error)
+	ListByUser(ctx context.Context, userID int) ([]*generated.APIKey, error)
+	CountActive(ctx context.Context, userID int, now time.Time) (int, error)
+	Revoke(ctx context.Context, id int, userID int) (bool, error)
+	Touch(ctx context.Context, id int, usedAt time.Time, before time.Time) error
+}
+
+type apiKeyRepository struct {
+	client *generated.APIKeyClient
+}
+
+func NewAPIKeyRepository(client *generated.APIKeyClient) APIKeyRepository {
+	return &apiKeyRepository{client: client}
+}
+
+// keys returns the client of the transaction carried by ctx, if there is one.
+func (repo *apiKeyRepository) keys(ctx context.Context) *generated.APIKeyClient {
+	if tx := generated.TxFromContext(ctx); tx != nil {
+		return tx.APIKey
+	}
+	return repo.client
+}
+
+func (repo *apiKeyRepository) Create(ctx context.Context, key *generated.APIKey) (*generated.APIKey, error) {
+	return repo.keys(ctx).Create().
+		SetName(key.Name).
+		SetPrefix(key.Prefix).
+		SetSecretHash(key.SecretHash).
+		SetScopes(key.Scopes).
+		SetNillableExpiresAt(key.ExpiresAt).
+		SetUserID(key.UserID).
+		Save(ctx)
+}
+
+// GetByPrefix returns the key with the given prefix, whether or not it is still
+// usable. Keys of soft-deleted users are not found.
+func (repo *apiKeyRepository) GetByPrefix(ctx context.Context, prefix string) (*generated.APIKey, error) {
+	return repo.client.Query().
+		Where(
+			apikey.PrefixEQ(prefix),
+			apikey.HasUserWith(user.DeletedAtIsNil()),
+		).
+		Only(ctx)
+}
+
+// ListByUser returns every key of the user, revoked ones included, newest first.
+func (repo *apiKeyRepository) ListByUser(ctx context.Context, userID int) ([]*generated.APIKey, error) {
+	return repo.client.Query().
+		Where(apikey.UserIDEQ(userID)).
+		Order(generated.Desc(apikey.FieldCreatedAt), generated.Desc(apikey.FieldID)).
+		All(ctx)
+}
+
+// CountActive counts the keys of the user that are neither revoked nor expired at now.
+func (repo *apiKeyRepository) CountActive(ctx context.Context, userID int, now time.Time) (int, error) {
+	return repo.keys(ctx).Query().
+		Where(
+			apikey.UserIDEQ(userID),
+			apikey.RevokedAtIsNil(),
+			apikey.Or(apikey.ExpiresAtIsNil(), apikey.ExpiresAtGT(now)),
+		).
+		Count(ctx)
+}
+
+// Revoke marks the user's key as revoked, and reports whether there was such a
+// key that was not revoked yet.
+func (repo *apiKeyRepository) Revoke(ctx context.Context, id int, userID int) (bool, error) {
+	updated, err := repo.client.Update().
+		Where(
+			apikey.IDEQ(id),
+			apikey.UserIDEQ(userID),
+			apikey.RevokedAtIsNil(),
+		).
+		SetRevokedAt(time.Now()).
+		Save(ctx)
+
+	if err != nil {
+		return false, err
+	}
+
+	return updated == 1, nil
+}
+
+// Touch records that the key was used at usedAt, unless it was already recorded
+// as used after before. That keeps busy keys from writing on every request.
+func (repo *apiKeyRepository) Touch(ctx context.Context, id int, usedAt time.Time, before time.Time) error {
+	_, err := repo.client.Update().
+		Where(
+			apikey.IDEQ(id),
+			apikey.Or(apikey.LastUsedAtIsNil(), apikey.LastUsedAtLT(before)),
+		).
+		SetLastUsedAt(usedAt).
+		Save(ctx)
+
+	return err
+}
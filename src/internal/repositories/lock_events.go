@@ -0,0 +1,38 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/ryuudan/golang-rest-api/ent/generated"
+)
+
+type LockEventRepository interface {
+	Create(ctx context.Context, event *generated.LockEvent) (*generated.LockEvent, error)
+}
+
+type lockEventRepository struct {
+	client *generated.LockEventClient
+}
+
+func NewLockEventRepository(client *generated.LockEventClient) LockEventRepository {
+	return &lockEventRepository{client: client}
+}
+
+func (repo *lockEventRepository) Create(ctx context.Context, event *generated.LockEvent) (*generated.LockEvent, error) {
+	created, err := repo.client.Create().
+		SetScope(event.Scope).
+		SetSubject(event.Subject).
+		SetAction(event.Action).
+		SetFailures(event.Failures).
+		SetNillableLockedUntil(event.LockedUntil).
+		SetNillableIP(event.IP).
+		SetNillableUserID(event.UserID).
+		SetNillableActorID(event.ActorID).
+		Save(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return created, nil
+}
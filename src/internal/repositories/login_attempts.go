@@ -0,0 +1,181 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/ryuudan/golang-rest-api/src/internal/models"
+)
+
+type LoginAttemptRepository interface {
+	Reserve(ctx context.Context, token string, subjects []*models.LoginSubject) (*models.LoginReservation, error)
+	Refund(ctx context.Context, token string, subject *models.LoginSubject, failure *models.LoginFailure) error
+	Reset(ctx context.Context, subject string) error
+}
+
+type loginAttemptRepository struct {
+	client *redis.Client
+}
+
+func NewLoginAttemptRepository(client *redis.Client) LoginAttemptRepository {
+	return &loginAttemptRepository{client: client}
+}
+
+// loginFailuresKey counts the recent failed logins of a subject.
+func loginFailuresKey(subject string) string {
+	return fmt.Sprintf("login_failures:%s", subject)
+}
+
+// loginBlockKey exists while a subject has to wait before trying again, either
+// backing off or locked out. It expires when the subject may try again, and holds
+// the token of the attempt that blocked it.
+func loginBlockKey(subject string) string {
+	return fmt.Sprintf("login_blocks:%s", subject)
+}
+
+// reserveScript reserves a login attempt against every subject at once. While any
+// of them is blocked nothing is counted, otherwise the attempt is counted as a
+// failure of each subject right away and blocks it for as long as its policy
+// asks, so that concurrent attempts cannot all slip in before the first one fails.
+// A lockout also forgets the failures, so that the subject starts over once it
+// ends.
+//
+// The keys are the failures and block key of each subject, and the arguments the
+// attempt token followed by the six policy values of each subject. It returns
+// whether the attempt is allowed and how many milliseconds until it would be,
+// followed by the failures counted, whether the subject was locked out and how
+// many milliseconds it is blocked for, for each subject.
+var reserveScript = redis.NewScript(`
+local token = ARGV[1]
+
+local retry = 0
+for i = 2, #KEYS, 2 do
+	retry = math.max(retry, redis.call("PTTL", KEYS[i]))
+end
+
+if retry > 0 then
+	return {0, retry}
+end
+
+local result = {1, 0}
+
+for subject = 1, #KEYS / 2 do
+	local failures_key = KEYS[subject * 2 - 1]
+	local block_key = KEYS[subject * 2]
+	local offset = 1 + (subject - 1) * 6
+
+	local window = tonumber(ARGV[offset + 1])
+	local free = tonumber(ARGV[offset + 2])
+	local max_failures = tonumber(ARGV[offset + 3])
+	local base = tonumber(ARGV[offset + 4])
+	local max_backoff = tonumber(ARGV[offset + 5])
+	local lock = tonumber(ARGV[offset + 6])
+
+	local failures = redis.call("INCR", failures_key)
+	if failures == 1 then
+		redis.call("PEXPIRE", failures_key, window)
+	end
+
+	local locked, blocked = 0, 0
+
+	if failures >= max_failures then
+		redis.call("DEL", failures_key)
+		redis.call("SET", block_key, token, "PX", lock)
+		locked, blocked = 1, lock
+	elseif failures > free then
+		blocked = math.floor(math.min(base * 2 ^ (failures - free - 1), max_backoff))
+		redis.call("SET", block_key, token, "PX", blocked)
+	end
+
+	table.insert(result, failures)
+	table.insert(result, locked)
+	table.insert(result, blocked)
+end
+
+return result
+`)
+
+// refundScript takes back the failure counted for a reserved attempt that did
+// not fail. The block the attempt caused is lifted, unless a later attempt
+// replaced it, and failures forgotten by a lockout the attempt caused are restored.
+var refundScript = redis.NewScript(`
+local token = ARGV[1]
+local locked = ARGV[2] == "1"
+local failures = tonumber(ARGV[3])
+local window = tonumber(ARGV[4])
+
+if redis.call("GET", KEYS[2]) == token then
+	redis.call("DEL", KEYS[2])
+end
+
+if locked then
+	if failures > 1 then
+		redis.call("SET", KEYS[1], failures - 1, "PX", window)
+	end
+elseif (tonumber(redis.call("GET", KEYS[1])) or 0) > 0 then
+	redis.call("DECR", KEYS[1])
+end
+
+return 1
+`)
+
+// Reserve counts a login attempt identified by token as failed against every
+// subject, unless any of them is blocked. The subjects are checked and counted in
+// a single step, so concurrent attempts never exceed what the policies allow.
+func (repo *loginAttemptRepository) Reserve(ctx context.Context, token string, subjects []*models.LoginSubject) (*models.LoginReservation, error) {
+	keys := make([]string, 0, len(subjects)*2)
+	args := []interface{}{token}
+
+	for _, subject := range subjects {
+		keys = append(keys, loginFailuresKey(subject.Name), loginBlockKey(subject.Name))
+		args = append(args,
+			subject.Policy.Window.Milliseconds(),
+			subject.Policy.FreeFailures,
+			subject.Policy.MaxFailures,
+			subject.Policy.BaseBackoff.Milliseconds(),
+			subject.Policy.MaxBackoff.Milliseconds(),
+			subject.Policy.LockDuration.Milliseconds(),
+		)
+	}
+
+	result, err := reserveScript.Run(ctx, repo.client, keys, args...).Int64Slice()
+	if err != nil {
+		return nil, err
+	}
+
+	reservation := &models.LoginReservation{
+		Allowed:    result[0] == 1,
+		RetryAfter: time.Duration(result[1]) * time.Millisecond,
+	}
+
+	for i := 2; i+2 < len(result); i += 3 {
+		reservation.Failures = append(reservation.Failures, &models.LoginFailure{
+			Failures:   int(result[i]),
+			Locked:     result[i+1] == 1,
+			RetryAfter: time.Duration(result[i+2]) * time.Millisecond,
+		})
+	}
+
+	return reservation, nil
+}
+
+// Refund takes back the failure that Reserve counted against subject for the
+// attempt identified by token.
+func (repo *loginAttemptRepository) Refund(ctx context.Context, token string, subject *models.LoginSubject, failure *models.LoginFailure) error {
+	return refundScript.Run(
+		ctx,
+		repo.client,
+		[]string{loginFailuresKey(subject.Name), loginBlockKey(subject.Name)},
+		token,
+		failure.Locked,
+		failure.Failures,
+		subject.Policy.Window.Milliseconds(),
+	).Err()
+}
+
+// Reset forgets the failed logins of subject and lifts any block on it.
+func (repo *loginAttemptRepository) Reset(ctx context.Context, subject string) error {
+	return repo.client.Del(ctx, loginFailuresKey(subject), loginBlockKey(subject)).Err()
+}
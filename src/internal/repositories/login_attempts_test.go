@@ -0,0 +1,211 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ryuudan/golang-rest-api/src/internal/models"
+)
+
+// Two free failures, then a backoff from one to four seconds, and a lockout of a
+// minute on the fourth failure.
+var testLockoutPolicy = &models.LockoutPolicy{
+	FreeFailures: 2,
+	MaxFailures:  4,
+	Window:       time.Hour,
+	BaseBackoff:  time.Second,
+	MaxBackoff:   4 * time.Second,
+	LockDuration: time.Minute,
+}
+
+func TestLoginAttemptReserve(t *testing.T) {
+	type step struct {
+		advance    time.Duration
+		refund     bool // Refund the attempt instead of leaving it failed
+		allowed    bool
+		retryAfter time.Duration // When not allowed
+		failures   int
+		locked     bool
+		blocked    time.Duration
+	}
+
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{
+			name: "backoff then lockout",
+			steps: []step{
+				{allowed: true, failures: 1},
+				{allowed: true, failures: 2},
+				{allowed: true, failures: 3, blocked: time.Second},
+				{allowed: false, retryAfter: time.Second},
+				{advance: time.Second, allowed: true, failures: 4, locked: true, blocked: time.Minute},
+				{advance: 30 * time.Second, allowed: false, retryAfter: 30 * time.Second},
+				{advance: 30 * time.Second, allowed: true, failures: 1},
+			},
+		},
+		{
+			name: "blocked attempts are not counted",
+			steps: []step{
+				{allowed: true, failures: 1},
+				{allowed: true, failures: 2},
+				{allowed: true, failures: 3, blocked: time.Second},
+				{allowed: false, retryAfter: time.Second},
+				{allowed: false, retryAfter: time.Second},
+				{advance: time.Second, allowed: true, failures: 4, locked: true, blocked: time.Minute},
+			},
+		},
+		{
+			name: "refunded attempts are not counted",
+			steps: []step{
+				{allowed: true, failures: 1},
+				{refund: true, allowed: true, failures: 2},
+				{allowed: true, failures: 2},
+			},
+		},
+		{
+			name: "refund lifts the backoff of the attempt",
+			steps: []step{
+				{allowed: true, failures: 1},
+				{allowed: true, failures: 2},
+				{refund: true, allowed: true, failures: 3, blocked: time.Second},
+				{allowed: true, failures: 3, blocked: time.Second},
+			},
+		},
+		{
+			name: "refund lifts the lockout of the attempt",
+			steps: []step{
+				{allowed: true, failures: 1},
+				{allowed: true, failures: 2},
+				{allowed: true, failures: 3, blocked: time.Second},
+				{advance: time.Second, refund: true, allowed: true, failures: 4, locked: true, blocked: time.Minute},
+				// The failures before it are restored, so the next one locks again
+				{allowed: true, failures: 4, locked: true, blocked: time.Minute},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client, clock := newTestRedis(t)
+			repo := NewLoginAttemptRepository(client)
+			ctx := context.Background()
+
+			subject := &models.LoginSubject{Name: "account:ada@example.com", Policy: testLockoutPolicy}
+
+			for i, step := range test.steps {
+				clock.Advance(step.advance)
+
+				token := fmt.Sprintf("attempt-%d", i)
+
+				reservation, err := repo.Reserve(ctx, token, []*models.LoginSubject{subject})
+				if err != nil {
+					t.Fatalf("step %d: Reserve: %v", i, err)
+				}
+
+				if reservation.Allowed != step.allowed {
+					t.Fatalf("step %d: allowed = %v, want %v", i, reservation.Allowed, step.allowed)
+				}
+
+				if !step.allowed {
+					if reservation.RetryAfter != step.retryAfter {
+						t.Errorf("step %d: retry after %v, want %v", i, reservation.RetryAfter, step.retryAfter)
+					}
+					continue
+				}
+
+				failure := reservation.Failures[0]
+				if failure.Failures != step.failures || failure.Locked != step.locked || failure.RetryAfter != step.blocked {
+					t.Errorf("step %d: got failures=%d locked=%v blocked=%v, want failures=%d locked=%v blocked=%v",
+						i, failure.Failures, failure.Locked, failure.RetryAfter, step.failures, step.locked, step.blocked)
+				}
+
+				if step.refund {
+					if err := repo.Refund(ctx, token, subject, failure); err != nil {
+						t.Fatalf("step %d: Refund: %v", i, err)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestLoginAttemptReserveChecksEverySubject(t *testing.T) {
+	client, _ := newTestRedis(t)
+	repo := NewLoginAttemptRepository(client)
+	ctx := context.Background()
+
+	account := &models.LoginSubject{Name: "account:ada@example.com", Policy: testLockoutPolicy}
+	other := &models.LoginSubject{Name: "account:grace@example.com", Policy: testLockoutPolicy}
+	ip := &models.LoginSubject{Name: "ip:192.0.2.1", Policy: &models.LockoutPolicy{
+		FreeFailures: 100,
+		MaxFailures:  1000,
+		Window:       time.Hour,
+		BaseBackoff:  time.Second,
+		MaxBackoff:   time.Second,
+		LockDuration: time.Hour,
+	}}
+
+	for i := 0; i < 3; i++ {
+		if _, err := repo.Reserve(ctx, fmt.Sprintf("attempt-%d", i), []*models.LoginSubject{account, ip}); err != nil {
+			t.Fatalf("Reserve: %v", err)
+		}
+	}
+
+	// The account backs off, so nothing is counted against the IP either
+	reservation, err := repo.Reserve(ctx, "blocked", []*models.LoginSubject{account, ip})
+	if err != nil {
+		t.Fatalf("Reserve: %v", err)
+	}
+	if reservation.Allowed || len(reservation.Failures) != 0 {
+		t.Fatalf("reservation = %+v, want it denied without counting", reservation)
+	}
+
+	reservation, err = repo.Reserve(ctx, "other", []*models.LoginSubject{other, ip})
+	if err != nil {
+		t.Fatalf("Reserve: %v", err)
+	}
+	if !reservation.Allowed || reservation.Failures[0].Failures != 1 || reservation.Failures[1].Failures != 4 {
+		t.Errorf("failures = %d and %d, want 1 for the other account and 4 for the IP",
+			reservation.Failures[0].Failures, reservation.Failures[1].Failures)
+	}
+}
+
+func TestLoginAttemptRefundKeepsLaterBlocks(t *testing.T) {
+	client, clock := newTestRedis(t)
+	repo := NewLoginAttemptRepository(client)
+	ctx := context.Background()
+
+	subject := &models.LoginSubject{Name: "account:ada@example.com", Policy: testLockoutPolicy}
+	subjects := []*models.LoginSubject{subject}
+
+	repo.Reserve(ctx, "first", subjects)
+	repo.Reserve(ctx, "second", subjects)
+
+	slow, err := repo.Reserve(ctx, "slow", subjects)
+	if err != nil || slow.Failures[0].RetryAfter != time.Second {
+		t.Fatalf("Reserve = %+v, %v, want a backoff", slow, err)
+	}
+
+	// Another attempt fails once the backoff of the slow one ran out
+	clock.Advance(time.Second)
+	if _, err := repo.Reserve(ctx, "later", subjects); err != nil {
+		t.Fatalf("Reserve: %v", err)
+	}
+
+	// The slow attempt turns out to have been right, which must not lift the lockout of the later one
+	if err := repo.Refund(ctx, "slow", subject, slow.Failures[0]); err != nil {
+		t.Fatalf("Refund: %v", err)
+	}
+
+	reservation, err := repo.Reserve(ctx, "blocked", subjects)
+	if err != nil {
+		t.Fatalf("Reserve: %v", err)
+	}
+	if reservation.Allowed {
+		t.Error("the lockout of the later attempt was lifted")
+	}
+}
@@ -0,0 +1,99 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"entgo.io/ent/dialect/sql"
+	"github.com/ryuudan/golang-rest-api/ent/generated"
+	"github.com/ryuudan/golang-rest-api/ent/generated/outboxmessage"
+)
+
+type OutboxRepository interface {
+	Enqueue(ctx context.Context, message *generated.OutboxMessage) error
+	ClaimDue(ctx context.Context, limit int, maxAttempts int, leaseUntil time.Time) ([]*generated.OutboxMessage, error)
+	MarkSent(ctx context.Context, id int) error
+	MarkFailed(ctx context.Context, id int, cause error, retryAt time.Time) error
+}
+
+type outboxRepository struct {
+	client *generated.OutboxMessageClient
+}
+
+func NewOutboxRepository(client *generated.OutboxMessageClient) OutboxRepository {
+	return &outboxRepository{client: client}
+}
+
+// messages returns the client of the transaction carried by ctx, if there is one.
+func (repo *outboxRepository) messages(ctx context.Context) *generated.OutboxMessageClient {
+	if tx := generated.TxFromContext(ctx); tx != nil {
+		return tx.OutboxMessage
+	}
+	return repo.client
+}
+
+// Enqueue stores a message for delivery. Called inside a transaction, the message
+// is only delivered if the transaction commits.
+func (repo *outboxRepository) Enqueue(ctx context.Context, message *generated.OutboxMessage) error {
+	return repo.messages(ctx).Create().
+		SetRecipient(message.Recipient).
+		SetSubject(message.Subject).
+		SetBody(message.Body).
+		Exec(ctx)
+}
+
+// ClaimDue leases up to limit unsent messages that are due and have not used up
+// their attempts, oldest first, by postponing them until leaseUntil. It must be
+// called inside a transaction, and only holds row locks until that commits: rows
+// locked by other instances are skipped, and once it committed the lease keeps
+// the messages from being claimed again while they are being sent. Messages whose
+// sender died are claimed again when their lease runs out.
+func (repo *outboxRepository) ClaimDue(ctx context.Context, limit int, maxAttempts int, leaseUntil time.Time) ([]*generated.OutboxMessage, error) {
+	messages, err := repo.messages(ctx).Query().
+		Where(
+			outboxmessage.SentAtIsNil(),
+			outboxmessage.AvailableAtLTE(time.Now()),
+			outboxmessage.AttemptsLT(maxAttempts),
+		).
+		Order(outboxmessage.ByID()).
+		Limit(limit).
+		ForUpdate(sql.WithLockAction(sql.SkipLocked)).
+		All(ctx)
+
+	if err != nil || len(messages) == 0 {
+		return nil, err
+	}
+
+	ids := make([]int, 0, len(messages))
+	for _, message := range messages {
+		ids = append(ids, message.ID)
+	}
+
+	err = repo.messages(ctx).Update().
+		Where(outboxmessage.IDIn(ids...)).
+		SetAvailableAt(leaseUntil).
+		Exec(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return messages, nil
+}
+
+func (repo *outboxRepository) MarkSent(ctx context.Context, id int) error {
+	return repo.messages(ctx).UpdateOneID(id).
+		SetSentAt(time.Now()).
+		AddAttempts(1).
+		ClearLastError().
+		Exec(ctx)
+}
+
+// MarkFailed records a failed delivery attempt and postpones the next one until retryAt.
+func (repo *outboxRepository) MarkFailed(ctx context.Context, id int, cause error, retryAt time.Time) error {
+	return repo.messages(ctx).UpdateOneID(id).
+		AddAttempts(1).
+		SetLastError(cause.Error()).
+		SetAvailableAt(retryAt).
+		Exec(ctx)
+}
@@ -0,0 +1,115 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/ryuudan/golang-rest-api/src/internal/models"
+)
+
+type QuotaRepository interface {
+	Consume(ctx context.Context, principal string, plan *models.Plan, now time.Time) (*models.QuotaCounts, error)
+	Counts(ctx context.Context, principal string, now time.Time) (*models.QuotaCounts, error)
+}
+
+type quotaRepository struct {
+	client *redis.Client
+}
+
+func NewQuotaRepository(client *redis.Client) QuotaRepository {
+	return &quotaRepository{client: client}
+}
+
+// consumeQuotaScript counts a request against the daily and the monthly counter
+// of a principal, unless either quota is already used up, in which case nothing
+// is counted. Counters expire when their window ends, so no cleanup is needed.
+//
+// It returns whether the request was counted and both counters after it.
+var consumeQuotaScript = redis.NewScript(`
+local daily = tonumber(redis.call("GET", KEYS[1])) or 0
+local monthly = tonumber(redis.call("GET", KEYS[2])) or 0
+
+if daily >= tonumber(ARGV[1]) or monthly >= tonumber(ARGV[2]) then
+	return {0, daily, monthly}
+end
+
+daily = redis.call("INCR", KEYS[1])
+if daily == 1 then
+	redis.call("EXPIREAT", KEYS[1], ARGV[3])
+end
+
+monthly = redis.call("INCR", KEYS[2])
+if monthly == 1 then
+	redis.call("EXPIREAT", KEYS[2], ARGV[4])
+end
+
+return {1, daily, monthly}
+`)
+
+// quotaWindows returns the keys of the daily and monthly counters of a principal
+// at the given time, and when each of them resets.
+func quotaWindows(principal string, now time.Time) (day string, month string, dayEnd time.Time, monthEnd time.Time) {
+	now = now.UTC()
+
+	day = fmt.Sprintf("quotas:%s:day:%s", principal, now.Format("2006-01-02"))
+	month = fmt.Sprintf("quotas:%s:month:%s", principal, now.Format("2006-01"))
+	dayEnd = time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, time.UTC)
+	monthEnd = time.Date(now.Year(), now.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+
+	return day, month, dayEnd, monthEnd
+}
+
+// Consume counts a request of principal against the daily and monthly quotas of plan.
+func (repo *quotaRepository) Consume(ctx context.Context, principal string, plan *models.Plan, now time.Time) (*models.QuotaCounts, error) {
+	day, month, dayEnd, monthEnd := quotaWindows(principal, now)
+
+	result, err := consumeQuotaScript.Run(
+		ctx,
+		repo.client,
+		[]string{day, month},
+		plan.DailyQuota,
+		plan.MonthlyQuota,
+		dayEnd.Unix(),
+		monthEnd.Unix(),
+	).Int64Slice()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.QuotaCounts{
+		Allowed:         result[0] == 1,
+		Daily:           int(result[1]),
+		Monthly:         int(result[2]),
+		DailyResetsAt:   dayEnd,
+		MonthlyResetsAt: monthEnd,
+	}, nil
+}
+
+// Counts returns the requests principal made in the current day and month.
+func (repo *quotaRepository) Counts(ctx context.Context, principal string, now time.Time) (*models.QuotaCounts, error) {
+	day, month, dayEnd, monthEnd := quotaWindows(principal, now)
+
+	values, err := repo.client.MGet(ctx, day, month).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	counts := &models.QuotaCounts{
+		DailyResetsAt:   dayEnd,
+		MonthlyResetsAt: monthEnd,
+	}
+
+	// Missing counters come back as nil, meaning nothing was counted yet
+	if value, ok := values[0].(string); ok {
+		counts.Daily, _ = strconv.Atoi(value)
+	}
+	if value, ok := values[1].(string); ok {
+		counts.Monthly, _ = strconv.Atoi(value)
+	}
+
+	return counts, nil
+}
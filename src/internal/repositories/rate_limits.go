@@ -0,0 +1,94 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/ryuudan/golang-rest-api/src/internal/models"
+)
+
+type RateLimitRepository interface {
+	Allow(ctx context.Context, key string, limit int, period time.Duration) (*models.RateLimit, error)
+	Peek(ctx context.Context, key string, limit int, period time.Duration) (*models.RateLimit, error)
+}
+
+type rateLimitRepository struct {
+	client *redis.Client
+}
+
+func NewRateLimitRepository(client *redis.Client) RateLimitRepository {
+	return &rateLimitRepository{client: client}
+}
+
+// gcraScript implements the generic cell rate algorithm. Requests are spaced by an
+// emission interval of period / limit, and up to limit of them may arrive at once.
+// The only state is the theoretical arrival time (TAT) of the next request, so
+// every instance shares the limit through a single key. The clock of Redis is
+// used, so that the clocks of the instances do not need to agree.
+//
+// It returns whether the request is allowed, the remaining requests, and how many
+// milliseconds until the next request is allowed and until the limit is reset.
+// When peeking, nothing is counted and the state before the request is returned.
+var gcraScript = redis.NewScript(`
+local interval = tonumber(ARGV[1])
+local tolerance = tonumber(ARGV[2])
+local peek = ARGV[3] == "1"
+
+local time = redis.call("TIME")
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+
+local tat = tonumber(redis.call("GET", KEYS[1])) or now
+if tat < now then
+	tat = now
+end
+
+local next_tat = tat + interval
+local allow_at = next_tat - tolerance
+
+if allow_at > now then
+	return {0, 0, allow_at - now, tat - now}
+end
+
+if peek then
+	return {1, math.floor((tolerance - (tat - now)) / interval), 0, tat - now}
+end
+
+redis.call("SET", KEYS[1], next_tat, "PX", next_tat - now)
+
+return {1, math.floor((tolerance - (next_tat - now)) / interval), 0, next_tat - now}
+`)
+
+func rateLimitKey(key string) string {
+	return fmt.Sprintf("rate_limits:%s", key)
+}
+
+// Allow counts a request against a limit of limit requests per period, shared by
+// every request made with the same key.
+func (repo *rateLimitRepository) Allow(ctx context.Context, key string, limit int, period time.Duration) (*models.RateLimit, error) {
+	return repo.run(ctx, key, limit, period, false)
+}
+
+// Peek returns the state of a limit without counting a request against it.
+func (repo *rateLimitRepository) Peek(ctx context.Context, key string, limit int, period time.Duration) (*models.RateLimit, error) {
+	return repo.run(ctx, key, limit, period, true)
+}
+
+func (repo *rateLimitRepository) run(ctx context.Context, key string, limit int, period time.Duration, peek bool) (*models.RateLimit, error) {
+	interval := period.Milliseconds() / int64(limit)
+
+	result, err := gcraScript.Run(ctx, repo.client, []string{rateLimitKey(key)}, interval, interval*int64(limit), peek).Int64Slice()
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.RateLimit{
+		Allowed:    result[0] == 1,
+		Limit:      limit,
+		Period:     period,
+		Remaining:  int(result[1]),
+		RetryAfter: time.Duration(result[2]) * time.Millisecond,
+		ResetAfter: time.Duration(result[3]) * time.Millisecond,
+	}, nil
+}
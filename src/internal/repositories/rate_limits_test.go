@@ -0,0 +1,122 @@
+package repositories
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRateLimitGCRA(t *testing.T) {
+	// Five requests per ten seconds, so one every two seconds with bursts of five
+	const limit = 5
+	const period = 10 * time.Second
+
+	type step struct {
+		advance    time.Duration
+		peek       bool
+		allowed    bool
+		remaining  int
+		retryAfter time.Duration
+		resetAfter time.Duration
+	}
+
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{
+			name: "burst then deny",
+			steps: []step{
+				{allowed: true, remaining: 4, resetAfter: 2 * time.Second},
+				{allowed: true, remaining: 3, resetAfter: 4 * time.Second},
+				{allowed: true, remaining: 2, resetAfter: 6 * time.Second},
+				{allowed: true, remaining: 1, resetAfter: 8 * time.Second},
+				{allowed: true, remaining: 0, resetAfter: 10 * time.Second},
+				{allowed: false, remaining: 0, retryAfter: 2 * time.Second, resetAfter: 10 * time.Second},
+			},
+		},
+		{
+			name: "one request per emission interval",
+			steps: []step{
+				{allowed: true, remaining: 4, resetAfter: 2 * time.Second},
+				{advance: 2 * time.Second, allowed: true, remaining: 4, resetAfter: 2 * time.Second},
+				{advance: 2 * time.Second, allowed: true, remaining: 4, resetAfter: 2 * time.Second},
+			},
+		},
+		{
+			name: "denied requests are not counted",
+			steps: []step{
+				{allowed: true, remaining: 4, resetAfter: 2 * time.Second},
+				{allowed: true, remaining: 3, resetAfter: 4 * time.Second},
+				{allowed: true, remaining: 2, resetAfter: 6 * time.Second},
+				{allowed: true, remaining: 1, resetAfter: 8 * time.Second},
+				{allowed: true, remaining: 0, resetAfter: 10 * time.Second},
+				{allowed: false, retryAfter: 2 * time.Second, resetAfter: 10 * time.Second},
+				{allowed: false, retryAfter: 2 * time.Second, resetAfter: 10 * time.Second},
+				{advance: 2 * time.Second, allowed: true, remaining: 0, resetAfter: 10 * time.Second},
+			},
+		},
+		{
+			name: "peek does not count",
+			steps: []step{
+				{peek: true, allowed: true, remaining: 5},
+				{peek: true, allowed: true, remaining: 5},
+				{allowed: true, remaining: 4, resetAfter: 2 * time.Second},
+				{peek: true, allowed: true, remaining: 4, resetAfter: 2 * time.Second},
+			},
+		},
+		{
+			name: "full again after the period",
+			steps: []step{
+				{allowed: true, remaining: 4, resetAfter: 2 * time.Second},
+				{allowed: true, remaining: 3, resetAfter: 4 * time.Second},
+				{advance: 500 * time.Millisecond, allowed: true, remaining: 2, resetAfter: 5500 * time.Millisecond},
+				{advance: period, allowed: true, remaining: 4, resetAfter: 2 * time.Second},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client, clock := newTestRedis(t)
+			repo := NewRateLimitRepository(client)
+
+			for i, step := range test.steps {
+				clock.Advance(step.advance)
+
+				run := repo.Allow
+				if step.peek {
+					run = repo.Peek
+				}
+
+				result, err := run(context.Background(), "test", limit, period)
+				if err != nil {
+					t.Fatalf("step %d: %v", i, err)
+				}
+
+				if result.Allowed != step.allowed || result.Remaining != step.remaining ||
+					result.RetryAfter != step.retryAfter || result.ResetAfter != step.resetAfter {
+					t.Errorf("step %d: got allowed=%v remaining=%d retry=%v reset=%v, want allowed=%v remaining=%d retry=%v reset=%v",
+						i, result.Allowed, result.Remaining, result.RetryAfter, result.ResetAfter,
+						step.allowed, step.remaining, step.retryAfter, step.resetAfter)
+				}
+			}
+		})
+	}
+}
+
+func TestRateLimitKeysAreIndependent(t *testing.T) {
+	client, _ := newTestRedis(t)
+	repo := NewRateLimitRepository(client)
+	ctx := context.Background()
+
+	if result, _ := repo.Allow(ctx, "a", 1, time.Minute); !result.Allowed {
+		t.Fatal("first request of a should be allowed")
+	}
+	if result, _ := repo.Allow(ctx, "a", 1, time.Minute); result.Allowed {
+		t.Error("second request of a should be denied")
+	}
+	if result, _ := repo.Allow(ctx, "b", 1, time.Minute); !result.Allowed {
+		t.Error("b should not share the limit of a")
+	}
+}
@@ -0,0 +1,72 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/ryuudan/golang-rest-api/ent/generated"
+	"github.com/ryuudan/golang-rest-api/ent/generated/recoverycode"
+)
+
+type RecoveryCodeRepository interface {
+	Replace(ctx context.Context, userID int, hashes []string) error
+	Use(ctx context.Context, userID int, hash string) (bool, error)
+	DeleteAll(ctx context.Context, userID int) error
+}
+
+type recoveryCodeRepository struct {
+	client *generated.RecoveryCodeClient
+}
+
+func NewRecoveryCodeRepository(client *generated.RecoveryCodeClient) RecoveryCodeRepository {
+	return &recoveryCodeRepository{client: client}
+}
+
+// codes returns the client of the transaction carried by ctx, if there is one.
+func (repo *recoveryCodeRepository) codes(ctx context.Context) *generated.RecoveryCodeClient {
+	if tx := generated.TxFromContext(ctx); tx != nil {
+		return tx.RecoveryCode
+	}
+	return repo.client
+}
+
+// Replace swaps every recovery code of the user for the given hashes.
+func (repo *recoveryCodeRepository) Replace(ctx context.Context, userID int, hashes []string) error {
+	if err := repo.DeleteAll(ctx, userID); err != nil {
+		return err
+	}
+
+	builders := make([]*generated.RecoveryCodeCreate, 0, len(hashes))
+	for _, hash := range hashes {
+		builders = append(builders, repo.codes(ctx).Create().SetUserID(userID).SetCodeHash(hash))
+	}
+
+	return repo.codes(ctx).CreateBulk(builders...).Exec(ctx)
+}
+
+// Use spends the user's unused recovery code with the given hash, and reports
+// whether there was one. A code can only be spent once, even concurrently.
+func (repo *recoveryCodeRepository) Use(ctx context.Context, userID int, hash string) (bool, error) {
+	updated, err := repo.codes(ctx).Update().
+		Where(
+			recoverycode.UserIDEQ(userID),
+			recoverycode.CodeHashEQ(hash),
+			recoverycode.UsedAtIsNil(),
+		).
+		SetUsedAt(time.Now()).
+		Save(ctx)
+
+	if err != nil {
+		return false, err
+	}
+
+	return updated == 1, nil
+}
+
+func (repo *recoveryCodeRepository) DeleteAll(ctx context.Context, userID int) error {
+	_, err := repo.codes(ctx).Delete().
+		Where(recoverycode.UserIDEQ(userID)).
+		Exec(ctx)
+
+	return err
+}
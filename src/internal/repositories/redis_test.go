@@ -0,0 +1,36 @@
+package repositories
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// testClock controls the time of a miniredis server, both the clock scripts read
+// with TIME and the expiry of keys.
+type testClock struct {
+	server *miniredis.Miniredis
+	now    time.Time
+}
+
+func (clock *testClock) Advance(duration time.Duration) {
+	clock.now = clock.now.Add(duration)
+	clock.server.SetTime(clock.now)
+	clock.server.FastForward(duration)
+}
+
+// newTestRedis returns a client of a fresh miniredis server and its clock.
+func newTestRedis(t *testing.T) (*redis.Client, *testClock) {
+	t.Helper()
+
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	clock := &testClock{server: server, now: time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)}
+	server.SetTime(clock.now)
+
+	return client, clock
+}
@@ -0,0 +1,32 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/ryuudan/golang-rest-api/ent/generated"
+	"github.com/ryuudan/golang-rest-api/ent/generated/role"
+)
+
+type RoleRepository interface {
+	GetByName(ctx context.Context, name string) (*generated.Role, error)
+}
+
+type roleRepository struct {
+	client *generated.RoleClient
+}
+
+func NewRoleRepository(client *generated.RoleClient) RoleRepository {
+	return &roleRepository{client: client}
+}
+
+func (repo *roleRepository) GetByName(ctx context.Context, name string) (*generated.Role, error) {
+	role, err := repo.client.Query().Where(
+		role.NameEQ(name),
+	).Only(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return role, nil
+}
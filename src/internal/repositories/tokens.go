@@ -0,0 +1,192 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/ryuudan/golang-rest-api/src/internal/models"
+)
+
+type TokenRepository interface {
+	SaveRefreshToken(ctx context.Context, hash string, token *models.RefreshToken, expiration time.Duration) error
+	GetRefreshToken(ctx context.Context, hash string) (*models.RefreshToken, error)
+	UseRefreshToken(ctx context.Context, hash string) (bool, error)
+	IsFamilyActive(ctx context.Context, familyID string) (bool, error)
+	RevokeFamily(ctx context.Context, familyID string) error
+	SavePasswordResetToken(ctx context.Context, hash string, token *models.PasswordResetToken, expiration time.Duration) error
+	GetPasswordResetToken(ctx context.Context, hash string) (*models.PasswordResetToken, error)
+	UsePasswordResetToken(ctx context.Context, hash string) (*models.PasswordResetToken, error)
+	MarkUsed(ctx context.Context, key string, expiration time.Duration) (bool, error)
+}
+
+type tokenRepository struct {
+	client *redis.Client
+}
+
+func NewTokenRepository(client *redis.Client) TokenRepository {
+	return &tokenRepository{client: client}
+}
+
+// useRefreshTokenScript increments the use counter of a refresh token only if the
+// token still exists, so that an expired token is never recreated without a TTL.
+var useRefreshTokenScript = redis.NewScript(`
+if redis.call("EXISTS", KEYS[1]) == 0 then
+	return -1
+end
+return redis.call("HINCRBY", KEYS[1], "uses", 1)
+`)
+
+func refreshTokenKey(hash string) string {
+	return fmt.Sprintf("refresh_tokens:%s", hash)
+}
+
+func passwordResetTokenKey(hash string) string {
+	return fmt.Sprintf("password_reset_tokens:%s", hash)
+}
+
+func usedTokenKey(key string) string {
+	return fmt.Sprintf("used_tokens:%s", key)
+}
+
+func refreshFamilyKey(familyID string) string {
+	return fmt.Sprintf("refresh_families:%s", familyID)
+}
+
+// SaveRefreshToken stores a refresh token under its hash and (re)activates the
+// token family for the same duration.
+func (repo *tokenRepository) SaveRefreshToken(ctx context.Context, hash string, token *models.RefreshToken, expiration time.Duration) error {
+	key := refreshTokenKey(hash)
+
+	_, err := repo.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, key, token)
+		pipe.Expire(ctx, key, expiration)
+		pipe.Set(ctx, refreshFamilyKey(token.FamilyID), token.UserID, expiration)
+		return nil
+	})
+
+	return err
+}
+
+// GetRefreshToken returns the refresh token stored under the hash, or redis.Nil
+// when it does not exist or has expired.
+func (repo *tokenRepository) GetRefreshToken(ctx context.Context, hash string) (*models.RefreshToken, error) {
+	result := repo.client.HGetAll(ctx, refreshTokenKey(hash))
+
+	if err := result.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(result.Val()) == 0 {
+		return nil, redis.Nil
+	}
+
+	var token models.RefreshToken
+	if err := result.Scan(&token); err != nil {
+		return nil, err
+	}
+
+	return &token, nil
+}
+
+// UseRefreshToken atomically records a use of the refresh token and reports
+// whether this was the first one. Used tokens are kept until they expire so
+// that a replay can still be detected.
+func (repo *tokenRepository) UseRefreshToken(ctx context.Context, hash string) (bool, error) {
+	uses, err := useRefreshTokenScript.Run(ctx, repo.client, []string{refreshTokenKey(hash)}).Int64()
+	if err != nil {
+		return false, err
+	}
+
+	if uses < 0 {
+		return false, redis.Nil
+	}
+
+	return uses == 1, nil
+}
+
+func (repo *tokenRepository) IsFamilyActive(ctx context.Context, familyID string) (bool, error) {
+	count, err := repo.client.Exists(ctx, refreshFamilyKey(familyID)).Result()
+	if err != nil {
+		return false, err
+	}
+
+	return count == 1, nil
+}
+
+// RevokeFamily invalidates every refresh token rotated from the same login.
+func (repo *tokenRepository) RevokeFamily(ctx context.Context, familyID string) error {
+	return repo.client.Del(ctx, refreshFamilyKey(familyID)).Err()
+}
+
+// SavePasswordResetToken stores a password reset token under its hash.
+func (repo *tokenRepository) SavePasswordResetToken(ctx context.Context, hash string, token *models.PasswordResetToken, expiration time.Duration) error {
+	key := passwordResetTokenKey(hash)
+
+	_, err := repo.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, key, token)
+		pipe.Expire(ctx, key, expiration)
+		return nil
+	})
+
+	return err
+}
+
+// GetPasswordResetToken returns the password reset token stored under the hash
+// without using it up, or redis.Nil when it does not exist or has expired.
+func (repo *tokenRepository) GetPasswordResetToken(ctx context.Context, hash string) (*models.PasswordResetToken, error) {
+	result := repo.client.HGetAll(ctx, passwordResetTokenKey(hash))
+
+	if err := result.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(result.Val()) == 0 {
+		return nil, redis.Nil
+	}
+
+	var token models.PasswordResetToken
+	if err := result.Scan(&token); err != nil {
+		return nil, err
+	}
+
+	return &token, nil
+}
+
+// UsePasswordResetToken deletes the password reset token stored under the hash and
+// returns it, so that it can only ever be used once. It returns redis.Nil when the
+// token does not exist, has expired or was already used.
+func (repo *tokenRepository) UsePasswordResetToken(ctx context.Context, hash string) (*models.PasswordResetToken, error) {
+	key := passwordResetTokenKey(hash)
+
+	var result *redis.MapStringStringCmd
+
+	_, err := repo.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		result = pipe.HGetAll(ctx, key)
+		pipe.Del(ctx, key)
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(result.Val()) == 0 {
+		return nil, redis.Nil
+	}
+
+	var token models.PasswordResetToken
+	if err := result.Scan(&token); err != nil {
+		return nil, err
+	}
+
+	return &token, nil
+}
+
+// MarkUsed records a use of a token that must only be used once, like a TOTP
+// code, and reports whether this was the first one. The record only needs to
+// outlive the token, so it expires after expiration.
+func (repo *tokenRepository) MarkUsed(ctx context.Context, key string, expiration time.Duration) (bool, error) {
+	return repo.client.SetNX(ctx, usedTokenKey(key), 1, expiration).Result()
+}
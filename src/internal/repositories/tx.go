@@ -0,0 +1,51 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ryuudan/golang-rest-api/ent/generated"
+)
+
+// Transactor runs functions in a database transaction. Repositories called with
+// the context passed to the function take part in the transaction.
+type Transactor interface {
+	WithTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type transactor struct {
+	client *generated.Client
+}
+
+func NewTransactor(client *generated.Client) Transactor {
+	return &transactor{client: client}
+}
+
+// WithTx commits the transaction when fn succeeds and rolls it back when it fails
+// or panics. Inside a transaction already, fn simply joins it.
+func (t *transactor) WithTx(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	if generated.TxFromContext(ctx) != nil {
+		return fn(ctx)
+	}
+
+	tx, err := t.client.Tx(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if v := recover(); v != nil {
+			_ = tx.Rollback()
+			panic(v)
+		}
+	}()
+
+	if err := fn(generated.NewTxContext(ctx, tx)); err != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			return fmt.Errorf("%w: rolling back: %v", err, rollbackErr)
+		}
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -2,15 +2,62 @@ package repositories
 
 import (
 	"context"
+	"fmt"
+	"time"
 
+	"entgo.io/ent/dialect/sql"
 	"github.com/ryuudan/golang-rest-api/ent/generated"
+	"github.com/ryuudan/golang-rest-api/ent/generated/permission"
+	"github.com/ryuudan/golang-rest-api/ent/generated/predicate"
+	"github.com/ryuudan/golang-rest-api/ent/generated/role"
 	"github.com/ryuudan/golang-rest-api/ent/generated/user"
+	"github.com/ryuudan/golang-rest-api/ent/schema"
+	"github.com/ryuudan/golang-rest-api/src/utils/render"
 )
 
+// UserOrderFields are the columns of the users table that listings can be ordered by.
+var UserOrderFields = []string{
+	user.FieldID,
+	user.FieldFirstName,
+	user.FieldLastName,
+	user.FieldMiddleName,
+	user.FieldEmail,
+	user.FieldBirthday,
+	user.FieldCreatedAt,
+	user.FieldUpdatedAt,
+}
+
+// UserCursorOrderFields are the columns cursor listings can be ordered by. Keyset
+// predicates cannot compare NULLs, so nullable columns are left out.
+var UserCursorOrderFields = []string{
+	user.FieldID,
+	user.FieldFirstName,
+	user.FieldLastName,
+	user.FieldEmail,
+	user.FieldCreatedAt,
+	user.FieldUpdatedAt,
+}
+
 type UserRepository interface {
 	Create(ctx context.Context, newUser *generated.User) (*generated.User, error)
 	GetByID(ctx context.Context, id int) (*generated.User, error)
+	Lock(ctx context.Context, id int) error
+	Update(ctx context.Context, id int, updatedUser *generated.User) (*generated.User, error)
+	UpdatePassword(ctx context.Context, id int, password string) error
+	MarkEmailVerified(ctx context.Context, id int) (*generated.User, error)
+	ClearEmailVerified(ctx context.Context, id int) (*generated.User, error)
+	SetTOTPSecret(ctx context.Context, id int, secret string) error
+	EnableTOTP(ctx context.Context, id int) error
+	DisableTOTP(ctx context.Context, id int) error
+	HasRole(ctx context.Context, id int, role string) (bool, error)
+	Delete(ctx context.Context, id int) error
+	Restore(ctx context.Context, id int) (*generated.User, error)
+	Purge(ctx context.Context, id int) error
 	GetByEmail(ctx context.Context, email string) (*generated.User, error)
+	GetEmailHolder(ctx context.Context, email string) (*generated.User, error)
+	GetPermissions(ctx context.Context, id int) ([]string, error)
+	List(ctx context.Context, params *render.QueryParams, orders []*render.OrderFields) ([]*generated.User, int, error)
+	ListByCursor(ctx context.Context, params *render.QueryParams, orders []*render.OrderFields) ([]*generated.User, *render.Cursor, error)
 }
 
 type userRepository struct {
@@ -21,9 +68,17 @@ func NewUserRepository(client *generated.UserClient) UserRepository {
 	return &userRepository{client: client}
 }
 
+// users returns the client of the transaction carried by ctx, if there is one.
+func (repo *userRepository) users(ctx context.Context) *generated.UserClient {
+	if tx := generated.TxFromContext(ctx); tx != nil {
+		return tx.User
+	}
+	return repo.client
+}
+
 func (repo *userRepository) Create(ctx context.Context, newUser *generated.User) (*generated.User, error) {
 
-	user, err := repo.client.Create().
+	user, err := repo.users(ctx).Create().
 		SetEmail(newUser.Email).
 		SetFirstName(newUser.FirstName).
 		SetLastName(newUser.LastName).
@@ -31,6 +86,59 @@ func (repo *userRepository) Create(ctx context.Context, newUser *generated.User)
 		SetNillableMiddleName(newUser.MiddleName).
 		SetNillableBirthday(newUser.Birthday).
 		SetNillablePhoneNumber(newUser.PhoneNumber).
+		AddRoles(newUser.Edges.Roles...).
+		Save(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// Update replaces the profile fields of a user. Nullable fields that are nil in
+// updatedUser are cleared; the password is left untouched.
+func (repo *userRepository) Update(ctx context.Context, id int, updatedUser *generated.User) (*generated.User, error) {
+	update := repo.users(ctx).UpdateOneID(id).
+		SetFirstName(updatedUser.FirstName).
+		SetLastName(updatedUser.LastName).
+		SetEmail(updatedUser.Email)
+
+	if updatedUser.MiddleName != nil {
+		update.SetMiddleName(*updatedUser.MiddleName)
+	} else {
+		update.ClearMiddleName()
+	}
+
+	if updatedUser.Birthday != nil {
+		update.SetBirthday(*updatedUser.Birthday)
+	} else {
+		update.ClearBirthday()
+	}
+
+	if updatedUser.PhoneNumber != nil {
+		update.SetPhoneNumber(*updatedUser.PhoneNumber)
+	} else {
+		update.ClearPhoneNumber()
+	}
+
+	user, err := update.Save(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// UpdatePassword replaces the password hash of a user.
+func (repo *userRepository) UpdatePassword(ctx context.Context, id int, password string) error {
+	return repo.users(ctx).UpdateOneID(id).SetPassword(password).Exec(ctx)
+}
+
+// MarkEmailVerified records that the user proved to own their email just now.
+func (repo *userRepository) MarkEmailVerified(ctx context.Context, id int) (*generated.User, error) {
+	user, err := repo.users(ctx).UpdateOneID(id).
+		SetEmailVerifiedAt(time.Now()).
 		Save(ctx)
 
 	if err != nil {
@@ -40,16 +148,89 @@ func (repo *userRepository) Create(ctx context.Context, newUser *generated.User)
 	return user, nil
 }
 
+// ClearEmailVerified forgets that the user verified their email, e.g. after it changed.
+func (repo *userRepository) ClearEmailVerified(ctx context.Context, id int) (*generated.User, error) {
+	user, err := repo.users(ctx).UpdateOneID(id).
+		ClearEmailVerifiedAt().
+		Save(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// SetTOTPSecret stores the encrypted TOTP secret of a user whose two-factor
+// authentication is not confirmed yet. Until EnableTOTP it is not required.
+func (repo *userRepository) SetTOTPSecret(ctx context.Context, id int, secret string) error {
+	return repo.users(ctx).UpdateOneID(id).
+		SetTotpSecret(secret).
+		ClearTotpEnabledAt().
+		Exec(ctx)
+}
+
+// EnableTOTP requires the stored TOTP secret from now on.
+func (repo *userRepository) EnableTOTP(ctx context.Context, id int) error {
+	return repo.users(ctx).UpdateOneID(id).
+		SetTotpEnabledAt(time.Now()).
+		Exec(ctx)
+}
+
+// DisableTOTP turns two-factor authentication off and forgets the secret.
+func (repo *userRepository) DisableTOTP(ctx context.Context, id int) error {
+	return repo.users(ctx).UpdateOneID(id).
+		ClearTotpSecret().
+		ClearTotpEnabledAt().
+		Exec(ctx)
+}
+
+// Delete soft deletes a user. The user disappears from every query but can be
+// brought back with Restore.
+func (repo *userRepository) Delete(ctx context.Context, id int) error {
+	return repo.users(ctx).DeleteOneID(id).Exec(ctx)
+}
+
+// Restore undoes the soft delete of a user.
+func (repo *userRepository) Restore(ctx context.Context, id int) (*generated.User, error) {
+	user, err := repo.users(ctx).UpdateOneID(id).
+		Where(user.DeletedAtNotNil()).
+		ClearDeletedAt().
+		Save(schema.SkipSoftDelete(ctx))
+
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// Purge permanently deletes a user, whether or not it was soft deleted before.
+func (repo *userRepository) Purge(ctx context.Context, id int) error {
+	return repo.users(ctx).DeleteOneID(id).Exec(schema.SkipSoftDelete(ctx))
+}
+
 func (repo *userRepository) GetByID(ctx context.Context, id int) (*generated.User, error) {
-	user, err := repo.client.Get(ctx, id)
+	user, err := repo.users(ctx).Get(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 	return user, nil
 }
 
+// Lock locks the row of the user until the transaction carried by ctx ends, so
+// that changes that depend on what the user already has are made one at a time.
+func (repo *userRepository) Lock(ctx context.Context, id int) error {
+	_, err := repo.users(ctx).Query().
+		Where(user.IDEQ(id)).
+		ForUpdate().
+		OnlyID(ctx)
+
+	return err
+}
+
 func (repo *userRepository) GetByEmail(ctx context.Context, email string) (*generated.User, error) {
-	user, err := repo.client.Query().Where(
+	user, err := repo.users(ctx).Query().Where(
 		user.EmailEQ(email),
 	).First(ctx)
 
@@ -59,3 +240,221 @@ func (repo *userRepository) GetByEmail(ctx context.Context, email string) (*gene
 
 	return user, nil
 }
+
+// GetEmailHolder returns the user holding an email, including soft deleted users,
+// which keep their email until they are purged.
+func (repo *userRepository) GetEmailHolder(ctx context.Context, email string) (*generated.User, error) {
+	return repo.GetByEmail(schema.SkipSoftDelete(ctx), email)
+}
+
+// GetPermissions returns the names of all permissions granted to the user
+// through any of their roles.
+func (repo *userRepository) GetPermissions(ctx context.Context, id int) ([]string, error) {
+	return repo.users(ctx).Query().
+		Where(user.IDEQ(id)).
+		QueryRoles().
+		QueryPermissions().
+		Unique(true).
+		Select(permission.FieldName).
+		Strings(ctx)
+}
+
+// HasRole reports whether the user was granted the role.
+func (repo *userRepository) HasRole(ctx context.Context, id int, name string) (bool, error) {
+	return repo.users(ctx).Query().
+		Where(user.IDEQ(id)).
+		QueryRoles().
+		Where(role.NameEQ(name)).
+		Exist(ctx)
+}
+
+// List returns one page of users matching the search query together with the
+// total number of matching users. The order fields must already be validated
+// against UserOrderFields.
+func (repo *userRepository) List(ctx context.Context, params *render.QueryParams, orders []*render.OrderFields) ([]*generated.User, int, error) {
+	query := repo.search(params.Query)
+
+	total, err := query.Clone().Count(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	users, err := query.
+		Order(orderOptions(orders)...).
+		Offset((params.Page - 1) * params.Limit).
+		Limit(params.Limit).
+		All(ctx)
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return users, total, nil
+}
+
+// ListByCursor returns the page of users that follows the cursor in params, and
+// the cursor of the page after it, or nil on the last page. The order fields must
+// already be validated against UserCursorOrderFields.
+func (repo *userRepository) ListByCursor(ctx context.Context, params *render.QueryParams, orders []*render.OrderFields) ([]*generated.User, *render.Cursor, error) {
+	orders = withTieBreaker(orders)
+	query := repo.search(params.Query)
+
+	if params.Cursor != "" {
+		cursor, err := render.DecodeCursor(params.Cursor)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if cursor.Order != params.Order || cursor.Query != render.QueryDigest(params.Query) {
+			return nil, nil, render.ErrInvalidCursor
+		}
+
+		values, err := cursor.ParseValues(cursorValueTypes(orders))
+		if err != nil {
+			return nil, nil, err
+		}
+
+		query = query.Where(keysetPredicate(orders, values))
+	}
+
+	// One extra row tells whether there is a next page
+	users, err := query.
+		Order(orderOptions(orders)...).
+		Limit(params.Limit + 1).
+		All(ctx)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(users) <= params.Limit {
+		return users, nil, nil
+	}
+
+	users = users[:params.Limit]
+	last := users[len(users)-1]
+	next := &render.Cursor{Order: params.Order, Query: render.QueryDigest(params.Query)}
+
+	for _, order := range orders {
+		value, err := cursorValue(last, order.Field)
+		if err != nil {
+			return nil, nil, err
+		}
+		next.Values = append(next.Values, value)
+	}
+
+	return users, next, nil
+}
+
+// cursorValue returns the value of one of the UserCursorOrderFields of a user, as
+// it goes into a cursor.
+func cursorValue(found *generated.User, field string) (interface{}, error) {
+	switch field {
+	case user.FieldID:
+		return found.ID, nil
+	case user.FieldFirstName:
+		return found.FirstName, nil
+	case user.FieldLastName:
+		return found.LastName, nil
+	case user.FieldEmail:
+		return found.Email, nil
+	case user.FieldCreatedAt:
+		return found.CreatedAt, nil
+	case user.FieldUpdatedAt:
+		return found.UpdatedAt, nil
+	default:
+		return nil, fmt.Errorf("%s cannot be used in a cursor", field)
+	}
+}
+
+// cursorValueTypes returns the type each cursor value must have for the given
+// order fields.
+func cursorValueTypes(orders []*render.OrderFields) []render.CursorValueType {
+	types := make([]render.CursorValueType, len(orders))
+
+	for i, order := range orders {
+		switch order.Field {
+		case user.FieldID:
+			types[i] = render.CursorInteger
+		case user.FieldCreatedAt, user.FieldUpdatedAt:
+			types[i] = render.CursorTime
+		default:
+			types[i] = render.CursorString
+		}
+	}
+
+	return types
+}
+
+// search returns a user query filtered by a case insensitive search on the
+// name and email columns.
+func (repo *userRepository) search(term string) *generated.UserQuery {
+	query := repo.client.Query()
+
+	if term != "" {
+		query = query.Where(user.Or(
+			user.FirstNameContainsFold(term),
+			user.LastNameContainsFold(term),
+			user.MiddleNameContainsFold(term),
+			user.EmailContainsFold(term),
+		))
+	}
+
+	return query
+}
+
+// withTieBreaker appends the ID to the order fields unless it is already one of
+// them, so that rows with equal sort keys always come back in the same order.
+func withTieBreaker(orders []*render.OrderFields) []*render.OrderFields {
+	for _, order := range orders {
+		if order.Field == user.FieldID {
+			return orders
+		}
+	}
+
+	return append(orders[:len(orders):len(orders)], &render.OrderFields{Field: user.FieldID, Direction: "asc"})
+}
+
+// orderOptions converts parsed order fields into ent order options.
+func orderOptions(orders []*render.OrderFields) []user.OrderOption {
+	options := []user.OrderOption{}
+
+	for _, order := range withTieBreaker(orders) {
+		direction := sql.OrderAsc()
+		if order.Direction == "desc" {
+			direction = sql.OrderDesc()
+		}
+
+		options = append(options, sql.OrderByField(order.Field, direction).ToFunc())
+	}
+
+	return options
+}
+
+// keysetPredicate selects the rows that sort strictly after the given sort-key
+// tuple, i.e. for orders (a asc, b desc) and values (x, y):
+//
+//	a > x OR (a = x AND b < y)
+func keysetPredicate(orders []*render.OrderFields, values []interface{}) predicate.User {
+	return func(s *sql.Selector) {
+		var or []*sql.Predicate
+
+		for i, order := range orders {
+			var and []*sql.Predicate
+
+			for j := 0; j < i; j++ {
+				and = append(and, sql.EQ(s.C(orders[j].Field), values[j]))
+			}
+
+			if order.Direction == "desc" {
+				and = append(and, sql.LT(s.C(order.Field), values[i]))
+			} else {
+				and = append(and, sql.GT(s.C(order.Field), values[i]))
+			}
+
+			or = append(or, sql.And(and...))
+		}
+
+		s.Where(sql.Or(or...))
+	}
+}
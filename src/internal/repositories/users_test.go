@@ -0,0 +1,152 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ryuudan/golang-rest-api/ent/generated"
+	"github.com/ryuudan/golang-rest-api/ent/generated/enttest"
+	"github.com/ryuudan/golang-rest-api/src/utils/render"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// newTestClient returns a client of a fresh in-memory database with the schema
+// applied.
+func newTestClient(t *testing.T) *generated.Client {
+	t.Helper()
+
+	client := enttest.Open(t, "sqlite3", fmt.Sprintf("file:%s?mode=memory&cache=shared&_fk=1", strings.ReplaceAll(t.Name(), "/", "_")))
+	t.Cleanup(func() { client.Close() })
+
+	return client
+}
+
+func TestListByCursorWalksEveryPage(t *testing.T) {
+	tests := []struct {
+		name  string
+		order string
+		less  func(a, b *generated.User) bool // Before the ID tie-breaker
+	}{
+		{"id", "id:asc", func(a, b *generated.User) bool { return false }},
+		{"first name descending", "first_name:desc", func(a, b *generated.User) bool { return a.FirstName > b.FirstName }},
+		{"last and first name", "last_name:asc,first_name:asc", func(a, b *generated.User) bool {
+			if a.LastName != b.LastName {
+				return a.LastName < b.LastName
+			}
+			return a.FirstName < b.FirstName
+		}},
+		{"email", "email:desc", func(a, b *generated.User) bool { return a.Email > b.Email }},
+		{"created at", "created_at:desc", func(a, b *generated.User) bool { return a.CreatedAt.After(b.CreatedAt) }},
+	}
+
+	client := newTestClient(t)
+	repo := NewUserRepository(client.User)
+	ctx := context.Background()
+
+	names := [][2]string{{"Ada", "Lovelace"}, {"Grace", "Hopper"}, {"Alan", "Turing"}, {"Ada", "Byron"}, {"Grace", "Lovelace"}, {"Edsger", "Dijkstra"}, {"Alan", "Kay"}}
+
+	var all []*generated.User
+	for i, name := range names {
+		created, err := repo.Create(ctx, &generated.User{
+			FirstName: name[0],
+			LastName:  name[1],
+			Email:     fmt.Sprintf("%s.%s.%d@example.com", strings.ToLower(name[0]), strings.ToLower(name[1]), i),
+			Password:  "hash",
+		})
+		if err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+		all = append(all, created)
+
+		// Distinct creation times, apart from the last two
+		if i < len(names)-2 {
+			time.Sleep(2 * time.Millisecond)
+		}
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			orders, err := render.ParseOrderString(test.order)
+			if err != nil {
+				t.Fatalf("ParseOrderString: %v", err)
+			}
+
+			want := append([]*generated.User(nil), all...)
+			sort.SliceStable(want, func(i, j int) bool {
+				if test.less(want[i], want[j]) {
+					return true
+				}
+				if test.less(want[j], want[i]) {
+					return false
+				}
+				return want[i].ID < want[j].ID
+			})
+
+			var got []*generated.User
+			params := &render.QueryParams{Limit: 2, Order: test.order}
+
+			for page := 0; page < len(all); page++ {
+				users, next, err := repo.ListByCursor(ctx, params, orders)
+				if err != nil {
+					t.Fatalf("page %d: ListByCursor: %v", page, err)
+				}
+				got = append(got, users...)
+
+				if next == nil {
+					break
+				}
+
+				if params.Cursor, err = render.EncodeCursor(next); err != nil {
+					t.Fatalf("page %d: EncodeCursor: %v", page, err)
+				}
+			}
+
+			if len(got) != len(want) {
+				t.Fatalf("walked %d users, want %d", len(got), len(want))
+			}
+			for i := range want {
+				if got[i].ID != want[i].ID {
+					t.Errorf("user %d = %d, want %d", i, got[i].ID, want[i].ID)
+				}
+			}
+		})
+	}
+}
+
+func TestCursorValue(t *testing.T) {
+	created := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	found := &generated.User{ID: 7, FirstName: "Ada", LastName: "Lovelace", Email: "ada@example.com", CreatedAt: created, UpdatedAt: created.Add(time.Hour)}
+
+	tests := []struct {
+		field   string
+		want    interface{}
+		wantErr bool
+	}{
+		{"id", 7, false},
+		{"first_name", "Ada", false},
+		{"last_name", "Lovelace", false},
+		{"email", "ada@example.com", false},
+		{"created_at", created, false},
+		{"updated_at", created.Add(time.Hour), false},
+		{"middle_name", nil, true},
+		{"password", nil, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.field, func(t *testing.T) {
+			value, err := cursorValue(found, test.field)
+
+			if (err != nil) != test.wantErr {
+				t.Fatalf("err = %v, want error %v", err, test.wantErr)
+			}
+			if value != test.want {
+				t.Errorf("cursorValue = %v, want %v", value, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,174 @@
+package services
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/ryuudan/golang-rest-api/ent/generated"
+	"github.com/ryuudan/golang-rest-api/src/constants"
+	"github.com/ryuudan/golang-rest-api/src/internal/models"
+	"github.com/ryuudan/golang-rest-api/src/internal/repositories"
+	"github.com/ryuudan/golang-rest-api/src/utils/tokens"
+)
+
+var ErrAPIKeyNotFound = errors.New("api key not found")
+var ErrScopeNotGranted = errors.New("scope not granted to the user")
+var ErrTooManyAPIKeys = errors.New("too many active api keys")
+
+type APIKeyService interface {
+	Create(ctx context.Context, userID int, request *models.CreateAPIKeyRequest) (*models.CreatedAPIKeyResponse, error)
+	List(ctx context.Context, userID int) ([]*generated.APIKey, error)
+	Revoke(ctx context.Context, userID int, id int) error
+	Authenticate(ctx context.Context, key string) (*models.APIKeyPrincipal, error)
+}
+
+type apiKeyService struct {
+	keys  repositories.APIKeyRepository
+	users repositories.UserRepository
+	tx    repositories.Transactor
+}
+
+func NewAPIKeyService(keys repositories.APIKeyRepository, users repositories.UserRepository, tx repositories.Transactor) APIKeyService {
+	return &apiKeyService{
+		keys:  keys,
+		users: users,
+		tx:    tx,
+	}
+}
+
+// Create issues a new API key for the user. The full key is only part of the
+// returned response, it cannot be retrieved again. A user holds at most
+// constants.API_KEY_MAX_PER_USER active keys, ErrTooManyAPIKeys is returned
+// beyond that.
+func (service *apiKeyService) Create(ctx context.Context, userID int, request *models.CreateAPIKeyRequest) (*models.CreatedAPIKeyResponse, error) {
+	permissions, err := service.users.GetPermissions(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	granted := make(map[string]bool, len(permissions))
+	for _, permission := range permissions {
+		granted[permission] = true
+	}
+
+	scopes := make([]string, 0, len(request.Scopes))
+	seen := make(map[string]bool, len(request.Scopes))
+
+	for _, scope := range request.Scopes {
+		if !granted[scope] {
+			return nil, ErrScopeNotGranted
+		}
+
+		if !seen[scope] {
+			seen[scope] = true
+			scopes = append(scopes, scope)
+		}
+	}
+
+	prefix, err := tokens.RandomString(constants.API_KEY_PREFIX_BYTES)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := tokens.RandomString(constants.API_KEY_SECRET_BYTES)
+	if err != nil {
+		return nil, err
+	}
+
+	var created *generated.APIKey
+
+	err = service.tx.WithTx(ctx, func(ctx context.Context) error {
+		// Concurrent requests of the user wait here, so that they cannot all pass the count
+		if err := service.users.Lock(ctx, userID); err != nil {
+			return err
+		}
+
+		active, err := service.keys.CountActive(ctx, userID, time.Now())
+		if err != nil {
+			return err
+		}
+
+		if active >= constants.API_KEY_MAX_PER_USER {
+			return ErrTooManyAPIKeys
+		}
+
+		created, err = service.keys.Create(ctx, &generated.APIKey{
+			Name:       request.Name,
+			Prefix:     prefix,
+			SecretHash: tokens.Hash(secret),
+			Scopes:     scopes,
+			ExpiresAt:  request.ExpiresAt,
+			UserID:     userID,
+		})
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.CreatedAPIKeyResponse{
+		APIKeyResponse: models.NewAPIKeyResponse(created),
+		Key:            constants.API_KEY_PREFIX + prefix + "_" + secret,
+	}, nil
+}
+
+func (service *apiKeyService) List(ctx context.Context, userID int) ([]*generated.APIKey, error) {
+	return service.keys.ListByUser(ctx, userID)
+}
+
+// Revoke stops the user's key from working. Revoked keys stay listed.
+func (service *apiKeyService) Revoke(ctx context.Context, userID int, id int) error {
+	revoked, err := service.keys.Revoke(ctx, id, userID)
+	if err != nil {
+		return err
+	}
+
+	if !revoked {
+		return ErrAPIKeyNotFound
+	}
+
+	return nil
+}
+
+// Authenticate returns who the given API key acts as, or nil when it is not a
+// key that can be used: unknown, malformed, revoked or expired.
+func (service *apiKeyService) Authenticate(ctx context.Context, key string) (*models.APIKeyPrincipal, error) {
+	prefix, secret, ok := strings.Cut(strings.TrimPrefix(key, constants.API_KEY_PREFIX), "_")
+	if !ok || !strings.HasPrefix(key, constants.API_KEY_PREFIX) {
+		return nil, nil
+	}
+
+	found, err := service.keys.GetByPrefix(ctx, prefix)
+	if err != nil {
+		if generated.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	if subtle.ConstantTimeCompare([]byte(found.SecretHash), []byte(tokens.Hash(secret))) != 1 {
+		return nil, nil
+	}
+
+	now := time.Now()
+
+	if found.RevokedAt != nil || (found.ExpiresAt != nil && !now.Before(*found.ExpiresAt)) {
+		return nil, nil
+	}
+
+	// Only bookkeeping, a failure to record it must not fail the request
+	if err := service.keys.Touch(ctx, found.ID, now, now.Add(-constants.API_KEY_LAST_USED_INTERVAL)); err != nil {
+		log.Printf("❌ Failed to record the use of API key %d: %v", found.ID, err)
+	}
+
+	return &models.APIKeyPrincipal{
+		ID:     found.ID,
+		UserID: found.UserID,
+		Scopes: found.Scopes,
+	}, nil
+}
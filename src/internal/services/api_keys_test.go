@@ -0,0 +1,93 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ryuudan/golang-rest-api/ent/generated"
+	"github.com/ryuudan/golang-rest-api/src/constants"
+	"github.com/ryuudan/golang-rest-api/src/internal/models"
+	"github.com/ryuudan/golang-rest-api/src/internal/repositories"
+)
+
+// inlineTx runs functions as if in a transaction, without a database.
+type inlineTx struct{}
+
+func (inlineTx) WithTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+type keyOwners struct {
+	repositories.UserRepository
+	locked int
+}
+
+func (users *keyOwners) GetPermissions(ctx context.Context, id int) ([]string, error) {
+	return []string{"users:read"}, nil
+}
+
+func (users *keyOwners) Lock(ctx context.Context, id int) error {
+	users.locked++
+	return nil
+}
+
+type countedKeys struct {
+	repositories.APIKeyRepository
+	active  int
+	created int
+}
+
+func (keys *countedKeys) CountActive(ctx context.Context, userID int, now time.Time) (int, error) {
+	return keys.active, nil
+}
+
+func (keys *countedKeys) Create(ctx context.Context, key *generated.APIKey) (*generated.APIKey, error) {
+	keys.created++
+	key.ID = keys.active + keys.created
+	return key, nil
+}
+
+func TestAPIKeyCreateCapsActiveKeys(t *testing.T) {
+	tests := []struct {
+		name   string
+		active int
+		err    error
+	}{
+		{"first key", 0, nil},
+		{"last key", constants.API_KEY_MAX_PER_USER - 1, nil},
+		{"over the cap", constants.API_KEY_MAX_PER_USER, ErrTooManyAPIKeys},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			users := &keyOwners{}
+			keys := &countedKeys{active: test.active}
+			service := NewAPIKeyService(keys, users, inlineTx{})
+
+			created, err := service.Create(context.Background(), 1, &models.CreateAPIKeyRequest{
+				Name:   "ci",
+				Scopes: []string{"users:read"},
+			})
+
+			if !errors.Is(err, test.err) {
+				t.Fatalf("err = %v, want %v", err, test.err)
+			}
+			if users.locked != 1 {
+				t.Errorf("user locked %d times, want once before counting", users.locked)
+			}
+
+			wantCreated := 0
+			if test.err == nil {
+				wantCreated = 1
+				if created == nil || created.Key == "" {
+					t.Errorf("created = %+v, want the new key", created)
+				}
+			}
+			if keys.created != wantCreated {
+				t.Errorf("created %d keys, want %d", keys.created, wantCreated)
+			}
+		})
+	}
+}
@@ -0,0 +1,536 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/ryuudan/golang-rest-api/ent/generated"
+	"github.com/ryuudan/golang-rest-api/src/constants"
+	"github.com/ryuudan/golang-rest-api/src/database"
+	"github.com/ryuudan/golang-rest-api/src/internal/models"
+	"github.com/ryuudan/golang-rest-api/src/internal/repositories"
+	"github.com/ryuudan/golang-rest-api/src/utils/mailer"
+	"github.com/ryuudan/golang-rest-api/src/utils/passwords"
+	"github.com/ryuudan/golang-rest-api/src/utils/tokens"
+)
+
+var ErrInvalidCredentials = errors.New("invalid email or password")
+var ErrInvalidRefreshToken = errors.New("invalid or expired refresh token")
+var ErrInvalidResetToken = errors.New("invalid or expired password reset token")
+var ErrInvalidMFAToken = errors.New("invalid or expired MFA token")
+
+// MFARequiredError is returned instead of tokens when the password of a user
+// with two-factor authentication was correct. The challenge has to be completed
+// through VerifyMFA.
+type MFARequiredError struct {
+	Challenge *models.MFAChallengeResponse
+}
+
+func (err *MFARequiredError) Error() string {
+	return "two-factor authentication required"
+}
+
+type AuthService interface {
+	Login(ctx context.Context, email string, password string, ip string) (*models.TokenResponse, error)
+	VerifyMFA(ctx context.Context, mfaToken string, code string, ip string) (*models.TokenResponse, error)
+	Refresh(ctx context.Context, refreshToken string) (*models.TokenResponse, error)
+	Logout(ctx context.Context, claims *tokens.AccessClaims, refreshToken string) error
+	LogoutAll(ctx context.Context, userID int) error
+	ForgotPassword(ctx context.Context, email string) error
+	ResetPassword(ctx context.Context, token string, password string) error
+	IsRevoked(ctx context.Context, claims *tokens.AccessClaims) (bool, error)
+}
+
+type authService struct {
+	repo     repositories.UserRepository
+	tokens   repositories.TokenRepository
+	lockouts LockoutService
+	mfa      MFAService
+	hasher   *passwords.Policy
+	rules    *passwords.Rules
+	mailer   mailer.Mailer
+	cache    *database.RedisCache
+	secret   string
+	resetURL string
+
+	// dummyHash is compared against when no user matches the email, so that
+	// unknown accounts take as long to reject as wrong passwords.
+	dummyHash string
+}
+
+// NewAuthService creates the auth service. resetURL is the page users are sent
+// to from password reset mails, the token is appended to it as a query parameter.
+func NewAuthService(repo repositories.UserRepository, tokens repositories.TokenRepository, lockouts LockoutService, mfa MFAService, hasher *passwords.Policy, rules *passwords.Rules, mailer mailer.Mailer, cache *database.RedisCache, secret string, resetURL string) AuthService {
+	dummyHash, err := hasher.Hash("dummy-password")
+	if err != nil {
+		log.Printf("❌ Failed to hash the dummy password, unknown emails are rejected faster: %v", err)
+	}
+
+	return &authService{
+		repo:      repo,
+		tokens:    tokens,
+		lockouts:  lockouts,
+		mfa:       mfa,
+		hasher:    hasher,
+		rules:     rules,
+		mailer:    mailer,
+		cache:     cache,
+		secret:    secret,
+		resetURL:  resetURL,
+		dummyHash: dummyHash,
+	}
+}
+
+// revokedTokenKey marks a single access token (by its jti) as revoked.
+func revokedTokenKey(tokenID string) string {
+	return fmt.Sprintf("%s:%s", constants.REVOKED_TOKENS_CACHE_NAMESPACE, tokenID)
+}
+
+// revokedUserKey holds the Unix milliseconds before which every token of the user is revoked.
+func revokedUserKey(userID int) string {
+	return fmt.Sprintf("%s:%d", constants.REVOKED_USERS_CACHE_NAMESPACE, userID)
+}
+
+// Login exchanges valid credentials for a new token pair. Failed logins are
+// counted against the account and the client IP, and while either has to back
+// off or is locked out a LoginLockedError is returned without checking the
+// password. Only wrong credentials count, an attempt that fails for any other
+// reason, like the database being down, is refunded. Users with two-factor
+// authentication get an MFARequiredError with a challenge instead of tokens.
+// Password hashes below the current hashing policy are replaced while the
+// password is at hand.
+func (auth *authService) Login(ctx context.Context, email string, password string, ip string) (*models.TokenResponse, error) {
+	attempt, err := auth.lockouts.Reserve(ctx, email, ip)
+	if err != nil {
+		return nil, err
+	}
+
+	user, rehash, err := auth.checkCredentials(ctx, attempt, email, password)
+	if err != nil {
+		return nil, err
+	}
+
+	if rehash {
+		auth.rehash(ctx, user.ID, password)
+	}
+
+	if user.TotpEnabledAt != nil {
+		mfaToken, err := tokens.GenerateMFAToken(user.ID, auth.secret, constants.MFA_TOKEN_EXPIRATION)
+		if err != nil {
+			return nil, err
+		}
+
+		return nil, &MFARequiredError{Challenge: &models.MFAChallengeResponse{
+			MFAToken:  mfaToken,
+			ExpiresIn: int(constants.MFA_TOKEN_EXPIRATION.Seconds()),
+		}}
+	}
+
+	return auth.completeLogin(ctx, user)
+}
+
+// checkCredentials returns the user the email and password belong to, and whether
+// their password hash should be replaced. It settles the attempt: wrong
+// credentials keep it as a failure, anything else refunds it. Earlier failures of
+// the account are kept even when the password matches, until the second factor
+// was checked as well.
+func (auth *authService) checkCredentials(ctx context.Context, attempt *LoginAttempt, email string, password string) (user *generated.User, rehash bool, err error) {
+	defer func() {
+		if err != nil && !errors.Is(err, ErrInvalidCredentials) {
+			refundAfterError(ctx, auth.lockouts, attempt, err)
+		}
+	}()
+
+	user, err = auth.repo.GetByEmail(ctx, email)
+	if err != nil {
+		if generated.IsNotFound(err) {
+			_, _, _ = auth.hasher.Verify(password, auth.dummyHash)
+			return nil, false, auth.loginFailed(ctx, attempt, ErrInvalidCredentials)
+		}
+		return nil, false, err
+	}
+
+	match, rehash, err := auth.hasher.Verify(password, user.Password)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if !match {
+		return nil, false, auth.loginFailed(ctx, attempt, ErrInvalidCredentials)
+	}
+
+	if err := auth.lockouts.Refund(ctx, attempt); err != nil {
+		return nil, false, err
+	}
+
+	return user, rehash, nil
+}
+
+// VerifyMFA completes the login of a user with two-factor authentication, given
+// the MFA token returned by Login and a TOTP or recovery code. Wrong codes count
+// as failed logins. Each MFA token is good for a single attempt, it is used up
+// before any code is checked, so that replaying it can neither consume a recovery
+// code nor reach the lockout counters. After a wrong code the user logs in again.
+func (auth *authService) VerifyMFA(ctx context.Context, mfaToken string, code string, ip string) (*models.TokenResponse, error) {
+	claims, err := tokens.ParseMFAToken(mfaToken, auth.secret)
+	if err != nil {
+		return nil, ErrInvalidMFAToken
+	}
+
+	userID, err := claims.UserID()
+	if err != nil {
+		return nil, ErrInvalidMFAToken
+	}
+
+	firstUse, err := auth.tokens.MarkUsed(ctx, fmt.Sprintf("mfa:%s", claims.ID), constants.MFA_TOKEN_EXPIRATION)
+	if err != nil {
+		return nil, err
+	}
+
+	if !firstUse {
+		return nil, ErrInvalidMFAToken
+	}
+
+	user, err := auth.repo.GetByID(ctx, userID)
+	if err != nil {
+		if generated.IsNotFound(err) {
+			return nil, ErrInvalidMFAToken
+		}
+		return nil, err
+	}
+
+	attempt, err := auth.lockouts.Reserve(ctx, user.Email, ip)
+	if err != nil {
+		return nil, err
+	}
+
+	valid, err := auth.mfa.VerifyCode(ctx, user, code)
+	if err != nil {
+		refundAfterError(ctx, auth.lockouts, attempt, err)
+		return nil, err
+	}
+
+	if !valid {
+		return nil, auth.loginFailed(ctx, attempt, ErrInvalidMFACode)
+	}
+
+	if err := auth.lockouts.Refund(ctx, attempt); err != nil {
+		return nil, err
+	}
+
+	return auth.completeLogin(ctx, user)
+}
+
+// completeLogin clears the failed logins of the user and issues a token pair.
+func (auth *authService) completeLogin(ctx context.Context, user *generated.User) (*models.TokenResponse, error) {
+	if err := auth.lockouts.RecordSuccess(ctx, user.Email); err != nil {
+		return nil, err
+	}
+
+	// Every login starts a new refresh token family
+	familyID, err := tokens.RandomString(16)
+	if err != nil {
+		return nil, err
+	}
+
+	return auth.issueTokens(ctx, user.ID, familyID)
+}
+
+// rehash replaces the password hash of the user with one of the current hashing
+// policy. The old hash still works, so failing to replace it only gets logged.
+func (auth *authService) rehash(ctx context.Context, userID int, password string) {
+	hash, err := auth.hasher.Hash(password)
+	if err == nil {
+		err = auth.repo.UpdatePassword(ctx, userID, hash)
+	}
+
+	if err != nil {
+		log.Printf("❌ Failed to rehash the password of user %d: %v", userID, err)
+	}
+}
+
+// loginFailed settles a failed login attempt and returns reason, the error to
+// report it with.
+func (auth *authService) loginFailed(ctx context.Context, attempt *LoginAttempt, reason error) error {
+	if err := auth.lockouts.Failed(ctx, attempt); err != nil {
+		return err
+	}
+	return reason
+}
+
+// Refresh rotates a refresh token: the presented token is spent and a new access
+// and refresh token pair of the same family is returned. Presenting a token that
+// was already spent means it was copied, so the whole family is revoked.
+func (auth *authService) Refresh(ctx context.Context, refreshToken string) (*models.TokenResponse, error) {
+	hash := tokens.Hash(refreshToken)
+
+	record, err := auth.tokens.GetRefreshToken(ctx, hash)
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, ErrInvalidRefreshToken
+		}
+		return nil, err
+	}
+
+	active, err := auth.tokens.IsFamilyActive(ctx, record.FamilyID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !active {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	revokedBefore, err := auth.revokedBefore(ctx, record.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	if record.IssuedAt <= revokedBefore {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	// Deleted users keep no sessions
+	if _, err := auth.repo.GetByID(ctx, record.UserID); err != nil {
+		if generated.IsNotFound(err) {
+			return nil, ErrInvalidRefreshToken
+		}
+		return nil, err
+	}
+
+	firstUse, err := auth.tokens.UseRefreshToken(ctx, hash)
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, ErrInvalidRefreshToken
+		}
+		return nil, err
+	}
+
+	if !firstUse {
+		if err := auth.tokens.RevokeFamily(ctx, record.FamilyID); err != nil {
+			return nil, err
+		}
+		return nil, ErrInvalidRefreshToken
+	}
+
+	return auth.issueTokens(ctx, record.UserID, record.FamilyID)
+}
+
+// Logout revokes the access token the request was made with until it expires. If
+// a refresh token of the same user is given, its token family is revoked as well.
+func (auth *authService) Logout(ctx context.Context, claims *tokens.AccessClaims, refreshToken string) error {
+	if expiration := time.Until(claims.ExpiresAt.Time); expiration > 0 {
+		if err := auth.cache.SetCache(ctx, revokedTokenKey(claims.ID), true, expiration); err != nil {
+			return err
+		}
+	}
+
+	if refreshToken == "" {
+		return nil
+	}
+
+	record, err := auth.tokens.GetRefreshToken(ctx, tokens.Hash(refreshToken))
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil
+		}
+		return err
+	}
+
+	if userID, err := claims.UserID(); err != nil || userID != record.UserID {
+		return nil
+	}
+
+	return auth.tokens.RevokeFamily(ctx, record.FamilyID)
+}
+
+// LogoutAll revokes every access and refresh token issued to the user so far. The
+// marker lives as long as the longest lived token that it could apply to.
+func (auth *authService) LogoutAll(ctx context.Context, userID int) error {
+	return auth.cache.SetCache(ctx, revokedUserKey(userID), time.Now().UnixMilli(), constants.REFRESH_TOKEN_EXPIRATION)
+}
+
+// ForgotPassword mails a single use password reset token to the user with the
+// given email. Unknown emails are silently ignored, so that the endpoint does
+// not reveal which accounts exist.
+func (auth *authService) ForgotPassword(ctx context.Context, email string) error {
+	user, err := auth.repo.GetByEmail(ctx, email)
+	if err != nil {
+		if generated.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	token, err := tokens.RandomString(32)
+	if err != nil {
+		return err
+	}
+
+	err = auth.tokens.SavePasswordResetToken(ctx, tokens.Hash(token), &models.PasswordResetToken{
+		UserID:   user.ID,
+		IssuedAt: time.Now().UnixMilli(),
+	}, constants.PASSWORD_RESET_TOKEN_EXPIRATION)
+
+	if err != nil {
+		return err
+	}
+
+	body := fmt.Sprintf(
+		"Hi %s,\n\nUse this token to reset your password within the next %d minutes:\n\n%s\n",
+		user.FirstName,
+		int(constants.PASSWORD_RESET_TOKEN_EXPIRATION.Minutes()),
+		token,
+	)
+
+	if auth.resetURL != "" {
+		body += fmt.Sprintf("\nOr open %s?token=%s\n", auth.resetURL, url.QueryEscape(token))
+	}
+
+	body += "\nIf you did not ask for a password reset, you can ignore this email.\n"
+
+	return auth.mailer.Send(ctx, &mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body:    body,
+	})
+}
+
+// ResetPassword spends a password reset token and replaces the password of its
+// user. Every session of the user is revoked, and so is every other reset token
+// issued before, since LogoutAll marks them as revoked as well. A password that
+// breaks the password rules leaves the token unspent, so that the user can try
+// another one.
+func (auth *authService) ResetPassword(ctx context.Context, token string, password string) error {
+	hash := tokens.Hash(token)
+
+	record, err := auth.tokens.GetPasswordResetToken(ctx, hash)
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return ErrInvalidResetToken
+		}
+		return err
+	}
+
+	revokedBefore, err := auth.revokedBefore(ctx, record.UserID)
+	if err != nil {
+		return err
+	}
+
+	if record.IssuedAt <= revokedBefore {
+		return ErrInvalidResetToken
+	}
+
+	user, err := auth.repo.GetByID(ctx, record.UserID)
+	if err != nil {
+		if generated.IsNotFound(err) {
+			return ErrInvalidResetToken
+		}
+		return err
+	}
+
+	if err := checkPassword(auth.rules, password, user); err != nil {
+		return err
+	}
+
+	// Only one of concurrent resets with the same token gets to spend it
+	if _, err := auth.tokens.UsePasswordResetToken(ctx, hash); err != nil {
+		if errors.Is(err, redis.Nil) {
+			return ErrInvalidResetToken
+		}
+		return err
+	}
+
+	hashed, err := auth.hasher.Hash(password)
+	if err != nil {
+		return err
+	}
+
+	if err := auth.repo.UpdatePassword(ctx, user.ID, hashed); err != nil {
+		return err
+	}
+
+	if err := auth.LogoutAll(ctx, user.ID); err != nil {
+		return err
+	}
+
+	// Whoever reset the password owns the mailbox, so a lockout no longer protects anything
+	return auth.lockouts.RecordSuccess(ctx, user.Email)
+}
+
+func (auth *authService) IsRevoked(ctx context.Context, claims *tokens.AccessClaims) (bool, error) {
+	var revoked bool
+
+	// Only the presence of the marker matters, not what it decodes to
+	err := auth.cache.GetCache(ctx, revokedTokenKey(claims.ID), &revoked)
+	if err == nil || errors.Is(err, database.ErrUndecodable) {
+		return true, nil
+	}
+
+	if !errors.Is(err, redis.Nil) {
+		return false, err
+	}
+
+	userID, err := claims.UserID()
+	if err != nil {
+		return false, err
+	}
+
+	revokedBefore, err := auth.revokedBefore(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+
+	return claims.IssuedAtMillis <= revokedBefore, nil
+}
+
+// revokedBefore returns the Unix milliseconds set by the user's last LogoutAll,
+// or zero when there was none. Milliseconds keep a login right after a LogoutAll,
+// within the same second, from being revoked as well.
+func (auth *authService) revokedBefore(ctx context.Context, userID int) (int64, error) {
+	var revokedBefore int64
+
+	err := auth.cache.GetCache(ctx, revokedUserKey(userID), &revokedBefore)
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	return revokedBefore, nil
+}
+
+func (auth *authService) issueTokens(ctx context.Context, userID int, familyID string) (*models.TokenResponse, error) {
+	accessToken, err := tokens.GenerateAccessToken(userID, auth.secret, constants.ACCESS_TOKEN_EXPIRATION)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, err := tokens.RandomString(32)
+	if err != nil {
+		return nil, err
+	}
+
+	err = auth.tokens.SaveRefreshToken(ctx, tokens.Hash(refreshToken), &models.RefreshToken{
+		UserID:   userID,
+		FamilyID: familyID,
+		IssuedAt: time.Now().UnixMilli(),
+	}, constants.REFRESH_TOKEN_EXPIRATION)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.TokenResponse{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(constants.ACCESS_TOKEN_EXPIRATION.Seconds()),
+	}, nil
+}
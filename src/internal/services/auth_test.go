@@ -0,0 +1,237 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/redis/go-redis/v9"
+	"github.com/ryuudan/golang-rest-api/ent/generated"
+	"github.com/ryuudan/golang-rest-api/src/database"
+	"github.com/ryuudan/golang-rest-api/src/internal/repositories"
+	"github.com/ryuudan/golang-rest-api/src/utils/passwords"
+	"github.com/ryuudan/golang-rest-api/src/utils/tokens"
+)
+
+const testSecret = "test-secret"
+
+// mfaUsers finds a single user with two-factor authentication enabled.
+type mfaUsers struct {
+	repositories.UserRepository
+}
+
+func (mfaUsers) GetByID(ctx context.Context, id int) (*generated.User, error) {
+	enabled := time.Now()
+	return &generated.User{ID: id, Email: "ada@example.com", TotpEnabledAt: &enabled}, nil
+}
+
+// countingLockouts records the attempts it settles, and never locks anyone out.
+type countingLockouts struct {
+	LockoutService
+	reserved, failed, refunded int
+}
+
+func (lockouts *countingLockouts) Reserve(ctx context.Context, email string, ip string) (*LoginAttempt, error) {
+	lockouts.reserved++
+	return &LoginAttempt{email: email, ip: ip}, nil
+}
+
+func (lockouts *countingLockouts) Failed(ctx context.Context, attempt *LoginAttempt) error {
+	lockouts.failed++
+	return nil
+}
+
+func (lockouts *countingLockouts) Refund(ctx context.Context, attempt *LoginAttempt) error {
+	lockouts.refunded++
+	return nil
+}
+
+func (lockouts *countingLockouts) RecordSuccess(ctx context.Context, email string) error {
+	return nil
+}
+
+// fixedCodes accepts a single code, and counts how often codes were checked.
+type fixedCodes struct {
+	MFAService
+	code    string
+	checked int
+}
+
+func (codes *fixedCodes) VerifyCode(ctx context.Context, user *generated.User, code string) (bool, error) {
+	codes.checked++
+	return code == codes.code, nil
+}
+
+func TestVerifyMFAUsesUpTheTokenFirst(t *testing.T) {
+	type step struct {
+		code string
+		err  error // nil when the login completes
+	}
+
+	tests := []struct {
+		name  string
+		steps []step
+		// Codes checked and attempts reserved over all steps
+		checked, reserved int
+	}{
+		{"single use", []step{{"123456", nil}}, 1, 1},
+		{"replayed after a login", []step{{"123456", nil}, {"123456", ErrInvalidMFAToken}}, 1, 1},
+		{"retried after a wrong code", []step{{"000000", ErrInvalidMFACode}, {"123456", ErrInvalidMFAToken}}, 1, 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server := miniredis.RunT(t)
+			client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+			t.Cleanup(func() { client.Close() })
+
+			lockouts := &countingLockouts{}
+			codes := &fixedCodes{code: "123456"}
+			hasher := passwords.NewPolicy(&passwords.Bcrypt{Cost: 4})
+			auth := NewAuthService(mfaUsers{}, repositories.NewTokenRepository(client), lockouts, codes, hasher, nil, nil, nil, testSecret, "")
+
+			mfaToken, err := tokens.GenerateMFAToken(1, testSecret, time.Minute)
+			if err != nil {
+				t.Fatalf("GenerateMFAToken: %v", err)
+			}
+
+			for i, step := range test.steps {
+				issued, err := auth.VerifyMFA(context.Background(), mfaToken, step.code, "192.0.2.1")
+
+				if !errors.Is(err, step.err) {
+					t.Fatalf("step %d: err = %v, want %v", i, err, step.err)
+				}
+				if step.err == nil && issued == nil {
+					t.Fatalf("step %d: no tokens issued", i)
+				}
+			}
+
+			if codes.checked != test.checked || lockouts.reserved != test.reserved {
+				t.Errorf("checked %d codes and reserved %d attempts, want %d and %d",
+					codes.checked, lockouts.reserved, test.checked, test.reserved)
+			}
+		})
+	}
+}
+
+// loginUsers finds a single user by email, or fails with err.
+type loginUsers struct {
+	repositories.UserRepository
+	user *generated.User
+	err  error
+}
+
+func (users loginUsers) GetByEmail(ctx context.Context, email string) (*generated.User, error) {
+	if users.err != nil {
+		return nil, users.err
+	}
+	if users.user == nil || users.user.Email != email {
+		return nil, &generated.NotFoundError{}
+	}
+	return users.user, nil
+}
+
+func TestLoginOnlyCountsWrongCredentials(t *testing.T) {
+	hasher := passwords.NewPolicy(&passwords.Bcrypt{Cost: 4})
+
+	hash, err := hasher.Hash("correct horse battery staple")
+	if err != nil {
+		t.Fatalf("Hash: %v", err)
+	}
+
+	ada := &generated.User{ID: 1, Email: "ada@example.com", Password: hash}
+	outage := errors.New("connection refused")
+
+	tests := []struct {
+		name     string
+		users    loginUsers
+		email    string
+		password string
+		err      error // nil when the login completes
+		failed   int
+		refunded int
+	}{
+		{"correct credentials", loginUsers{user: ada}, "ada@example.com", "correct horse battery staple", nil, 0, 1},
+		{"wrong password", loginUsers{user: ada}, "ada@example.com", "wrong password", ErrInvalidCredentials, 1, 0},
+		{"unknown email", loginUsers{user: ada}, "grace@example.com", "correct horse battery staple", ErrInvalidCredentials, 1, 0},
+		{"database outage", loginUsers{err: outage}, "ada@example.com", "correct horse battery staple", outage, 0, 1},
+		{"unreadable stored hash", loginUsers{user: &generated.User{ID: 1, Email: "ada@example.com", Password: "$scrypt$broken"}},
+			"ada@example.com", "correct horse battery staple", passwords.ErrUnknownHash, 0, 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server := miniredis.RunT(t)
+			client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+			t.Cleanup(func() { client.Close() })
+
+			lockouts := &countingLockouts{}
+			auth := NewAuthService(test.users, repositories.NewTokenRepository(client), lockouts, &fixedCodes{}, hasher, nil, nil, nil, testSecret, "")
+
+			issued, err := auth.Login(context.Background(), test.email, test.password, "192.0.2.1")
+
+			if !errors.Is(err, test.err) {
+				t.Fatalf("err = %v, want %v", err, test.err)
+			}
+			if test.err == nil && issued == nil {
+				t.Fatal("no tokens issued")
+			}
+
+			if lockouts.failed != test.failed || lockouts.refunded != test.refunded {
+				t.Errorf("failed %d and refunded %d attempts, want %d and %d",
+					lockouts.failed, lockouts.refunded, test.failed, test.refunded)
+			}
+		})
+	}
+}
+
+func TestIsRevokedComparesMilliseconds(t *testing.T) {
+	tests := []struct {
+		name     string
+		issuedAt time.Duration // Relative to the LogoutAll
+		revoked  bool
+	}{
+		{"issued long before", -time.Hour, true},
+		{"issued just before", -time.Millisecond, true},
+		{"issued at the same time", 0, true},
+		{"issued just after", time.Millisecond, false},
+		{"issued long after", time.Hour, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server := miniredis.RunT(t)
+			client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+			t.Cleanup(func() { client.Close() })
+
+			cache := database.NewRedisCache(client, database.CacheTimeouts{Read: time.Second, Write: time.Second}, database.NewEncoder(database.JSONCodec{}, database.CompressionNone, 0))
+			hasher := passwords.NewPolicy(&passwords.Bcrypt{Cost: 4})
+			auth := NewAuthService(mfaUsers{}, repositories.NewTokenRepository(client), &countingLockouts{}, &fixedCodes{}, hasher, nil, nil, cache, testSecret, "")
+
+			if err := auth.LogoutAll(context.Background(), 1); err != nil {
+				t.Fatalf("LogoutAll: %v", err)
+			}
+
+			var revokedBefore int64
+			if err := cache.GetCache(context.Background(), revokedUserKey(1), &revokedBefore); err != nil {
+				t.Fatalf("GetCache: %v", err)
+			}
+
+			claims := &tokens.AccessClaims{
+				RegisteredClaims: jwt.RegisteredClaims{ID: "token", Subject: "1"},
+				IssuedAtMillis:   time.UnixMilli(revokedBefore).Add(test.issuedAt).UnixMilli(),
+			}
+
+			revoked, err := auth.IsRevoked(context.Background(), claims)
+			if err != nil {
+				t.Fatalf("IsRevoked: %v", err)
+			}
+			if revoked != test.revoked {
+				t.Errorf("revoked = %v, want %v", revoked, test.revoked)
+			}
+		})
+	}
+}
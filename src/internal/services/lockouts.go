@@ -0,0 +1,227 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/ryuudan/golang-rest-api/ent/generated"
+	"github.com/ryuudan/golang-rest-api/ent/generated/lockevent"
+	"github.com/ryuudan/golang-rest-api/src/constants"
+	"github.com/ryuudan/golang-rest-api/src/internal/models"
+	"github.com/ryuudan/golang-rest-api/src/internal/repositories"
+	"github.com/ryuudan/golang-rest-api/src/utils/tokens"
+)
+
+var accountLockoutPolicy = &models.LockoutPolicy{
+	FreeFailures: constants.ACCOUNT_LOGIN_FREE_FAILURES,
+	MaxFailures:  constants.ACCOUNT_LOGIN_MAX_FAILURES,
+	Window:       constants.LOGIN_FAILURE_WINDOW,
+	BaseBackoff:  constants.LOGIN_BASE_BACKOFF,
+	MaxBackoff:   constants.LOGIN_MAX_BACKOFF,
+	LockDuration: constants.ACCOUNT_LOCKOUT_DURATION,
+}
+
+var ipLockoutPolicy = &models.LockoutPolicy{
+	FreeFailures: constants.IP_LOGIN_FREE_FAILURES,
+	MaxFailures:  constants.IP_LOGIN_MAX_FAILURES,
+	Window:       constants.LOGIN_FAILURE_WINDOW,
+	BaseBackoff:  constants.LOGIN_BASE_BACKOFF,
+	MaxBackoff:   constants.LOGIN_MAX_BACKOFF,
+	LockDuration: constants.IP_LOCKOUT_DURATION,
+}
+
+// LoginLockedError is returned for logins attempted while the account or the
+// client IP is backing off or locked out after failed logins.
+type LoginLockedError struct {
+	RetryAfter time.Duration
+}
+
+func (err *LoginLockedError) Error() string {
+	return fmt.Sprintf("login locked, retry after %s", err.RetryAfter)
+}
+
+// LoginAttempt is a login attempt reserved by LockoutService.Reserve. It already
+// counts as failed, and has to be settled with either Failed or Refund once the
+// credentials were checked.
+type LoginAttempt struct {
+	email       string
+	ip          string
+	token       string
+	subjects    []*models.LoginSubject
+	reservation *models.LoginReservation
+}
+
+// refundAfterError refunds an attempt that failed with cause instead of a wrong
+// credential, so that outages do not lock accounts. The caller reports cause, a
+// failure to refund is only logged.
+func refundAfterError(ctx context.Context, lockouts LockoutService, attempt *LoginAttempt, cause error) {
+	if err := lockouts.Refund(ctx, attempt); err != nil {
+		log.Printf("❌ Failed to refund the login attempt for %s after %v: %v", attempt.email, cause, err)
+	}
+}
+
+type LockoutService interface {
+	Reserve(ctx context.Context, email string, ip string) (*LoginAttempt, error)
+	Failed(ctx context.Context, attempt *LoginAttempt) error
+	Refund(ctx context.Context, attempt *LoginAttempt) error
+	RecordSuccess(ctx context.Context, email string) error
+	Unlock(ctx context.Context, userID int, actorID int) error
+}
+
+type lockoutService struct {
+	attempts repositories.LoginAttemptRepository
+	events   repositories.LockEventRepository
+	users    repositories.UserRepository
+}
+
+func NewLockoutService(attempts repositories.LoginAttemptRepository, events repositories.LockEventRepository, users repositories.UserRepository) LockoutService {
+	return &lockoutService{
+		attempts: attempts,
+		events:   events,
+		users:    users,
+	}
+}
+
+// lockoutEmail normalizes an email the way every failed login, lockout and
+// unlock of its account refers to it.
+func lockoutEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// accountSubject names what the failed logins for an email are counted against.
+// Unknown emails are counted like any other, so lockouts do not reveal which
+// accounts exist.
+func accountSubject(email string) string {
+	return fmt.Sprintf("account:%s", lockoutEmail(email))
+}
+
+func ipSubject(ip string) string {
+	return fmt.Sprintf("ip:%s", ip)
+}
+
+// Reserve starts a login attempt for the account and the client IP. It returns a
+// LoginLockedError while either of them has to wait before trying again, and
+// otherwise counts the attempt as failed against both in the same step, so that
+// parallel attempts cannot all be checked before any of them is counted.
+func (lockout *lockoutService) Reserve(ctx context.Context, email string, ip string) (*LoginAttempt, error) {
+	token, err := tokens.RandomString(16)
+	if err != nil {
+		return nil, err
+	}
+
+	subjects := []*models.LoginSubject{
+		{Name: accountSubject(email), Policy: accountLockoutPolicy},
+		{Name: ipSubject(ip), Policy: ipLockoutPolicy},
+	}
+
+	reservation, err := lockout.attempts.Reserve(ctx, token, subjects)
+	if err != nil {
+		return nil, err
+	}
+
+	if !reservation.Allowed {
+		return nil, &LoginLockedError{RetryAfter: reservation.RetryAfter}
+	}
+
+	return &LoginAttempt{
+		email:       email,
+		ip:          ip,
+		token:       token,
+		subjects:    subjects,
+		reservation: reservation,
+	}, nil
+}
+
+// Failed settles an attempt whose credentials were wrong. It was counted already,
+// so this only records a lock event for the account and the client IP if the
+// attempt locked them out.
+func (lockout *lockoutService) Failed(ctx context.Context, attempt *LoginAttempt) error {
+	account, client := attempt.reservation.Failures[0], attempt.reservation.Failures[1]
+
+	if account.Locked {
+		event := &generated.LockEvent{
+			Scope:    lockevent.ScopeAccount,
+			Subject:  lockoutEmail(attempt.email),
+			Failures: account.Failures,
+			IP:       &attempt.ip,
+		}
+
+		if user, err := lockout.users.GetByEmail(ctx, attempt.email); err == nil {
+			event.UserID = &user.ID
+		}
+
+		lockout.recordLock(ctx, event, account.RetryAfter)
+	}
+
+	if client.Locked {
+		lockout.recordLock(ctx, &generated.LockEvent{
+			Scope:    lockevent.ScopeIP,
+			Subject:  attempt.ip,
+			Failures: client.Failures,
+			IP:       &attempt.ip,
+		}, client.RetryAfter)
+	}
+
+	return nil
+}
+
+// Refund settles an attempt whose credentials were right, taking back the failure
+// it was counted as along with any backoff or lockout that caused.
+func (lockout *lockoutService) Refund(ctx context.Context, attempt *LoginAttempt) error {
+	for i, subject := range attempt.subjects {
+		if err := lockout.attempts.Refund(ctx, attempt.token, subject, attempt.reservation.Failures[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// recordLock stores a lock event. The lockout itself is already in place, so a
+// failure to record it is only logged.
+func (lockout *lockoutService) recordLock(ctx context.Context, event *generated.LockEvent, duration time.Duration) {
+	lockedUntil := time.Now().Add(duration)
+
+	event.Action = lockevent.ActionLocked
+	event.LockedUntil = &lockedUntil
+
+	log.Printf("🔒 Locked out %s %s after %d failed logins", event.Scope, event.Subject, event.Failures)
+
+	if _, err := lockout.events.Create(ctx, event); err != nil {
+		log.Printf("❌ Failed to record the lockout of %s %s: %v", event.Scope, event.Subject, err)
+	}
+}
+
+// RecordSuccess forgets the failed logins of the account. Those of the client IP
+// are kept, since one valid login says nothing about the other accounts tried.
+func (lockout *lockoutService) RecordSuccess(ctx context.Context, email string) error {
+	return lockout.attempts.Reset(ctx, accountSubject(email))
+}
+
+// Unlock lifts any backoff or lockout of the user's account on behalf of actorID,
+// and records it. The failed logins counted against the client IPs they came from
+// stay in place, since those IPs may have tried other accounts as well. They
+// expire on their own once the failure window or IP lockout is over.
+func (lockout *lockoutService) Unlock(ctx context.Context, userID int, actorID int) error {
+	user, err := lockout.users.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if err := lockout.attempts.Reset(ctx, accountSubject(user.Email)); err != nil {
+		return err
+	}
+
+	_, err = lockout.events.Create(ctx, &generated.LockEvent{
+		Scope:   lockevent.ScopeAccount,
+		Subject: lockoutEmail(user.Email),
+		Action:  lockevent.ActionUnlocked,
+		UserID:  &user.ID,
+		ActorID: &actorID,
+	})
+
+	return err
+}
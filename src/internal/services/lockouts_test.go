@@ -0,0 +1,93 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/ryuudan/golang-rest-api/ent/generated"
+	"github.com/ryuudan/golang-rest-api/src/internal/models"
+	"github.com/ryuudan/golang-rest-api/src/internal/repositories"
+)
+
+// recordedEvents keeps the lock events instead of storing them.
+type recordedEvents struct {
+	events []*generated.LockEvent
+}
+
+func (recorded *recordedEvents) Create(ctx context.Context, event *generated.LockEvent) (*generated.LockEvent, error) {
+	recorded.events = append(recorded.events, event)
+	return event, nil
+}
+
+// storedUser finds a single user, under their email as it was stored.
+type storedUser struct {
+	repositories.UserRepository
+	user *generated.User
+}
+
+func (users storedUser) GetByID(ctx context.Context, id int) (*generated.User, error) {
+	return users.user, nil
+}
+
+func (users storedUser) GetByEmail(ctx context.Context, email string) (*generated.User, error) {
+	return users.user, nil
+}
+
+func TestLockAndUnlockReferToTheSameAccount(t *testing.T) {
+	tests := []struct {
+		name   string
+		typed  string // The email a login was attempted with
+		stored string
+	}{
+		{"same case", "ada@example.com", "ada@example.com"},
+		{"typed in another case", "ADA@example.com", "ada@example.com"},
+		{"stored in another case", "ada@example.com", "Ada@Example.com"},
+		{"surrounding spaces", "  ada@example.com ", "ada@example.com"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server := miniredis.RunT(t)
+			client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+			t.Cleanup(func() { client.Close() })
+
+			events := &recordedEvents{}
+			lockouts := NewLockoutService(repositories.NewLoginAttemptRepository(client), events, storedUser{user: &generated.User{ID: 1, Email: test.stored}})
+
+			attempt, err := lockouts.Reserve(context.Background(), test.typed, "192.0.2.1")
+			if err != nil {
+				t.Fatalf("Reserve: %v", err)
+			}
+
+			// Settle it as the failure that locked the account
+			attempt.reservation.Failures[0] = &models.LoginFailure{Failures: 10, Locked: true}
+			if err := lockouts.Failed(context.Background(), attempt); err != nil {
+				t.Fatalf("Failed: %v", err)
+			}
+
+			if err := lockouts.Unlock(context.Background(), 1, 2); err != nil {
+				t.Fatalf("Unlock: %v", err)
+			}
+
+			if len(events.events) != 2 {
+				t.Fatalf("recorded %d events, want the lock and the unlock", len(events.events))
+			}
+			if locked, unlocked := events.events[0].Subject, events.events[1].Subject; locked != unlocked {
+				t.Errorf("locked %q but unlocked %q", locked, unlocked)
+			}
+
+			for _, key := range server.Keys() {
+				if strings.Contains(key, "account:") {
+					t.Errorf("key %q of the account left after the unlock", key)
+				}
+			}
+
+			if !server.Exists("login_failures:ip:192.0.2.1") {
+				t.Error("the failures of the client IP were cleared, want them kept")
+			}
+		})
+	}
+}
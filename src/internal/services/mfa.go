@@ -0,0 +1,265 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pquerna/otp/totp"
+	"github.com/ryuudan/golang-rest-api/ent/generated"
+	"github.com/ryuudan/golang-rest-api/src/constants"
+	"github.com/ryuudan/golang-rest-api/src/internal/models"
+	"github.com/ryuudan/golang-rest-api/src/internal/repositories"
+	"github.com/ryuudan/golang-rest-api/src/utils/encryption"
+	"github.com/ryuudan/golang-rest-api/src/utils/tokens"
+)
+
+var ErrMFAAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+var ErrMFANotEnrolled = errors.New("two-factor authentication was not set up")
+var ErrMFANotEnabled = errors.New("two-factor authentication is not enabled")
+var ErrInvalidMFACode = errors.New("invalid authentication code")
+
+// totpReplayWindow covers every code totp.Validate accepts at a given time, the
+// current one and one period on either side.
+const totpReplayWindow = 3 * 30 * time.Second
+
+type MFAService interface {
+	Enroll(ctx context.Context, userID int) (*models.MFAEnrollmentResponse, error)
+	Confirm(ctx context.Context, userID int, code string, ip string) (*models.RecoveryCodesResponse, error)
+	Disable(ctx context.Context, userID int, code string, ip string) error
+	VerifyCode(ctx context.Context, user *generated.User, code string) (bool, error)
+	IsCompliant(ctx context.Context, userID int) (bool, error)
+}
+
+type mfaService struct {
+	users    repositories.UserRepository
+	recovery repositories.RecoveryCodeRepository
+	tokens   repositories.TokenRepository
+	tx       repositories.Transactor
+	lockouts LockoutService
+	cipher   *encryption.Cipher
+}
+
+func NewMFAService(users repositories.UserRepository, recovery repositories.RecoveryCodeRepository, tokens repositories.TokenRepository, tx repositories.Transactor, lockouts LockoutService, cipher *encryption.Cipher) MFAService {
+	return &mfaService{
+		users:    users,
+		recovery: recovery,
+		tokens:   tokens,
+		tx:       tx,
+		lockouts: lockouts,
+		cipher:   cipher,
+	}
+}
+
+// Enroll generates a new TOTP secret for the user. It is only required at login
+// once Confirm proved that the user's authenticator produces matching codes, and
+// enrolling again before that replaces it.
+func (mfa *mfaService) Enroll(ctx context.Context, userID int) (*models.MFAEnrollmentResponse, error) {
+	user, err := mfa.users.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.TotpEnabledAt != nil {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	key, err := totp.Generate(totp.GenerateOpts{
+		Issuer:      constants.MFA_ISSUER,
+		AccountName: user.Email,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	encrypted, err := mfa.cipher.Encrypt(key.Secret())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := mfa.users.SetTOTPSecret(ctx, user.ID, encrypted); err != nil {
+		return nil, err
+	}
+
+	return &models.MFAEnrollmentResponse{
+		Secret: key.Secret(),
+		URL:    key.URL(),
+	}, nil
+}
+
+// Confirm turns two-factor authentication on once the user entered a valid code
+// for the enrolled secret, and returns a fresh set of recovery codes. Wrong codes
+// count as failed logins of the user from ip.
+func (mfa *mfaService) Confirm(ctx context.Context, userID int, code string, ip string) (*models.RecoveryCodesResponse, error) {
+	user, err := mfa.users.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.TotpEnabledAt != nil {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	if user.TotpSecret == nil {
+		return nil, ErrMFANotEnrolled
+	}
+
+	err = mfa.checkCode(ctx, user, ip, func() (bool, error) {
+		return mfa.verifyTOTP(ctx, user, code)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := generateRecoveryCodes(constants.MFA_RECOVERY_CODE_COUNT)
+	if err != nil {
+		return nil, err
+	}
+
+	err = mfa.tx.WithTx(ctx, func(ctx context.Context) error {
+		if err := mfa.users.EnableTOTP(ctx, user.ID); err != nil {
+			return err
+		}
+		return mfa.recovery.Replace(ctx, user.ID, hashes)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.RecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+// Disable turns two-factor authentication off, which takes a valid TOTP or
+// recovery code, and drops the secret and the recovery codes. Wrong codes count
+// as failed logins of the user from ip.
+func (mfa *mfaService) Disable(ctx context.Context, userID int, code string, ip string) error {
+	user, err := mfa.users.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if user.TotpEnabledAt == nil {
+		return ErrMFANotEnabled
+	}
+
+	err = mfa.checkCode(ctx, user, ip, func() (bool, error) {
+		return mfa.VerifyCode(ctx, user, code)
+	})
+	if err != nil {
+		return err
+	}
+
+	return mfa.tx.WithTx(ctx, func(ctx context.Context) error {
+		if err := mfa.users.DisableTOTP(ctx, user.ID); err != nil {
+			return err
+		}
+		return mfa.recovery.DeleteAll(ctx, user.ID)
+	})
+}
+
+// checkCode runs verify as a login attempt of the user from ip, so that guessing
+// codes runs into the same backoff and lockout as guessing them at login. It
+// returns a LoginLockedError without verifying while the attempt has to wait, and
+// ErrInvalidMFACode for a wrong code.
+func (mfa *mfaService) checkCode(ctx context.Context, user *generated.User, ip string, verify func() (bool, error)) error {
+	attempt, err := mfa.lockouts.Reserve(ctx, user.Email, ip)
+	if err != nil {
+		return err
+	}
+
+	valid, err := verify()
+	if err != nil {
+		refundAfterError(ctx, mfa.lockouts, attempt, err)
+		return err
+	}
+
+	if !valid {
+		if err := mfa.lockouts.Failed(ctx, attempt); err != nil {
+			return err
+		}
+		return ErrInvalidMFACode
+	}
+
+	return mfa.lockouts.Refund(ctx, attempt)
+}
+
+// VerifyCode checks a second factor of a user with two-factor authentication,
+// either a TOTP code or one of their unused recovery codes. Either can only be
+// used once.
+func (mfa *mfaService) VerifyCode(ctx context.Context, user *generated.User, code string) (bool, error) {
+	if user.TotpEnabledAt == nil || user.TotpSecret == nil {
+		return false, nil
+	}
+
+	code = normalizeCode(code)
+
+	if len(code) == 6 {
+		return mfa.verifyTOTP(ctx, user, code)
+	}
+
+	return mfa.recovery.Use(ctx, user.ID, tokens.Hash(code))
+}
+
+// verifyTOTP checks a code against the user's TOTP secret. Valid codes are
+// remembered while they are valid, so that an observed code cannot be replayed.
+func (mfa *mfaService) verifyTOTP(ctx context.Context, user *generated.User, code string) (bool, error) {
+	secret, err := mfa.cipher.Decrypt(*user.TotpSecret)
+	if err != nil {
+		return false, fmt.Errorf("decrypting TOTP secret: %w", err)
+	}
+
+	code = normalizeCode(code)
+
+	if !totp.Validate(code, secret) {
+		return false, nil
+	}
+
+	return mfa.tokens.MarkUsed(ctx, fmt.Sprintf("totp:%d:%s", user.ID, code), totpReplayWindow)
+}
+
+// IsCompliant reports whether the user meets the two-factor policy: admins must
+// have it enabled, everyone else may.
+func (mfa *mfaService) IsCompliant(ctx context.Context, userID int) (bool, error) {
+	admin, err := mfa.users.HasRole(ctx, userID, constants.ROLE_ADMIN)
+	if err != nil || !admin {
+		return !admin, err
+	}
+
+	user, err := mfa.users.GetByID(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+
+	return user.TotpEnabledAt != nil, nil
+}
+
+// generateRecoveryCodes returns count random recovery codes, formatted for
+// humans as xxxx-xxxx-xxxx-xxxx, and the hashes to store for them.
+func generateRecoveryCodes(count int) ([]string, []string, error) {
+	codes := make([]string, 0, count)
+	hashes := make([]string, 0, count)
+
+	for i := 0; i < count; i++ {
+		buf := make([]byte, 10)
+		if _, err := rand.Read(buf); err != nil {
+			return nil, nil, err
+		}
+
+		code := strings.ToLower(base32.StdEncoding.EncodeToString(buf))
+
+		codes = append(codes, fmt.Sprintf("%s-%s-%s-%s", code[0:4], code[4:8], code[8:12], code[12:16]))
+		hashes = append(hashes, tokens.Hash(code))
+	}
+
+	return codes, hashes, nil
+}
+
+// normalizeCode drops the separators people type or paste along with codes.
+func normalizeCode(code string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+}
@@ -0,0 +1,37 @@
+package services
+
+import (
+	"github.com/ryuudan/golang-rest-api/ent/generated"
+	"github.com/ryuudan/golang-rest-api/src/utils/passwords"
+	"github.com/ryuudan/golang-rest-api/src/utils/render"
+)
+
+// WeakPasswordError is returned when a new password breaks the password rules.
+// Details lists every rule it breaks.
+type WeakPasswordError struct {
+	Details []render.ValidationErrorDetails
+}
+
+func (err *WeakPasswordError) Error() string {
+	return "password does not meet the password rules"
+}
+
+// checkPassword returns a WeakPasswordError when the password breaks the rules,
+// which among other things keep it from containing the user's name or email.
+func checkPassword(rules *passwords.Rules, password string, user *generated.User) error {
+	personal := []string{user.FirstName, user.LastName, user.Email}
+	if user.MiddleName != nil {
+		personal = append(personal, *user.MiddleName)
+	}
+
+	details, err := rules.Check("password", password, personal...)
+	if err != nil {
+		return err
+	}
+
+	if len(details) > 0 {
+		return &WeakPasswordError{Details: details}
+	}
+
+	return nil
+}
@@ -0,0 +1,172 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ryuudan/golang-rest-api/ent/generated/user"
+	"github.com/ryuudan/golang-rest-api/src/constants"
+	"github.com/ryuudan/golang-rest-api/src/database"
+	"github.com/ryuudan/golang-rest-api/src/internal/models"
+	"github.com/ryuudan/golang-rest-api/src/internal/repositories"
+)
+
+// Plans maps every plan tier to its limits.
+var Plans = map[user.Plan]*models.Plan{
+	user.PlanFree: {
+		HourlyLimit:  constants.FREE_PLAN_HOURLY_LIMIT,
+		DailyQuota:   constants.FREE_PLAN_DAILY_QUOTA,
+		MonthlyQuota: constants.FREE_PLAN_MONTHLY_QUOTA,
+	},
+	user.PlanPartner: {
+		HourlyLimit:  constants.PARTNER_PLAN_HOURLY_LIMIT,
+		DailyQuota:   constants.PARTNER_PLAN_DAILY_QUOTA,
+		MonthlyQuota: constants.PARTNER_PLAN_MONTHLY_QUOTA,
+	},
+}
+
+type QuotaService interface {
+	Consume(ctx context.Context, userID int) (*models.RateLimit, error)
+	Usage(ctx context.Context, userID int) (*models.UsageResponse, error)
+}
+
+type quotaService struct {
+	users      repositories.UserRepository
+	rateLimits repositories.RateLimitRepository
+	quotas     repositories.QuotaRepository
+	plans      *database.Cache[user.Plan]
+}
+
+// NewQuotaService creates the quota service. The plan of every user is read
+// through plans, whose entries must be dropped whenever a user changes.
+func NewQuotaService(users repositories.UserRepository, rateLimits repositories.RateLimitRepository, quotas repositories.QuotaRepository, plans *database.Cache[user.Plan]) QuotaService {
+	return &quotaService{
+		users:      users,
+		rateLimits: rateLimits,
+		quotas:     quotas,
+		plans:      plans,
+	}
+}
+
+// userPrincipal names what the limits of a user are counted against. Requests
+// made with any of the user's API keys count against it too, since the limits
+// belong to the plan of the user.
+func userPrincipal(userID int) string {
+	return fmt.Sprintf("user:%d", userID)
+}
+
+// planOf returns the plan of the user. It is read on every request, so it is
+// cached rather than loaded from the database each time.
+func (quota *quotaService) planOf(ctx context.Context, userID int) (user.Plan, *models.Plan, error) {
+	name, err := quota.plans.Fetch(ctx, userID, func(ctx context.Context) (user.Plan, error) {
+		found, err := quota.users.GetByID(ctx, userID)
+		if err != nil {
+			return "", err
+		}
+		return found.Plan, nil
+	})
+
+	if err != nil {
+		return "", nil, err
+	}
+
+	plan, ok := Plans[name]
+	if !ok {
+		return "", nil, fmt.Errorf("unknown plan %q", name)
+	}
+
+	return name, plan, nil
+}
+
+// Consume counts a request of the user against the hourly limit and the daily and
+// monthly quotas of their plan. The hourly limit is checked first, so that bursts
+// it rejects do not use up the quotas. The result describes the hourly limit, or
+// the quota that was used up when the request is rejected by one.
+func (quota *quotaService) Consume(ctx context.Context, userID int) (*models.RateLimit, error) {
+	_, plan, err := quota.planOf(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	principal := userPrincipal(userID)
+
+	hourly, err := quota.rateLimits.Allow(ctx, principal, plan.HourlyLimit, time.Hour)
+	if err != nil {
+		return nil, err
+	}
+
+	if !hourly.Allowed {
+		return hourly, nil
+	}
+
+	now := time.Now()
+
+	counts, err := quota.quotas.Consume(ctx, principal, plan, now)
+	if err != nil {
+		return nil, err
+	}
+
+	if counts.Allowed {
+		return hourly, nil
+	}
+
+	// Report whichever quota ran out, the monthly one outlasts the daily one
+	exhausted := &models.RateLimit{
+		Limit:  plan.DailyQuota,
+		Period: 24 * time.Hour,
+	}
+	resetsAt := counts.DailyResetsAt
+
+	if counts.Monthly >= plan.MonthlyQuota {
+		exhausted.Limit = plan.MonthlyQuota
+		exhausted.Period = counts.MonthlyResetsAt.Sub(counts.MonthlyResetsAt.AddDate(0, -1, 0))
+		resetsAt = counts.MonthlyResetsAt
+	}
+
+	exhausted.ResetAfter = resetsAt.Sub(now)
+	exhausted.RetryAfter = exhausted.ResetAfter
+
+	return exhausted, nil
+}
+
+// Usage reports how much of each limit of the user's plan is left, without
+// counting a request.
+func (quota *quotaService) Usage(ctx context.Context, userID int) (*models.UsageResponse, error) {
+	name, plan, err := quota.planOf(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	principal := userPrincipal(userID)
+	now := time.Now()
+
+	hourly, err := quota.rateLimits.Peek(ctx, principal, plan.HourlyLimit, time.Hour)
+	if err != nil {
+		return nil, err
+	}
+
+	counts, err := quota.quotas.Counts(ctx, principal, now)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.UsageResponse{
+		Plan: string(name),
+		Hourly: &models.QuotaWindow{
+			Limit:     plan.HourlyLimit,
+			Remaining: hourly.Remaining,
+			ResetsAt:  now.Add(hourly.ResetAfter).UTC(),
+		},
+		Daily: &models.QuotaWindow{
+			Limit:     plan.DailyQuota,
+			Remaining: max(plan.DailyQuota-counts.Daily, 0),
+			ResetsAt:  counts.DailyResetsAt,
+		},
+		Monthly: &models.QuotaWindow{
+			Limit:     plan.MonthlyQuota,
+			Remaining: max(plan.MonthlyQuota-counts.Monthly, 0),
+			ResetsAt:  counts.MonthlyResetsAt,
+		},
+	}, nil
+}
@@ -5,42 +5,199 @@ import (
 	"errors"
 
 	"github.com/ryuudan/golang-rest-api/ent/generated"
+	"github.com/ryuudan/golang-rest-api/src/constants"
+	"github.com/ryuudan/golang-rest-api/src/internal/models"
 	"github.com/ryuudan/golang-rest-api/src/internal/repositories"
+	"github.com/ryuudan/golang-rest-api/src/utils/passwords"
+	"github.com/ryuudan/golang-rest-api/src/utils/render"
+)
+
+var (
+	ErrEmailTaken             = errors.New("email already exists")
+	ErrEmailHeldByDeletedUser = errors.New("email belongs to a deleted user")
+	ErrPhoneNumberTaken       = errors.New("phone number already exists")
 )
 
 type UserService interface {
 	CreateUser(ctx context.Context, newUser *generated.User) (*generated.User, error)
 	GetUserByID(ctx context.Context, id int) (*generated.User, error)
+	UpdateUser(ctx context.Context, id int, update *models.UpdateUserRequest) (*generated.User, error)
+	DeleteUser(ctx context.Context, id int) error
+	RestoreUser(ctx context.Context, id int) (*generated.User, error)
+	PurgeUser(ctx context.Context, id int) error
 	GetUserByEmail(ctx context.Context, email string) (*generated.User, error)
+	HasPermission(ctx context.Context, id int, permission string) (bool, error)
+	ListUsers(ctx context.Context, params *render.QueryParams, orders []*render.OrderFields) ([]*generated.User, int, error)
+	ListUsersByCursor(ctx context.Context, params *render.QueryParams, orders []*render.OrderFields) ([]*generated.User, *render.Cursor, error)
+}
+
+// SessionRevoker revokes every token issued to a user so far, see
+// AuthService.LogoutAll.
+type SessionRevoker interface {
+	LogoutAll(ctx context.Context, userID int) error
 }
 
 type userService struct {
-	repo repositories.UserRepository
+	repo         repositories.UserRepository
+	roles        repositories.RoleRepository
+	tx           repositories.Transactor
+	verification VerificationService
+	sessions     SessionRevoker
+	hasher       *passwords.Policy
+	rules        *passwords.Rules
 }
 
-func NewUserService(repo repositories.UserRepository) UserService {
+func NewUserService(repo repositories.UserRepository, roles repositories.RoleRepository, tx repositories.Transactor, verification VerificationService, sessions SessionRevoker, hasher *passwords.Policy, rules *passwords.Rules) UserService {
 	return &userService{
-		repo: repo,
+		repo:         repo,
+		roles:        roles,
+		tx:           tx,
+		verification: verification,
+		sessions:     sessions,
+		hasher:       hasher,
+		rules:        rules,
 	}
 }
 
+// CreateUser registers a new user. The password of newUser is expected in plain
+// text, it has to meet the password rules and is replaced with its hash before
+// the user is stored.
 func (user *userService) CreateUser(ctx context.Context, newUser *generated.User) (*generated.User, error) {
 
 	// Check if the email is already taken
 	existingUser, err := user.repo.GetByEmail(ctx, newUser.Email)
 	if err == nil && existingUser != nil {
-		return nil, errors.New("email already exists")
+		return nil, ErrEmailTaken
 	}
 
-	// Create the user
-	createdUser, err := user.repo.Create(ctx, newUser)
+	if err := checkPassword(user.rules, newUser.Password, newUser); err != nil {
+		return nil, err
+	}
+
+	// New accounts start with the default role
+	defaultRole, err := user.roles.GetByName(ctx, constants.ROLE_USER)
 	if err != nil {
 		return nil, err
 	}
+	newUser.Edges.Roles = []*generated.Role{defaultRole}
+
+	// Generate a salted and hashed password
+	hash, err := user.hasher.Hash(newUser.Password)
+	if err != nil {
+		return nil, err
+	}
+	newUser.Password = hash
+
+	// Create the user and queue its verification mail, either both or neither
+	var createdUser *generated.User
+
+	err = user.tx.WithTx(ctx, func(ctx context.Context) error {
+		var err error
+		if createdUser, err = user.repo.Create(ctx, newUser); err != nil {
+			return err
+		}
+		return user.verification.SendVerification(ctx, createdUser)
+	})
+
+	if err != nil {
+		return nil, user.uniqueConflict(ctx, 0, newUser.Email, err)
+	}
 
 	return createdUser, nil
 }
 
+func (user *userService) UpdateUser(ctx context.Context, id int, update *models.UpdateUserRequest) (*generated.User, error) {
+
+	// Check if the email is taken by another user
+	existingUser, err := user.repo.GetByEmail(ctx, update.Email)
+	if err == nil && existingUser != nil && existingUser.ID != id {
+		return nil, ErrEmailTaken
+	}
+
+	current, err := user.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	var updatedUser *generated.User
+
+	err = user.tx.WithTx(ctx, func(ctx context.Context) error {
+		var err error
+
+		updatedUser, err = user.repo.Update(ctx, id, &generated.User{
+			FirstName:   update.FirstName,
+			LastName:    update.LastName,
+			MiddleName:  update.MiddleName,
+			Birthday:    update.Birthday,
+			Email:       update.Email,
+			PhoneNumber: update.PhoneNumber,
+		})
+
+		if err != nil || updatedUser.Email == current.Email {
+			return err
+		}
+
+		// A new email has to be verified again
+		if updatedUser, err = user.repo.ClearEmailVerified(ctx, id); err != nil {
+			return err
+		}
+		return user.verification.SendVerification(ctx, updatedUser)
+	})
+
+	if err != nil {
+		return nil, user.uniqueConflict(ctx, id, update.Email, err)
+	}
+
+	return updatedUser, nil
+}
+
+// uniqueConflict translates a unique constraint violation while saving the user
+// with the given ID (zero for a new user) into the error of the field that caused
+// it. Other errors are returned unchanged.
+func (user *userService) uniqueConflict(ctx context.Context, id int, email string, err error) error {
+	if !generated.IsConstraintError(err) {
+		return err
+	}
+
+	holder, lookupErr := user.repo.GetEmailHolder(ctx, email)
+
+	switch {
+	case generated.IsNotFound(lookupErr):
+		return ErrPhoneNumberTaken
+	case lookupErr != nil:
+		return lookupErr
+	case holder.ID == id:
+		return ErrPhoneNumberTaken
+	case holder.DeletedAt != nil:
+		return ErrEmailHeldByDeletedUser
+	default:
+		return ErrEmailTaken
+	}
+}
+
+// DeleteUser soft deletes a user and revokes their tokens. Tokens are revoked
+// first, so that a failure never leaves a deleted user with a valid session.
+// They stay revoked if the user is restored later on.
+func (user *userService) DeleteUser(ctx context.Context, id int) error {
+	if err := user.sessions.LogoutAll(ctx, id); err != nil {
+		return err
+	}
+	return user.repo.Delete(ctx, id)
+}
+
+func (user *userService) RestoreUser(ctx context.Context, id int) (*generated.User, error) {
+	// Additional business logic can be added here before restoring the user
+	return user.repo.Restore(ctx, id)
+}
+
+// PurgeUser permanently deletes a user and revokes their tokens, like DeleteUser.
+func (user *userService) PurgeUser(ctx context.Context, id int) error {
+	if err := user.sessions.LogoutAll(ctx, id); err != nil {
+		return err
+	}
+	return user.repo.Purge(ctx, id)
+}
+
 func (user *userService) GetUserByID(ctx context.Context, id int) (*generated.User, error) {
 	// Additional business logic can be added here before retrieving the user
 	return user.repo.GetByID(ctx, id)
@@ -50,3 +207,28 @@ func (user *userService) GetUserByEmail(ctx context.Context, email string) (*gen
 	// Additional business logic can be added here before retrieving the user
 	return user.repo.GetByEmail(ctx, email)
 }
+
+func (user *userService) ListUsers(ctx context.Context, params *render.QueryParams, orders []*render.OrderFields) ([]*generated.User, int, error) {
+	// Additional business logic can be added here before listing the users
+	return user.repo.List(ctx, params, orders)
+}
+
+func (user *userService) ListUsersByCursor(ctx context.Context, params *render.QueryParams, orders []*render.OrderFields) ([]*generated.User, *render.Cursor, error) {
+	// Additional business logic can be added here before listing the users
+	return user.repo.ListByCursor(ctx, params, orders)
+}
+
+func (user *userService) HasPermission(ctx context.Context, id int, permission string) (bool, error) {
+	permissions, err := user.repo.GetPermissions(ctx, id)
+	if err != nil {
+		return false, err
+	}
+
+	for _, granted := range permissions {
+		if granted == permission {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
@@ -0,0 +1,120 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+
+	"github.com/ryuudan/golang-rest-api/ent/generated"
+	"github.com/ryuudan/golang-rest-api/src/constants"
+	"github.com/ryuudan/golang-rest-api/src/internal/repositories"
+	"github.com/ryuudan/golang-rest-api/src/utils/tokens"
+)
+
+var ErrInvalidVerificationToken = errors.New("invalid or expired email verification token")
+
+type VerificationService interface {
+	SendVerification(ctx context.Context, user *generated.User) error
+	Verify(ctx context.Context, token string) (*generated.User, error)
+	Resend(ctx context.Context, email string) error
+}
+
+type verificationService struct {
+	users     repositories.UserRepository
+	outbox    repositories.OutboxRepository
+	secret    string
+	verifyURL string
+}
+
+// NewVerificationService creates the email verification service. verifyURL is
+// the page users are sent to from verification mails, the token is appended to
+// it as a query parameter.
+func NewVerificationService(users repositories.UserRepository, outbox repositories.OutboxRepository, secret string, verifyURL string) VerificationService {
+	return &verificationService{
+		users:     users,
+		outbox:    outbox,
+		secret:    secret,
+		verifyURL: verifyURL,
+	}
+}
+
+// SendVerification queues a verification mail for the user's current email. It
+// goes through the outbox, so when ctx carries a transaction the mail is only
+// sent if the transaction commits.
+func (verification *verificationService) SendVerification(ctx context.Context, user *generated.User) error {
+	token, err := tokens.GenerateEmailVerificationToken(user.ID, user.Email, verification.secret, constants.EMAIL_VERIFICATION_TOKEN_EXPIRATION)
+	if err != nil {
+		return err
+	}
+
+	body := fmt.Sprintf(
+		"Hi %s,\n\nUse this token to verify your email within the next %d hours:\n\n%s\n",
+		user.FirstName,
+		int(constants.EMAIL_VERIFICATION_TOKEN_EXPIRATION.Hours()),
+		token,
+	)
+
+	if verification.verifyURL != "" {
+		body += fmt.Sprintf("\nOr open %s?token=%s\n", verification.verifyURL, url.QueryEscape(token))
+	}
+
+	body += "\nIf you did not create an account, you can ignore this email.\n"
+
+	return verification.outbox.Enqueue(ctx, &generated.OutboxMessage{
+		Recipient: user.Email,
+		Subject:   "Verify your email",
+		Body:      body,
+	})
+}
+
+// Verify marks the email of the token's user as verified. Tokens issued for an
+// email the user has since changed are rejected. Verifying twice is harmless.
+func (verification *verificationService) Verify(ctx context.Context, token string) (*generated.User, error) {
+	claims, err := tokens.ParseEmailVerificationToken(token, verification.secret)
+	if err != nil {
+		return nil, ErrInvalidVerificationToken
+	}
+
+	userID, err := claims.UserID()
+	if err != nil {
+		return nil, ErrInvalidVerificationToken
+	}
+
+	user, err := verification.users.GetByID(ctx, userID)
+	if err != nil {
+		if generated.IsNotFound(err) {
+			return nil, ErrInvalidVerificationToken
+		}
+		return nil, err
+	}
+
+	if user.Email != claims.Email {
+		return nil, ErrInvalidVerificationToken
+	}
+
+	if user.EmailVerifiedAt != nil {
+		return user, nil
+	}
+
+	return verification.users.MarkEmailVerified(ctx, user.ID)
+}
+
+// Resend queues another verification mail for the user with the given email.
+// Unknown and already verified emails are silently ignored, so that the endpoint
+// does not reveal which accounts exist.
+func (verification *verificationService) Resend(ctx context.Context, email string) error {
+	user, err := verification.users.GetByEmail(ctx, email)
+	if err != nil {
+		if generated.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	if user.EmailVerifiedAt != nil {
+		return nil
+	}
+
+	return verification.SendVerification(ctx, user)
+}
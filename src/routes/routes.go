@@ -2,57 +2,195 @@ package routes
 
 import (
 	"net/http"
-	"time"
+	"os"
 
 	"github.com/go-chi/chi/v5"
-	"github.com/go-chi/httprate"
 	"github.com/redis/go-redis/v9"
 	"github.com/ryuudan/golang-rest-api/ent/generated"
+	"github.com/ryuudan/golang-rest-api/ent/generated/user"
+	"github.com/ryuudan/golang-rest-api/src/constants"
 	"github.com/ryuudan/golang-rest-api/src/database"
 	"github.com/ryuudan/golang-rest-api/src/internal/handlers"
+	"github.com/ryuudan/golang-rest-api/src/internal/middlewares"
+	"github.com/ryuudan/golang-rest-api/src/internal/models"
 	"github.com/ryuudan/golang-rest-api/src/internal/repositories"
 	"github.com/ryuudan/golang-rest-api/src/internal/services"
+	"github.com/ryuudan/golang-rest-api/src/utils/encryption"
+	"github.com/ryuudan/golang-rest-api/src/utils/mailer"
+	"github.com/ryuudan/golang-rest-api/src/utils/passwords"
 )
 
-func PublicRouter(client *generated.Client, redis_client *redis.Client) http.Handler {
+// Services holds the services used by both routers. They are built once with
+// NewServices, so that both routers share the same instances.
+type Services struct {
+	auth         services.AuthService
+	verification services.VerificationService
+	lockouts     services.LockoutService
+	mfa          services.MFAService
+}
+
+// NewServices builds the services shared by PublicRouter and PrivateRouter.
+//
+// Example:
+//
+//	shared := routes.NewServices(client, redis_client, cache, mail, cipher, hasher, rules)
+//	app.Mount("/api", routes.PrivateRouter(client, redis_client, cache, shared, hasher, rules))
+//	app.Mount("/public", routes.PublicRouter(redis_client, shared))
+func NewServices(client *generated.Client, redis_client *redis.Client, cache *database.RedisCache, mail mailer.Mailer, cipher *encryption.Cipher, hasher *passwords.Policy, rules *passwords.Rules) *Services {
+	// repositories
+	userRepo := repositories.NewUserRepository(client.User)
+	tokenRepo := repositories.NewTokenRepository(redis_client)
+	loginAttemptRepo := repositories.NewLoginAttemptRepository(redis_client)
+	lockEventRepo := repositories.NewLockEventRepository(client.LockEvent)
+	outboxRepo := repositories.NewOutboxRepository(client.OutboxMessage)
+	recoveryCodeRepo := repositories.NewRecoveryCodeRepository(client.RecoveryCode)
+	transactor := repositories.NewTransactor(client)
+
+	// services
+	lockoutService := services.NewLockoutService(loginAttemptRepo, lockEventRepo, userRepo)
+	mfaService := services.NewMFAService(userRepo, recoveryCodeRepo, tokenRepo, transactor, lockoutService, cipher)
+	authService := services.NewAuthService(userRepo, tokenRepo, lockoutService, mfaService, hasher, rules, mail, cache, os.Getenv("JWT_SECRET"), os.Getenv("PASSWORD_RESET_URL"))
+	verificationService := services.NewVerificationService(userRepo, outboxRepo, os.Getenv("JWT_SECRET"), os.Getenv("EMAIL_VERIFICATION_URL"))
+
+	return &Services{
+		auth:         authService,
+		verification: verificationService,
+		lockouts:     lockoutService,
+		mfa:          mfaService,
+	}
+}
+
+func PublicRouter(redis_client *redis.Client, shared *Services) http.Handler {
 	public := chi.NewRouter()
 
-	// 100 requests per minute
-	public.Use(httprate.LimitByIP(100, 1*time.Minute))
+	// repositories
+	rateLimitRepo := repositories.NewRateLimitRepository(redis_client)
+
+	// Shared by every instance, 100 requests per minute and client IP
+	public.Use(middlewares.RateLimit(
+		rateLimitRepo,
+		"public",
+		constants.PUBLIC_RATE_LIMIT,
+		constants.PUBLIC_RATE_LIMIT_PERIOD,
+		middlewares.KeyByIP,
+	))
+
+	// handlers
+	authHandler := handlers.NewAuthHandler(shared.auth, shared.verification)
 
 	public.Get("/", func(w http.ResponseWriter, r *http.Request) {
 		w.Write([]byte("Welcome to the public API"))
 	})
 
+	public.Route("/auth", func(r chi.Router) {
+		r.Post("/login", authHandler.Login)
+		r.Post("/mfa", authHandler.VerifyMFA)
+		r.Post("/refresh", authHandler.Refresh)
+		r.Post("/forgot-password", authHandler.ForgotPassword)
+		r.Post("/reset-password", authHandler.ResetPassword)
+		r.Get("/verify", authHandler.Verify)
+		r.Post("/verify/resend", authHandler.ResendVerification)
+	})
+
 	return public
 }
 
-func PrivateRouter(client *generated.Client, redis_client *redis.Client) http.Handler {
+func PrivateRouter(client *generated.Client, redis_client *redis.Client, cache *database.RedisCache, shared *Services, hasher *passwords.Policy, rules *passwords.Rules) http.Handler {
 	private := chi.NewRouter()
 
-	// Add authentication middleware here
-	// Add authorization middleware here
-	// Add rate limiting middleware here
-
-	// Initialize handlers
-	cache := database.Cache(redis_client)
-
 	// repositories
 	userRepo := repositories.NewUserRepository(client.User)
+	roleRepo := repositories.NewRoleRepository(client.Role)
+	rateLimitRepo := repositories.NewRateLimitRepository(redis_client)
+	quotaRepo := repositories.NewQuotaRepository(redis_client)
+	apiKeyRepo := repositories.NewAPIKeyRepository(client.APIKey)
+	transactor := repositories.NewTransactor(client)
 
 	// services
-	userService := services.NewUserService(userRepo)
+	userService := services.NewUserService(userRepo, roleRepo, transactor, shared.verification, shared.auth, hasher, rules)
+	apiKeyService := services.NewAPIKeyService(apiKeyRepo, userRepo, transactor)
+
+	// caches
+	userCache := database.NewCache[*models.UserResponse](
+		cache.Namespace(constants.USERS_CACHE_NAMESPACE),
+		constants.DEFAULT_CACHE_EXPIRATION,
+		database.WithEarlyRefresh(constants.CACHE_EARLY_REFRESH_BETA),
+	)
+	userListCache := database.NewCache[*models.UserPage](
+		cache.Namespace(constants.USERS_LIST_CACHE_NAMESPACE),
+		constants.LIST_CACHE_EXPIRATION,
+		database.WithTags(constants.USERS_CACHE_TAG),
+	)
+	planCache := database.NewCache[user.Plan](
+		cache.Namespace(constants.USER_PLANS_CACHE_NAMESPACE),
+		constants.USER_PLANS_CACHE_EXPIRATION,
+	)
+
+	quotaService := services.NewQuotaService(userRepo, rateLimitRepo, quotaRepo, planCache)
 
 	// handlers
-	userHandler := handlers.NewUserHandler(userService, cache)
+	userHandler := handlers.NewUserHandler(userService, userCache, userListCache)
+	authHandler := handlers.NewAuthHandler(shared.auth, shared.verification)
+	usageHandler := handlers.NewUsageHandler(quotaService)
+	lockoutHandler := handlers.NewLockoutHandler(shared.lockouts)
+	mfaHandler := handlers.NewMFAHandler(shared.mfa)
+	apiKeyHandler := handlers.NewAPIKeyHandler(apiKeyService)
+
+	authorizer := middlewares.NewAuthorizer(userService)
+
+	// Checked before authenticating, so that floods of bad tokens are cut off early
+	private.Use(middlewares.RateLimit(
+		rateLimitRepo,
+		"private",
+		constants.PRIVATE_RATE_LIMIT,
+		constants.PRIVATE_RATE_LIMIT_PERIOD,
+		middlewares.KeyByIP,
+	))
+	private.Use(middlewares.Authenticate(os.Getenv("JWT_SECRET"), shared.auth, apiKeyService))
+	// Limits of the authenticated user's plan, counted per API key
+	private.Use(middlewares.Quota(quotaService))
 
 	private.Get("/", func(w http.ResponseWriter, r *http.Request) {
 		w.Write([]byte("Welcome to the private API"))
 	})
 
+	private.Route("/auth", func(r chi.Router) {
+		r.Use(middlewares.RequireSession)
+
+		r.Post("/logout", authHandler.Logout)
+		r.Post("/logout-all", authHandler.LogoutAll)
+		r.Post("/mfa/enroll", mfaHandler.Enroll)
+		r.Post("/mfa/confirm", mfaHandler.Confirm)
+		r.Delete("/mfa", mfaHandler.Disable)
+	})
+
+	private.Route("/me", func(r chi.Router) {
+		r.Get("/", userHandler.Me)
+		r.Get("/usage", usageHandler.Usage)
+	})
+
+	// API keys cannot manage API keys
+	private.Route("/api-keys", func(r chi.Router) {
+		r.Use(middlewares.RequireSession)
+
+		r.Get("/", apiKeyHandler.List)
+		r.Post("/", apiKeyHandler.Create)
+		r.Delete("/{id}", apiKeyHandler.Revoke)
+	})
+
 	private.Route("/users", func(r chi.Router) {
-		r.Get("/{id}", userHandler.GetOneByID)
-		r.Post("/", userHandler.Create)
+		// Managing users takes two-factor authentication for accounts that require it
+		r.Use(middlewares.RequireMFA(shared.mfa))
+
+		r.With(authorizer.RequirePermission(constants.PERMISSION_USERS_READ)).Get("/", userHandler.List)
+		r.With(authorizer.RequirePermission(constants.PERMISSION_USERS_READ)).Get("/{id}", userHandler.GetOneByID)
+		r.With(authorizer.RequirePermission(constants.PERMISSION_USERS_WRITE)).Post("/", userHandler.Create)
+		r.With(authorizer.RequirePermission(constants.PERMISSION_USERS_WRITE)).Put("/{id}", userHandler.Update)
+		r.With(authorizer.RequirePermission(constants.PERMISSION_USERS_WRITE)).Patch("/{id}", userHandler.Patch)
+		r.With(authorizer.RequirePermission(constants.PERMISSION_USERS_DELETE)).Delete("/{id}", userHandler.Delete)
+		r.With(authorizer.RequirePermission(constants.PERMISSION_USERS_DELETE)).Post("/{id}/restore", userHandler.Restore)
+		r.With(authorizer.RequirePermission(constants.PERMISSION_USERS_PURGE)).Delete("/{id}/purge", userHandler.Purge)
+		r.With(authorizer.RequirePermission(constants.PERMISSION_USERS_WRITE)).Post("/{id}/unlock", lockoutHandler.Unlock)
 	})
 
 	return private
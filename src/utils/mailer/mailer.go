@@ -0,0 +1,204 @@
+package mailer
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"log"
+	"net"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ryuudan/golang-rest-api/src/constants"
+	"github.com/ryuudan/golang-rest-api/src/utils/tokens"
+)
+
+// Message is a plain text email to a single recipient.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// encode renders the message in the Internet Message Format, leaving out the
+// From header when from is empty.
+func (message *Message) encode(from string, date time.Time) []byte {
+	var content strings.Builder
+
+	if from != "" {
+		fmt.Fprintf(&content, "From: %s\r\n", from)
+	}
+	fmt.Fprintf(&content, "To: %s\r\n", message.To)
+	fmt.Fprintf(&content, "Subject: %s\r\n", message.Subject)
+	fmt.Fprintf(&content, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	content.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	content.WriteString(message.Body)
+
+	return []byte(content.String())
+}
+
+// Mailer delivers emails. Implementations must be safe for concurrent use.
+type Mailer interface {
+	Send(ctx context.Context, message *Message) error
+}
+
+// LogMailer writes every email to the log instead of delivering it, for local
+// development.
+type LogMailer struct{}
+
+func (LogMailer) Send(ctx context.Context, message *Message) error {
+	log.Printf("📧 Mail to %s: %s\n%s", message.To, message.Subject, message.Body)
+	return nil
+}
+
+// FileMailer stores every email as an .eml file in a directory instead of
+// delivering it, so that local mail can be opened with any mail client.
+type FileMailer struct {
+	dir string
+}
+
+func NewFileMailer(dir string) *FileMailer {
+	return &FileMailer{dir: dir}
+}
+
+func (mailer *FileMailer) Send(ctx context.Context, message *Message) error {
+	if err := os.MkdirAll(mailer.dir, 0o755); err != nil {
+		return err
+	}
+
+	suffix, err := tokens.RandomString(4)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	name := fmt.Sprintf("%s-%s.eml", now.Format("20060102T150405"), suffix)
+
+	return os.WriteFile(filepath.Join(mailer.dir, name), message.encode("", now), 0o644)
+}
+
+// SMTPMailer delivers emails through an SMTP server. Locally, a stand-in like
+// the mailpit service of docker-compose.yml catches them instead.
+type SMTPMailer struct {
+	addr string
+	from string
+	auth smtp.Auth
+}
+
+// NewSMTPMailer creates a mailer sending from the given address through the SMTP
+// server at addr (host:port). Without a username the server must not require
+// authentication.
+func NewSMTPMailer(addr string, from string, username string, password string) *SMTPMailer {
+	mailer := &SMTPMailer{addr: addr, from: from}
+
+	if username != "" {
+		host, _, _ := strings.Cut(addr, ":")
+		mailer.auth = smtp.PlainAuth("", username, password, host)
+	}
+
+	return mailer
+}
+
+// Send delivers a message like smtp.SendMail, upgrading to TLS when the server
+// supports it. The whole exchange ends by the deadline of ctx, or after
+// SMTP_TIMEOUT when ctx has none, and is aborted when ctx is cancelled, so that
+// a stalled server cannot hold up the caller.
+func (mailer *SMTPMailer) Send(ctx context.Context, message *Message) error {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(constants.SMTP_TIMEOUT)
+	}
+
+	var dialer net.Dialer
+
+	dialCtx, cancel := context.WithDeadline(ctx, deadline)
+	defer cancel()
+
+	conn, err := dialer.DialContext(dialCtx, "tcp", mailer.addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if err := conn.SetDeadline(deadline); err != nil {
+		return err
+	}
+
+	// Unblocks any read or write in progress when ctx is cancelled
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	host, _, _ := strings.Cut(mailer.addr, ":")
+
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+
+	if mailer.auth != nil {
+		if err := client.Auth(mailer.auth); err != nil {
+			return err
+		}
+	}
+
+	if err := client.Mail(mailer.from); err != nil {
+		return err
+	}
+
+	if err := client.Rcpt(message.To); err != nil {
+		return err
+	}
+
+	writer, err := client.Data()
+	if err != nil {
+		return err
+	}
+
+	if _, err := writer.Write(message.encode(mailer.from, time.Now())); err != nil {
+		return err
+	}
+
+	if err := writer.Close(); err != nil {
+		return err
+	}
+
+	return client.Quit()
+}
+
+// FromEnv picks the mailer named by MAILER, log, file or smtp. There is no
+// default, so that a deployment never silently logs mail it was meant to send.
+// The file mailer writes to MAILER_DIR, which defaults to ./mail. The SMTP mailer
+// sends from SMTP_FROM through SMTP_ADDR, authenticating with SMTP_USERNAME and
+// SMTP_PASSWORD when they are set.
+func FromEnv() (Mailer, error) {
+	switch name := os.Getenv("MAILER"); name {
+	case "":
+		return nil, fmt.Errorf("MAILER is required, use smtp to deliver mail or log or file for local development")
+	case "log":
+		return LogMailer{}, nil
+	case "file":
+		dir := os.Getenv("MAILER_DIR")
+		if dir == "" {
+			dir = "mail"
+		}
+		return NewFileMailer(dir), nil
+	case "smtp":
+		addr, from := os.Getenv("SMTP_ADDR"), os.Getenv("SMTP_FROM")
+		if addr == "" || from == "" {
+			return nil, fmt.Errorf("MAILER=smtp requires SMTP_ADDR and SMTP_FROM")
+		}
+		return NewSMTPMailer(addr, from, os.Getenv("SMTP_USERNAME"), os.Getenv("SMTP_PASSWORD")), nil
+	default:
+		return nil, fmt.Errorf("unknown MAILER %q", name)
+	}
+}
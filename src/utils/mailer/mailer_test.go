@@ -0,0 +1,202 @@
+package mailer
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+)
+
+// smtpStandIn is a minimal SMTP server that accepts every message, or never
+// answers at all when stalled.
+type smtpStandIn struct {
+	listener net.Listener
+	stalled  bool
+	received chan string
+}
+
+func newSMTPStandIn(t *testing.T, stalled bool) *smtpStandIn {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+
+	server := &smtpStandIn{listener: listener, stalled: stalled, received: make(chan string, 1)}
+	t.Cleanup(func() { listener.Close() })
+
+	go server.serve()
+
+	return server
+}
+
+func (server *smtpStandIn) serve() {
+	for {
+		conn, err := server.listener.Accept()
+		if err != nil {
+			return
+		}
+		go server.handle(conn)
+	}
+}
+
+func (server *smtpStandIn) handle(conn net.Conn) {
+	defer conn.Close()
+
+	if server.stalled {
+		// Hold the connection open without a greeting until the client gives up
+		conn.Read(make([]byte, 1))
+		return
+	}
+
+	text := textproto.NewConn(conn)
+	text.PrintfLine("220 stand-in ready")
+
+	for {
+		line, err := text.ReadLine()
+		if err != nil {
+			return
+		}
+
+		command, _, _ := strings.Cut(line, " ")
+
+		switch strings.ToUpper(command) {
+		case "EHLO", "HELO":
+			text.PrintfLine("250 stand-in")
+		case "MAIL", "RCPT", "RSET", "NOOP":
+			text.PrintfLine("250 OK")
+		case "DATA":
+			text.PrintfLine("354 go ahead")
+			data, err := text.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			server.received <- string(data)
+			text.PrintfLine("250 queued")
+		case "QUIT":
+			text.PrintfLine("221 bye")
+			return
+		default:
+			text.PrintfLine("502 not implemented")
+		}
+	}
+}
+
+func TestSMTPMailerSend(t *testing.T) {
+	server := newSMTPStandIn(t, false)
+	mailer := NewSMTPMailer(server.listener.Addr().String(), "noreply@example.com", "", "")
+
+	err := mailer.Send(context.Background(), &Message{
+		To:      "ada@example.com",
+		Subject: "Verify your email",
+		Body:    "Hello Ada",
+	})
+	if err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	select {
+	case data := <-server.received:
+		headers, err := textproto.NewReader(bufio.NewReader(strings.NewReader(data))).ReadMIMEHeader()
+		if err != nil {
+			t.Fatalf("ReadMIMEHeader: %v", err)
+		}
+		if headers.Get("To") != "ada@example.com" || headers.Get("From") != "noreply@example.com" || headers.Get("Subject") != "Verify your email" {
+			t.Errorf("headers = %v", headers)
+		}
+		if !strings.HasSuffix(strings.TrimSpace(data), "Hello Ada") {
+			t.Errorf("message = %q, want it to end with the body", data)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("the stand-in received no message")
+	}
+}
+
+func TestSMTPMailerHonoursContext(t *testing.T) {
+	tests := []struct {
+		name string
+		ctx  func() (context.Context, context.CancelFunc)
+	}{
+		{"deadline", func() (context.Context, context.CancelFunc) {
+			return context.WithTimeout(context.Background(), 100*time.Millisecond)
+		}},
+		{"cancelled", func() (context.Context, context.CancelFunc) {
+			ctx, cancel := context.WithCancel(context.Background())
+			time.AfterFunc(100*time.Millisecond, cancel)
+			return ctx, cancel
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server := newSMTPStandIn(t, true)
+			mailer := NewSMTPMailer(server.listener.Addr().String(), "noreply@example.com", "", "")
+
+			ctx, cancel := test.ctx()
+			defer cancel()
+
+			start := time.Now()
+			err := mailer.Send(ctx, &Message{To: "ada@example.com", Subject: "Hi", Body: "Hi"})
+
+			if err == nil {
+				t.Fatal("Send succeeded against a stalled server")
+			}
+			if elapsed := time.Since(start); elapsed > time.Second {
+				t.Errorf("Send gave up after %v, want it bounded by ctx", elapsed)
+			}
+		})
+	}
+}
+
+func TestSMTPMailerUnreachable(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+
+	err = NewSMTPMailer(addr, "noreply@example.com", "", "").Send(context.Background(), &Message{To: "ada@example.com"})
+
+	var opErr *net.OpError
+	if !errors.As(err, &opErr) {
+		t.Errorf("err = %v, want a dial error", err)
+	}
+}
+
+func TestFromEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		wantErr bool
+	}{
+		{"unset", map[string]string{"MAILER": ""}, true},
+		{"log", map[string]string{"MAILER": "log"}, false},
+		{"file", map[string]string{"MAILER": "file"}, false},
+		{"smtp", map[string]string{"MAILER": "smtp", "SMTP_ADDR": "localhost:1025", "SMTP_FROM": "noreply@example.com"}, false},
+		{"smtp without address", map[string]string{"MAILER": "smtp", "SMTP_ADDR": "", "SMTP_FROM": "noreply@example.com"}, true},
+		{"unknown", map[string]string{"MAILER": "carrier-pigeon"}, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			for name, value := range test.env {
+				t.Setenv(name, value)
+			}
+
+			mailer, err := FromEnv()
+
+			if (err != nil) != test.wantErr {
+				t.Fatalf("err = %v, want error %v", err, test.wantErr)
+			}
+			if !test.wantErr && mailer == nil {
+				t.Error("FromEnv returned no mailer")
+			}
+		})
+	}
+}
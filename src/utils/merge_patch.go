@@ -0,0 +1,59 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// MergePatch applies a JSON merge patch (RFC 7396) to a JSON document and returns
+// the patched document. Members of the patch replace those of the target, objects
+// are merged recursively, and members set to null are removed from the target.
+//
+// Example:
+//
+//	target := `{"first_name":"John","middle_name":"Q"}`
+//	patch := `{"first_name":"Jane","middle_name":null}`
+//	merged, err := MergePatch([]byte(target), []byte(patch))
+//	// merged will be {"first_name":"Jane"}
+func MergePatch(target []byte, patch []byte) ([]byte, error) {
+	var targetValue, patchValue interface{}
+
+	if err := decodeJSON(target, &targetValue); err != nil {
+		return nil, err
+	}
+
+	if err := decodeJSON(patch, &patchValue); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(mergeValues(targetValue, patchValue))
+}
+
+func mergeValues(target interface{}, patch interface{}) interface{} {
+	patchObject, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	targetObject, ok := target.(map[string]interface{})
+	if !ok {
+		targetObject = map[string]interface{}{}
+	}
+
+	for name, value := range patchObject {
+		if value == nil {
+			delete(targetObject, name)
+			continue
+		}
+		targetObject[name] = mergeValues(targetObject[name], value)
+	}
+
+	return targetObject
+}
+
+// decodeJSON keeps numbers as json.Number so that they survive the round trip unchanged.
+func decodeJSON(data []byte, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return decoder.Decode(v)
+}
@@ -0,0 +1,75 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+// The examples of RFC 7396, Appendix A, plus the cases user updates rely on.
+func TestMergePatch(t *testing.T) {
+	tests := []struct {
+		name   string
+		target string
+		patch  string
+		want   string
+	}{
+		{"replace member", `{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{"add member", `{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{"remove member", `{"a":"b"}`, `{"a":null}`, `{}`},
+		{"remove one of two", `{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{"array replaces", `{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{"value replaces array", `{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{"nested merge", `{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{"arrays are not merged", `{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{"non object target", `["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{"object replaces array", `["a"]`, `{"a":"b"}`, `{"a":"b"}`},
+		{"non object patch", `{"a":"foo"}`, `"bar"`, `"bar"`},
+		{"null is kept as value", `{"e":null}`, `{"a":1}`, `{"a":1,"e":null}`},
+		{"null patch", `{"a":"foo"}`, `null`, `null`},
+		{"nested into missing", `{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+		{"clear optional field", `{"first_name":"John","middle_name":"Q"}`, `{"first_name":"Jane","middle_name":null}`, `{"first_name":"Jane"}`},
+		{"large number survives", `{"id":9007199254740993}`, `{"first_name":"Jane"}`, `{"first_name":"Jane","id":9007199254740993}`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := MergePatch([]byte(test.target), []byte(test.patch))
+			if err != nil {
+				t.Fatalf("MergePatch: %v", err)
+			}
+
+			if !jsonEqual(t, got, []byte(test.want)) {
+				t.Errorf("MergePatch(%s, %s) = %s, want %s", test.target, test.patch, got, test.want)
+			}
+		})
+	}
+}
+
+func TestMergePatchInvalidJSON(t *testing.T) {
+	if _, err := MergePatch([]byte(`{"a":`), []byte(`{}`)); err == nil {
+		t.Error("expected an error for an invalid target")
+	}
+	if _, err := MergePatch([]byte(`{}`), []byte(`{"a":`)); err == nil {
+		t.Error("expected an error for an invalid patch")
+	}
+}
+
+// jsonEqual compares two documents after normalizing member order, keeping
+// numbers exact.
+func jsonEqual(t *testing.T, a, b []byte) bool {
+	t.Helper()
+
+	var left, right interface{}
+	if err := decodeJSON(a, &left); err != nil {
+		t.Fatalf("decode %s: %v", a, err)
+	}
+	if err := decodeJSON(b, &right); err != nil {
+		t.Fatalf("decode %s: %v", b, err)
+	}
+
+	leftData, _ := json.Marshal(left)
+	rightData, _ := json.Marshal(right)
+
+	return bytes.Equal(leftData, rightData)
+}
@@ -0,0 +1,127 @@
+package passwords
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/ryuudan/golang-rest-api/src/constants"
+	"golang.org/x/crypto/argon2"
+)
+
+// Argon2id hashes passwords with Argon2id, encoded in the PHC string format:
+//
+//	$argon2id$v=19$m=65536,t=3,p=2$<salt>$<hash>
+//
+// where salt and hash are base64 encoded without padding.
+type Argon2id struct {
+	Memory      uint32 // In KiB
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// argon2idParams are the parameters and outputs decoded from a PHC string.
+type argon2idParams struct {
+	memory      uint32
+	iterations  uint32
+	parallelism uint8
+	salt        []byte
+	key         []byte
+}
+
+func (hasher *Argon2id) Hash(password string) (string, error) {
+	salt := make([]byte, hasher.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, hasher.Iterations, hasher.Memory, hasher.Parallelism, hasher.KeyLength)
+
+	return fmt.Sprintf(
+		"$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version,
+		hasher.Memory,
+		hasher.Iterations,
+		hasher.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+func (hasher *Argon2id) Identifies(encoded string) bool {
+	return strings.HasPrefix(encoded, "$argon2id$")
+}
+
+func (hasher *Argon2id) Verify(password string, encoded string) (bool, error) {
+	params, err := decodeArgon2id(encoded)
+	if err != nil {
+		return false, err
+	}
+
+	key := argon2.IDKey([]byte(password), params.salt, params.iterations, params.memory, params.parallelism, uint32(len(params.key)))
+
+	return subtle.ConstantTimeCompare(key, params.key) == 1, nil
+}
+
+func (hasher *Argon2id) Weaker(encoded string) (bool, error) {
+	params, err := decodeArgon2id(encoded)
+	if err != nil {
+		return false, err
+	}
+
+	return params.memory < hasher.Memory ||
+		params.iterations < hasher.Iterations ||
+		params.parallelism < hasher.Parallelism ||
+		uint32(len(params.salt)) < hasher.SaltLength ||
+		uint32(len(params.key)) < hasher.KeyLength, nil
+}
+
+func decodeArgon2id(encoded string) (*argon2idParams, error) {
+	// The leading $ leaves an empty first part
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return nil, ErrMalformedHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return nil, ErrMalformedHash
+	}
+
+	// Formatting the parameters back rejects trailing input, signs and leading zeros
+	params := &argon2idParams{}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.memory, &params.iterations, &params.parallelism); err != nil ||
+		fmt.Sprintf("m=%d,t=%d,p=%d", params.memory, params.iterations, params.parallelism) != parts[3] {
+		return nil, ErrMalformedHash
+	}
+
+	var err error
+	if params.salt, err = base64.RawStdEncoding.Strict().DecodeString(parts[4]); err != nil {
+		return nil, ErrMalformedHash
+	}
+
+	if params.key, err = base64.RawStdEncoding.Strict().DecodeString(parts[5]); err != nil {
+		return nil, ErrMalformedHash
+	}
+
+	if !params.inRange() {
+		return nil, ErrMalformedHash
+	}
+
+	return params, nil
+}
+
+// inRange reports whether the parameters are ones Argon2id can be computed with,
+// within bounds that keep a single verification from exhausting the server.
+// argon2.IDKey panics on zero iterations or parallelism.
+func (params *argon2idParams) inRange() bool {
+	return params.iterations >= 1 && params.iterations <= constants.ARGON2_MAX_ITERATIONS &&
+		params.parallelism >= 1 &&
+		params.memory >= 8*uint32(params.parallelism) && params.memory <= constants.ARGON2_MAX_MEMORY &&
+		len(params.salt) >= constants.ARGON2_MIN_SALT_LENGTH && len(params.salt) <= constants.ARGON2_MAX_SALT_LENGTH &&
+		len(params.key) >= constants.ARGON2_MIN_KEY_LENGTH && len(params.key) <= constants.ARGON2_MAX_KEY_LENGTH
+}
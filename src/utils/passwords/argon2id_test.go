@@ -0,0 +1,86 @@
+package passwords
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestDecodeArgon2id(t *testing.T) {
+	salt := base64.RawStdEncoding.EncodeToString([]byte("0123456789abcdef"))
+	key := base64.RawStdEncoding.EncodeToString([]byte("0123456789abcdef0123456789abcdef"))
+
+	phc := func(version, params, salt, key string) string {
+		return strings.Join([]string{"", "argon2id", version, params, salt, key}, "$")
+	}
+
+	tests := []struct {
+		name    string
+		encoded string
+		want    *argon2idParams // nil when malformed
+	}{
+		{"valid", phc("v=19", "m=65536,t=3,p=2", salt, key), &argon2idParams{memory: 65536, iterations: 3, parallelism: 2}},
+		{"smallest", phc("v=19", "m=8,t=1,p=1", salt, key), &argon2idParams{memory: 8, iterations: 1, parallelism: 1}},
+		{"other algorithm", strings.Replace(phc("v=19", "m=65536,t=3,p=2", salt, key), "argon2id", "argon2i", 1), nil},
+		{"missing part", "$argon2id$v=19$m=65536,t=3,p=2$" + salt, nil},
+		{"extra part", phc("v=19", "m=65536,t=3,p=2", salt, key) + "$", nil},
+		{"other version", phc("v=16", "m=65536,t=3,p=2", salt, key), nil},
+		{"parameters out of order", phc("v=19", "t=3,m=65536,p=2", salt, key), nil},
+		{"trailing parameter", phc("v=19", "m=65536,t=3,p=2,x=1", salt, key), nil},
+		{"signed parameter", phc("v=19", "m=+65536,t=3,p=2", salt, key), nil},
+		{"zero iterations", phc("v=19", "m=65536,t=0,p=2", salt, key), nil},
+		{"too many iterations", phc("v=19", "m=65536,t=1000,p=2", salt, key), nil},
+		{"zero parallelism", phc("v=19", "m=65536,t=3,p=0", salt, key), nil},
+		{"parallelism overflows", phc("v=19", "m=65536,t=3,p=256", salt, key), nil},
+		{"less than 8 KiB per lane", phc("v=19", "m=15,t=3,p=2", salt, key), nil},
+		{"too much memory", phc("v=19", "m=4294967295,t=3,p=2", salt, key), nil},
+		{"salt not base64", phc("v=19", "m=65536,t=3,p=2", "not base64!", key), nil},
+		{"padded salt", phc("v=19", "m=65536,t=3,p=2", salt+"==", key), nil},
+		{"short salt", phc("v=19", "m=65536,t=3,p=2", base64.RawStdEncoding.EncodeToString([]byte("salt")), key), nil},
+		{"empty key", phc("v=19", "m=65536,t=3,p=2", salt, ""), nil},
+		{"long key", phc("v=19", "m=65536,t=3,p=2", salt, key+key+key), nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			params, err := decodeArgon2id(test.encoded)
+
+			if test.want == nil {
+				if !errors.Is(err, ErrMalformedHash) {
+					t.Errorf("err = %v, want ErrMalformedHash", err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("decodeArgon2id: %v", err)
+			}
+			if params.memory != test.want.memory || params.iterations != test.want.iterations || params.parallelism != test.want.parallelism {
+				t.Errorf("got m=%d,t=%d,p=%d, want m=%d,t=%d,p=%d", params.memory, params.iterations, params.parallelism,
+					test.want.memory, test.want.iterations, test.want.parallelism)
+			}
+			if len(params.salt) != 16 || len(params.key) != 32 {
+				t.Errorf("got a %d byte salt and %d byte key, want 16 and 32", len(params.salt), len(params.key))
+			}
+		})
+	}
+}
+
+func TestArgon2idVerifyRejectsMalformedHashes(t *testing.T) {
+	hasher := &Argon2id{Memory: 64, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+
+	encoded, err := hasher.Hash("correct horse battery staple")
+	if err != nil {
+		t.Fatalf("Hash: %v", err)
+	}
+
+	// Parameters argon2.IDKey would panic on
+	for _, params := range []string{"m=64,t=0,p=1", "m=64,t=1,p=0"} {
+		malformed := strings.Replace(encoded, "m=64,t=1,p=1", params, 1)
+
+		if _, err := hasher.Verify("correct horse battery staple", malformed); !errors.Is(err, ErrMalformedHash) {
+			t.Errorf("Verify with %s: err = %v, want ErrMalformedHash", params, err)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package passwords
+
+import (
+	"errors"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Bcrypt hashes passwords with bcrypt. Its hashes keep their own modular crypt
+// format, $2a$<cost>$<salt and hash>, which predates the PHC string format but
+// encodes the algorithm and cost the same way.
+type Bcrypt struct {
+	Cost int
+}
+
+func (hasher *Bcrypt) Hash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), hasher.Cost)
+	if err != nil {
+		return "", err
+	}
+
+	return string(hash), nil
+}
+
+func (hasher *Bcrypt) Identifies(encoded string) bool {
+	return strings.HasPrefix(encoded, "$2a$") || strings.HasPrefix(encoded, "$2b$") || strings.HasPrefix(encoded, "$2y$")
+}
+
+func (hasher *Bcrypt) Verify(password string, encoded string) (bool, error) {
+	err := bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return false, nil
+	}
+
+	return err == nil, err
+}
+
+func (hasher *Bcrypt) Weaker(encoded string) (bool, error) {
+	cost, err := bcrypt.Cost([]byte(encoded))
+	if err != nil {
+		return false, err
+	}
+
+	return cost < hasher.Cost, nil
+}
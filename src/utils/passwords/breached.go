@@ -0,0 +1,142 @@
+package passwords
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"io"
+	"os"
+	"strings"
+)
+
+// maxCorpusLine bounds the length of a line of a breached password corpus, a
+// SHA-1 hash and a count.
+const maxCorpusLine = 128
+
+var ErrMalformedCorpus = errors.New("malformed breached password corpus")
+
+// BreachedCorpus looks passwords up in a local file of breached password hashes,
+// so that checking them never sends anything, not even a hash prefix, to a third
+// party. The file holds one uppercase hex SHA-1 hash per line, optionally followed
+// by :<count>, sorted by hash. That is the format of the Have I Been Pwned corpus
+// as fetched range by range from its k-anonymity API, e.g. with its
+// PwnedPasswordsDownloader, so the full corpus can be dropped in for the small
+// one that ships with the deployment.
+//
+// The file is binary searched on every lookup instead of being loaded, since the
+// full corpus is tens of gigabytes.
+type BreachedCorpus struct {
+	file *os.File
+	size int64
+}
+
+// OpenBreachedCorpus opens the corpus at path. It stays open for the lifetime of
+// the process, lookups are safe for concurrent use.
+func OpenBreachedCorpus(path string) (*BreachedCorpus, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return &BreachedCorpus{file: file, size: info.Size()}, nil
+}
+
+// Contains reports whether the password is in the corpus.
+func (corpus *BreachedCorpus) Contains(password string) (bool, error) {
+	sum := sha1.Sum([]byte(password))
+	target := []byte(strings.ToUpper(hex.EncodeToString(sum[:])))
+
+	// Invariant: if the hash is in the file, its line starts in [low, high)
+	low, high := int64(0), corpus.size
+
+	for low < high {
+		middle := low + (high-low)/2
+
+		start, err := corpus.lineStart(middle)
+		if err != nil {
+			return false, err
+		}
+
+		if start >= high {
+			high = middle
+			continue
+		}
+
+		line, err := corpus.lineAt(start)
+		if err != nil {
+			return false, err
+		}
+
+		hash, _, _ := bytes.Cut(line, []byte(":"))
+
+		switch bytes.Compare(bytes.ToUpper(bytes.TrimSpace(hash)), target) {
+		case 0:
+			return true, nil
+		case -1:
+			low = start + int64(len(line)) + 1
+		default:
+			high = middle
+		}
+	}
+
+	return false, nil
+}
+
+// lineStart returns the offset of the first line starting at or after offset,
+// or the size of the file when there is none.
+func (corpus *BreachedCorpus) lineStart(offset int64) (int64, error) {
+	if offset == 0 {
+		return 0, nil
+	}
+
+	buf, err := corpus.read(offset - 1)
+	if err != nil {
+		return 0, err
+	}
+
+	newline := bytes.IndexByte(buf, '\n')
+	if newline < 0 {
+		if offset-1+int64(len(buf)) >= corpus.size {
+			return corpus.size, nil
+		}
+		return 0, ErrMalformedCorpus
+	}
+
+	return offset + int64(newline), nil
+}
+
+// lineAt returns the line starting at offset, without its line break.
+func (corpus *BreachedCorpus) lineAt(offset int64) ([]byte, error) {
+	buf, err := corpus.read(offset)
+	if err != nil {
+		return nil, err
+	}
+
+	if newline := bytes.IndexByte(buf, '\n'); newline >= 0 {
+		return buf[:newline], nil
+	}
+
+	if offset+int64(len(buf)) < corpus.size {
+		return nil, ErrMalformedCorpus
+	}
+
+	return buf, nil
+}
+
+func (corpus *BreachedCorpus) read(offset int64) ([]byte, error) {
+	buf := make([]byte, maxCorpusLine)
+
+	n, err := corpus.file.ReadAt(buf, offset)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+
+	return buf[:n], nil
+}
@@ -0,0 +1,102 @@
+package passwords
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// writeCorpus writes the hashes of the passwords, sorted, to a corpus file in
+// the format of the Have I Been Pwned corpus and opens it.
+func writeCorpus(t *testing.T, passwords []string, format func(hash string, i int) string) *BreachedCorpus {
+	t.Helper()
+
+	hashes := make([]string, len(passwords))
+	for i, password := range passwords {
+		sum := sha1.Sum([]byte(password))
+		hashes[i] = strings.ToUpper(hex.EncodeToString(sum[:]))
+	}
+	sort.Strings(hashes)
+
+	var content strings.Builder
+	for i, hash := range hashes {
+		content.WriteString(format(hash, i))
+	}
+
+	path := filepath.Join(t.TempDir(), "breached.txt")
+	if err := os.WriteFile(path, []byte(content.String()), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	corpus, err := OpenBreachedCorpus(path)
+	if err != nil {
+		t.Fatalf("OpenBreachedCorpus: %v", err)
+	}
+	t.Cleanup(func() { corpus.file.Close() })
+
+	return corpus
+}
+
+func TestBreachedCorpusContains(t *testing.T) {
+	breached := make([]string, 500)
+	for i := range breached {
+		breached[i] = fmt.Sprintf("breached-%d", i)
+	}
+
+	tests := []struct {
+		name      string
+		passwords []string
+		format    func(hash string, i int) string
+	}{
+		{"with counts", breached, func(hash string, i int) string { return fmt.Sprintf("%s:%d\n", hash, i+1) }},
+		{"without counts", breached, func(hash string, i int) string { return hash + "\n" }},
+		{"crlf line breaks", breached, func(hash string, i int) string { return fmt.Sprintf("%s:%d\r\n", hash, i+1) }},
+		{"lowercase hashes", breached, func(hash string, i int) string { return strings.ToLower(hash) + "\n" }},
+		{"no final line break", breached, func(hash string, i int) string {
+			if i == len(breached)-1 {
+				return hash + ":1"
+			}
+			return hash + ":1\n"
+		}},
+		{"counts of varying length", breached, func(hash string, i int) string {
+			return fmt.Sprintf("%s:%d\n", hash, 1<<(i%40))
+		}},
+		{"single line", breached[:1], func(hash string, i int) string { return hash + ":7\n" }},
+		{"empty", nil, nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			corpus := writeCorpus(t, test.passwords, test.format)
+
+			// Every line is found, which covers the first and last ones
+			for _, password := range test.passwords {
+				if found, err := corpus.Contains(password); err != nil || !found {
+					t.Fatalf("Contains(%q) = %v, %v, want it found", password, found, err)
+				}
+			}
+
+			for _, password := range []string{"", "correct horse battery staple", "breached-500", "Breached-1"} {
+				if found, err := corpus.Contains(password); err != nil || found {
+					t.Errorf("Contains(%q) = %v, %v, want it not found", password, found, err)
+				}
+			}
+		})
+	}
+}
+
+func TestBreachedCorpusMalformed(t *testing.T) {
+	corpus := writeCorpus(t, []string{"a", "b", "c"}, func(hash string, i int) string {
+		return hash + ":" + strings.Repeat("1", maxCorpusLine) + "\n"
+	})
+
+	if _, err := corpus.Contains("correct horse battery staple"); !errors.Is(err, ErrMalformedCorpus) {
+		t.Errorf("err = %v, want ErrMalformedCorpus", err)
+	}
+}
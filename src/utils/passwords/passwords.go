@@ -0,0 +1,153 @@
+package passwords
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/ryuudan/golang-rest-api/src/constants"
+)
+
+var ErrUnknownHash = errors.New("unknown password hash format")
+var ErrMalformedHash = errors.New("malformed password hash")
+
+// Hasher hashes passwords with one algorithm. Hashes are encoded with their
+// algorithm and parameters, so that they can be verified after the parameters
+// of the hasher changed.
+type Hasher interface {
+	Hash(password string) (string, error)
+
+	// Identifies reports whether the encoded hash is of the hasher's algorithm.
+	Identifies(encoded string) bool
+
+	// Verify reports whether the password matches an encoded hash of the
+	// hasher's algorithm.
+	Verify(password string, encoded string) (bool, error)
+
+	// Weaker reports whether an encoded hash of the hasher's algorithm was made
+	// with weaker parameters than the hasher's.
+	Weaker(encoded string) (bool, error)
+}
+
+// Policy hashes new passwords with the preferred hasher and verifies hashes of
+// every known algorithm, so that the preferred one can change at any time.
+type Policy struct {
+	preferred Hasher
+	known     []Hasher
+}
+
+// NewPolicy creates a policy hashing with preferred, which also verifies hashes
+// of the legacy hashers.
+func NewPolicy(preferred Hasher, legacy ...Hasher) *Policy {
+	return &Policy{
+		preferred: preferred,
+		known:     append([]Hasher{preferred}, legacy...),
+	}
+}
+
+// Hash hashes the password with the preferred hasher.
+func (policy *Policy) Hash(password string) (string, error) {
+	return policy.preferred.Hash(password)
+}
+
+// Verify reports whether the password matches the encoded hash, and if so
+// whether the hash should be replaced, because it is not of the preferred
+// algorithm or was made with weaker parameters.
+func (policy *Policy) Verify(password string, encoded string) (match bool, rehash bool, err error) {
+	for _, hasher := range policy.known {
+		if !hasher.Identifies(encoded) {
+			continue
+		}
+
+		match, err := hasher.Verify(password, encoded)
+		if err != nil || !match {
+			return false, false, err
+		}
+
+		if hasher != policy.preferred {
+			return true, true, nil
+		}
+
+		weaker, err := hasher.Weaker(encoded)
+		return true, weaker, err
+	}
+
+	return false, false, ErrUnknownHash
+}
+
+// FromEnv creates the policy named by PASSWORD_HASHER, argon2id (the default) or
+// bcrypt. Argon2id takes its parameters from ARGON2_MEMORY (in KiB),
+// ARGON2_ITERATIONS and ARGON2_PARALLELISM, bcrypt its cost from BCRYPT_COST.
+// Either way, hashes of both algorithms are verified.
+func FromEnv() (*Policy, error) {
+	argon2id := &Argon2id{
+		Memory:      constants.ARGON2_MEMORY,
+		Iterations:  constants.ARGON2_ITERATIONS,
+		Parallelism: constants.ARGON2_PARALLELISM,
+		SaltLength:  constants.ARGON2_SALT_LENGTH,
+		KeyLength:   constants.ARGON2_KEY_LENGTH,
+	}
+	bcrypt := &Bcrypt{Cost: constants.BCRYPT_COST}
+
+	if err := uintFromEnv("ARGON2_MEMORY", &argon2id.Memory); err != nil {
+		return nil, err
+	}
+
+	if err := uintFromEnv("ARGON2_ITERATIONS", &argon2id.Iterations); err != nil {
+		return nil, err
+	}
+
+	parallelism := uint32(argon2id.Parallelism)
+	if err := uintFromEnv("ARGON2_PARALLELISM", &parallelism); err != nil {
+		return nil, err
+	}
+
+	if parallelism > 255 {
+		return nil, fmt.Errorf("invalid ARGON2_PARALLELISM %d, at most 255", parallelism)
+	}
+	argon2id.Parallelism = uint8(parallelism)
+
+	// Hashes out of these bounds are rejected as malformed, so they must not be made
+	if argon2id.Memory > constants.ARGON2_MAX_MEMORY || argon2id.Memory < 8*uint32(argon2id.Parallelism) {
+		return nil, fmt.Errorf("invalid ARGON2_MEMORY %d, must be between 8 KiB per lane and %d", argon2id.Memory, constants.ARGON2_MAX_MEMORY)
+	}
+
+	if argon2id.Iterations > constants.ARGON2_MAX_ITERATIONS {
+		return nil, fmt.Errorf("invalid ARGON2_ITERATIONS %d, at most %d", argon2id.Iterations, constants.ARGON2_MAX_ITERATIONS)
+	}
+
+	if value := os.Getenv("BCRYPT_COST"); value != "" {
+		cost, err := strconv.Atoi(value)
+		if err != nil || cost < 4 || cost > 31 {
+			return nil, fmt.Errorf("invalid BCRYPT_COST %q, must be between 4 and 31", value)
+		}
+		bcrypt.Cost = cost
+	}
+
+	switch name := os.Getenv("PASSWORD_HASHER"); name {
+	case "", "argon2id":
+		return NewPolicy(argon2id, bcrypt), nil
+	case "bcrypt":
+		return NewPolicy(bcrypt, argon2id), nil
+	default:
+		return nil, fmt.Errorf("unknown PASSWORD_HASHER %q", name)
+	}
+}
+
+// uintFromEnv overrides target with the positive integer in the environment
+// variable name, if it is set.
+func uintFromEnv(name string, target *uint32) error {
+	value := os.Getenv(name)
+	if value == "" {
+		return nil
+	}
+
+	parsed, err := strconv.ParseUint(value, 10, 32)
+	if err != nil || parsed == 0 {
+		return fmt.Errorf("invalid %s %q", name, value)
+	}
+
+	*target = uint32(parsed)
+	return nil
+}
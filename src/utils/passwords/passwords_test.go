@@ -0,0 +1,141 @@
+package passwords
+
+import (
+	"errors"
+	"testing"
+)
+
+// Parameters cheap enough for tests.
+func testArgon2id(iterations uint32) *Argon2id {
+	return &Argon2id{Memory: 64, Iterations: iterations, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+}
+
+func TestPolicyVerify(t *testing.T) {
+	const password = "correct horse battery staple"
+
+	tests := []struct {
+		name       string
+		policy     *Policy
+		hashedWith Hasher // nil to verify encoded as is
+		encoded    string
+		password   string
+		match      bool
+		rehash     bool
+		err        error
+	}{
+		{
+			name:       "preferred algorithm and parameters",
+			policy:     NewPolicy(testArgon2id(2), &Bcrypt{Cost: 4}),
+			hashedWith: testArgon2id(2),
+			password:   password,
+			match:      true,
+		},
+		{
+			name:       "stronger parameters",
+			policy:     NewPolicy(testArgon2id(2), &Bcrypt{Cost: 4}),
+			hashedWith: testArgon2id(3),
+			password:   password,
+			match:      true,
+		},
+		{
+			name:       "weaker parameters",
+			policy:     NewPolicy(testArgon2id(2), &Bcrypt{Cost: 4}),
+			hashedWith: testArgon2id(1),
+			password:   password,
+			match:      true,
+			rehash:     true,
+		},
+		{
+			name:       "legacy algorithm",
+			policy:     NewPolicy(testArgon2id(2), &Bcrypt{Cost: 4}),
+			hashedWith: &Bcrypt{Cost: 4},
+			password:   password,
+			match:      true,
+			rehash:     true,
+		},
+		{
+			name:       "lower bcrypt cost",
+			policy:     NewPolicy(&Bcrypt{Cost: 5}, testArgon2id(2)),
+			hashedWith: &Bcrypt{Cost: 4},
+			password:   password,
+			match:      true,
+			rehash:     true,
+		},
+		{
+			name:       "wrong password is never rehashed",
+			policy:     NewPolicy(testArgon2id(2), &Bcrypt{Cost: 4}),
+			hashedWith: &Bcrypt{Cost: 4},
+			password:   "wrong password",
+		},
+		{
+			name:     "unknown algorithm",
+			policy:   NewPolicy(testArgon2id(2), &Bcrypt{Cost: 4}),
+			encoded:  "$scrypt$ln=15,r=8,p=1$c2FsdA$aGFzaA",
+			password: password,
+			err:      ErrUnknownHash,
+		},
+		{
+			name:     "malformed hash",
+			policy:   NewPolicy(testArgon2id(2), &Bcrypt{Cost: 4}),
+			encoded:  "$argon2id$v=19$m=64,t=0,p=1$MDEyMzQ1Njc4OWFiY2RlZg$MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY",
+			password: password,
+			err:      ErrMalformedHash,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			encoded := test.encoded
+			if test.hashedWith != nil {
+				var err error
+				if encoded, err = test.hashedWith.Hash(password); err != nil {
+					t.Fatalf("Hash: %v", err)
+				}
+			}
+
+			match, rehash, err := test.policy.Verify(test.password, encoded)
+
+			if !errors.Is(err, test.err) {
+				t.Fatalf("err = %v, want %v", err, test.err)
+			}
+			if match != test.match || rehash != test.rehash {
+				t.Errorf("Verify = %v, %v, want %v, %v", match, rehash, test.match, test.rehash)
+			}
+		})
+	}
+}
+
+func TestFromEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		wantErr bool
+	}{
+		{"defaults", map[string]string{}, false},
+		{"bcrypt", map[string]string{"PASSWORD_HASHER": "bcrypt", "BCRYPT_COST": "12"}, false},
+		{"unknown hasher", map[string]string{"PASSWORD_HASHER": "md5"}, true},
+		{"bcrypt cost too low", map[string]string{"BCRYPT_COST": "3"}, true},
+		{"zero iterations", map[string]string{"ARGON2_ITERATIONS": "0"}, true},
+		{"too many iterations", map[string]string{"ARGON2_ITERATIONS": "1000"}, true},
+		{"parallelism overflows", map[string]string{"ARGON2_PARALLELISM": "256"}, true},
+		{"too much memory", map[string]string{"ARGON2_MEMORY": "4294967295"}, true},
+		{"less than 8 KiB per lane", map[string]string{"ARGON2_MEMORY": "15", "ARGON2_PARALLELISM": "2"}, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			for _, name := range []string{"PASSWORD_HASHER", "BCRYPT_COST", "ARGON2_MEMORY", "ARGON2_ITERATIONS", "ARGON2_PARALLELISM"} {
+				t.Setenv(name, test.env[name])
+			}
+
+			policy, err := FromEnv()
+
+			if (err != nil) != test.wantErr {
+				t.Fatalf("err = %v, want error %v", err, test.wantErr)
+			}
+			if !test.wantErr && policy == nil {
+				t.Error("FromEnv returned no policy")
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package passwords
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ryuudan/golang-rest-api/src/constants"
+	"github.com/ryuudan/golang-rest-api/src/utils/render"
+)
+
+// Rules are the requirements new passwords have to meet.
+type Rules struct {
+	MinLength int
+	MaxLength int
+	MinScore  int             // See Score
+	Breached  *BreachedCorpus // The check is skipped when nil
+}
+
+// Check returns a validation error detail of the field for every rule the
+// password breaks, or none when it meets them all. personal holds what the
+// password must not contain, like the name and email of its user.
+func (rules *Rules) Check(field string, password string, personal ...string) ([]render.ValidationErrorDetails, error) {
+	var details []render.ValidationErrorDetails
+
+	fail := func(message string) {
+		details = append(details, render.ValidationErrorDetails{Field: field, Message: field + " " + message})
+	}
+
+	length := utf8.RuneCountInString(password)
+
+	if length < rules.MinLength {
+		fail(fmt.Sprintf("should be at least %d characters", rules.MinLength))
+	}
+
+	// Also keeps hashing and scoring cheap
+	if rules.MaxLength > 0 && length > rules.MaxLength {
+		fail(fmt.Sprintf("should be at most %d characters", rules.MaxLength))
+		return details, nil
+	}
+
+	lowered := strings.ToLower(password)
+	for _, value := range personal {
+		if containsAny(lowered, PersonalTokens(value)) {
+			fail("should not contain your name or email")
+			break
+		}
+	}
+
+	if Score(password, personal...) < rules.MinScore {
+		fail("is too easy to guess, try a longer one or a few uncommon words")
+	}
+
+	if rules.Breached != nil {
+		breached, err := rules.Breached.Contains(password)
+		if err != nil {
+			return nil, err
+		}
+
+		if breached {
+			fail("appeared in a data breach, please choose another one")
+		}
+	}
+
+	return details, nil
+}
+
+func containsAny(password string, words []string) bool {
+	for _, word := range words {
+		if strings.Contains(password, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// RulesFromEnv creates the password rules from PASSWORD_MIN_LENGTH and
+// PASSWORD_MIN_SCORE, and opens the breached password corpus at
+// PASSWORD_BREACHED_FILE. Without PASSWORD_BREACHED_FILE, the corpus shipping
+// with the deployment is used, if it is there.
+func RulesFromEnv() (*Rules, error) {
+	rules := &Rules{
+		MinLength: constants.PASSWORD_MIN_LENGTH,
+		MaxLength: constants.PASSWORD_MAX_LENGTH,
+		MinScore:  constants.PASSWORD_MIN_SCORE,
+	}
+
+	if value := os.Getenv("PASSWORD_MIN_LENGTH"); value != "" {
+		length, err := strconv.Atoi(value)
+		if err != nil || length < 1 || length > rules.MaxLength {
+			return nil, fmt.Errorf("invalid PASSWORD_MIN_LENGTH %q, must be between 1 and %d", value, rules.MaxLength)
+		}
+		rules.MinLength = length
+	}
+
+	if value := os.Getenv("PASSWORD_MIN_SCORE"); value != "" {
+		score, err := strconv.Atoi(value)
+		if err != nil || score < 0 || score > 4 {
+			return nil, fmt.Errorf("invalid PASSWORD_MIN_SCORE %q, must be between 0 and 4", value)
+		}
+		rules.MinScore = score
+	}
+
+	path := os.Getenv("PASSWORD_BREACHED_FILE")
+	if path == "" {
+		path = constants.PASSWORD_BREACHED_FILE
+	}
+
+	corpus, err := OpenBreachedCorpus(path)
+	if err != nil {
+		// Only a corpus that was asked for explicitly has to be there
+		if errors.Is(err, fs.ErrNotExist) && os.Getenv("PASSWORD_BREACHED_FILE") == "" {
+			log.Printf("No breached password corpus at %s, new passwords are not checked against one", path)
+			return rules, nil
+		}
+		return nil, fmt.Errorf("opening the breached password corpus: %w", err)
+	}
+
+	rules.Breached = corpus
+
+	return rules, nil
+}
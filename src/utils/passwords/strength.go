@@ -0,0 +1,215 @@
+package passwords
+
+import (
+	_ "embed"
+	"math"
+	"strings"
+	"unicode"
+)
+
+// commonPasswords lists frequently used passwords and words, most common first.
+//
+//go:embed common_passwords.txt
+var commonPasswords string
+
+// commonRanks maps every common password to its rank, starting at 1.
+var commonRanks = func() map[string]int {
+	ranks := make(map[string]int)
+	for i, word := range strings.Fields(commonPasswords) {
+		if _, ok := ranks[word]; !ok {
+			ranks[word] = i + 1
+		}
+	}
+	return ranks
+}()
+
+// keyboardRows are sequences of adjacent keys people walk along.
+var keyboardRows = []string{"qwertyuiop", "asdfghjkl", "zxcvbnm", "1234567890", "1qaz2wsx3edc", "qazwsxedc"}
+
+// leetSubstitutions undoes the usual character substitutions.
+var leetSubstitutions = strings.NewReplacer("@", "a", "4", "a", "3", "e", "1", "i", "!", "i", "0", "o", "$", "s", "5", "s", "7", "t")
+
+// maxScoredLength caps how much of a password is scored, longer ones score the
+// highest anyway.
+const maxScoredLength = 64
+
+// Score rates how hard a password is to guess from 0 (trivial) to 4 (strong),
+// on the same scale as zxcvbn. Like zxcvbn, it estimates the number of guesses
+// an attacker needs, finding the cheapest way to build the password from
+// common passwords, the personal words given, repeats, sequences, keyboard
+// walks and, for the rest, single characters guessed by brute force.
+func Score(password string, personal ...string) int {
+	guesses := estimateGuesses(password, personalWords(personal))
+
+	switch magnitude := math.Log10(guesses); {
+	case magnitude < 3:
+		return 0
+	case magnitude < 6:
+		return 1
+	case magnitude < 8:
+		return 2
+	case magnitude < 10:
+		return 3
+	default:
+		return 4
+	}
+}
+
+// estimateGuesses finds the cheapest split of the password into patterns. best[j]
+// holds the fewest guesses needed for the first j characters.
+func estimateGuesses(password string, personal map[string]bool) float64 {
+	chars := []rune(password)
+	if len(chars) > maxScoredLength {
+		chars = chars[:maxScoredLength]
+	}
+
+	best := make([]float64, len(chars)+1)
+	best[0] = 1
+
+	for j := 1; j <= len(chars); j++ {
+		best[j] = best[j-1] * cardinality(chars[j-1])
+
+		for i := 0; i < j-1; i++ {
+			if guesses := patternGuesses(chars[i:j], personal); guesses > 0 {
+				best[j] = math.Min(best[j], best[i]*guesses)
+			}
+		}
+	}
+
+	return best[len(chars)]
+}
+
+// patternGuesses returns the guesses needed for a run of at least two characters
+// that matches a known pattern, or 0 when it matches none.
+func patternGuesses(chars []rune, personal map[string]bool) float64 {
+	word := strings.ToLower(string(chars))
+	variations := 1.0
+	if word != string(chars) {
+		variations = 2 // Capitalized or mixed case versions of the same word
+	}
+
+	if personal[word] {
+		return variations
+	}
+
+	if rank, ok := commonRanks[word]; ok {
+		return float64(rank) * variations
+	}
+
+	if unleet := leetSubstitutions.Replace(word); unleet != word {
+		if personal[unleet] {
+			return 2 * variations
+		}
+		if rank, ok := commonRanks[unleet]; ok {
+			return float64(rank) * 2 * variations
+		}
+	}
+
+	if len(chars) < 3 {
+		return 0
+	}
+
+	if isRepeat(chars) {
+		return cardinality(chars[0]) * float64(len(chars))
+	}
+
+	if isSequence(chars) {
+		return 26 * float64(len(chars))
+	}
+
+	for _, row := range keyboardRows {
+		if len(chars) >= 4 && (strings.Contains(row, word) || strings.Contains(reverse(row), word)) {
+			return 10 * float64(len(chars))
+		}
+	}
+
+	return 0
+}
+
+// personalWords returns the lowercased personal values and the words in them.
+func personalWords(personal []string) map[string]bool {
+	words := make(map[string]bool)
+
+	for _, value := range personal {
+		for _, word := range PersonalTokens(value) {
+			words[word] = true
+		}
+	}
+
+	return words
+}
+
+// PersonalTokens splits a personal value, like a name or an email, into the
+// lowercased words a password should not be built from. Of emails only the local
+// part counts, and words shorter than three characters are left out.
+func PersonalTokens(value string) []string {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if local, _, found := strings.Cut(value, "@"); found {
+		value = local
+	}
+
+	tokens := []string{}
+	if len([]rune(value)) >= 3 {
+		tokens = append(tokens, value)
+	}
+
+	words := strings.FieldsFunc(value, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	for _, word := range words {
+		if len([]rune(word)) >= 3 && word != value {
+			tokens = append(tokens, word)
+		}
+	}
+
+	return tokens
+}
+
+// cardinality is the number of characters a brute force attack tries for a
+// character of the same class.
+func cardinality(char rune) float64 {
+	switch {
+	case unicode.IsDigit(char):
+		return 10
+	case unicode.IsLower(char) || unicode.IsUpper(char):
+		return 26
+	case char < unicode.MaxASCII:
+		return 33
+	default:
+		return 100
+	}
+}
+
+func isRepeat(chars []rune) bool {
+	for _, char := range chars[1:] {
+		if char != chars[0] {
+			return false
+		}
+	}
+	return true
+}
+
+// isSequence reports whether every character follows the previous one by the
+// same step of one, like abcd or 4321.
+func isSequence(chars []rune) bool {
+	step := unicode.ToLower(chars[1]) - unicode.ToLower(chars[0])
+	if step != 1 && step != -1 {
+		return false
+	}
+
+	for i := 2; i < len(chars); i++ {
+		if unicode.ToLower(chars[i])-unicode.ToLower(chars[i-1]) != step {
+			return false
+		}
+	}
+	return true
+}
+
+func reverse(value string) string {
+	chars := []rune(value)
+	for i, j := 0, len(chars)-1; i < j; i, j = i+1, j-1 {
+		chars[i], chars[j] = chars[j], chars[i]
+	}
+	return string(chars)
+}
@@ -0,0 +1,66 @@
+package passwords
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestScore(t *testing.T) {
+	personal := []string{"Ada Lovelace", "ada.lovelace@example.com"}
+
+	tests := []struct {
+		name     string
+		password string
+		personal []string
+		want     int
+	}{
+		{"empty", "", nil, 0},
+		{"common password", "password", nil, 0},
+		{"capitalized and leet common password", "P@ssw0rd", nil, 0},
+		{"common password and digit", "Password1", nil, 0},
+		{"repeat", "aaaaaaaaaaaa", nil, 0},
+		{"sequence", "abcdefgh", nil, 0},
+		{"keyboard walk", "qwertyuiop", nil, 0},
+		{"keyboard walk and sequence", "qwerty123456", nil, 0},
+		{"common words", "shadowmaster", nil, 0},
+		{"common password and two digits", "monkey42", nil, 1},
+		{"common password and suffix", "dragonfly7", nil, 2},
+		{"short random", "zq8rw", nil, 2},
+		{"longer random", "zq8rw!", nil, 3},
+		{"long random", "kX9#mQ2$vL7!", nil, 4},
+		{"passphrase", "correct horse battery staple", nil, 4},
+		{"personal words without them", "adalovelace1815", nil, 4},
+		{"personal words", "adalovelace1815", personal, 1},
+		{"personal word", "ada", personal, 0},
+		{"only the scored length counts", strings.Repeat("kX9#mQ2$vL7!", 10), nil, 4},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := Score(test.password, test.personal...); got != test.want {
+				t.Errorf("Score(%q) = %d, want %d", test.password, got, test.want)
+			}
+		})
+	}
+}
+
+func TestPersonalTokens(t *testing.T) {
+	tests := []struct {
+		value string
+		want  []string
+	}{
+		{"Ada Lovelace", []string{"ada lovelace", "ada", "lovelace"}},
+		{"ada.lovelace@example.com", []string{"ada.lovelace", "ada", "lovelace"}},
+		{"  Al  ", []string{}},
+		{"Jo Li", []string{"jo li"}},
+		{"grace", []string{"grace"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.value, func(t *testing.T) {
+			if got := PersonalTokens(test.value); strings.Join(got, "|") != strings.Join(test.want, "|") {
+				t.Errorf("PersonalTokens(%q) = %q, want %q", test.value, got, test.want)
+			}
+		})
+	}
+}
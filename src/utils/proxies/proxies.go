@@ -0,0 +1,116 @@
+package proxies
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"os"
+	"strings"
+)
+
+// FromEnv parses TRUSTED_PROXIES, a comma separated list of the IPs
+// or CIDR ranges of the reverse proxies in front of the API, e.g.
+// "10.0.0.0/8,192.0.2.10". It returns no proxies when it is unset.
+func FromEnv() ([]netip.Prefix, error) {
+	var proxies []netip.Prefix
+
+	for _, value := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+
+		if !strings.Contains(value, "/") {
+			addr, err := netip.ParseAddr(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid TRUSTED_PROXIES entry %q: %w", value, err)
+			}
+			proxies = append(proxies, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+
+		prefix, err := netip.ParsePrefix(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid TRUSTED_PROXIES entry %q: %w", value, err)
+		}
+		proxies = append(proxies, prefix.Masked())
+	}
+
+	return proxies, nil
+}
+
+// RealIP sets the remote address of requests relayed by one of the trusted
+// proxies to the client address the proxies forwarded. X-Forwarded-For is read
+// from the right, skipping the trusted proxies, so that addresses a client
+// prepended itself are never used; X-Real-IP is used when there is none. The
+// headers of requests from anywhere else are ignored, since anyone can set them.
+func RealIP(trusted []netip.Prefix) func(http.Handler) http.Handler {
+	isTrusted := func(addr netip.Addr) bool {
+		for _, prefix := range trusted {
+			if prefix.Contains(addr) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			remote, ok := parseIP(r.RemoteAddr)
+
+			if ok && isTrusted(remote) {
+				if client, ok := forwardedFor(r, isTrusted); ok {
+					r.RemoteAddr = client.String()
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// forwardedFor returns the client address forwarded to a trusted proxy.
+func forwardedFor(r *http.Request, isTrusted func(netip.Addr) bool) (netip.Addr, bool) {
+	var hops []string
+	for _, header := range r.Header.Values("X-Forwarded-For") {
+		hops = append(hops, strings.Split(header, ",")...)
+	}
+
+	if len(hops) == 0 {
+		return parseIP(r.Header.Get("X-Real-IP"))
+	}
+
+	var client netip.Addr
+
+	for i := len(hops) - 1; i >= 0; i-- {
+		addr, ok := parseIP(hops[i])
+		if !ok {
+			// Whatever comes before a malformed hop cannot be trusted either
+			break
+		}
+
+		client = addr
+		if !isTrusted(addr) {
+			break
+		}
+	}
+
+	return client, client.IsValid()
+}
+
+func parseIP(value string) (netip.Addr, bool) {
+	value = strings.TrimSpace(value)
+
+	if host, _, err := net.SplitHostPort(value); err == nil {
+		value = host
+	}
+	value = strings.TrimSuffix(strings.TrimPrefix(value, "["), "]")
+
+	addr, err := netip.ParseAddr(value)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+
+	return addr.Unmap(), true
+}
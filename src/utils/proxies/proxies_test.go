@@ -0,0 +1,93 @@
+package proxies
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRealIP(t *testing.T) {
+	t.Setenv("TRUSTED_PROXIES", "10.0.0.0/8, 192.0.2.10")
+
+	trusted, err := FromEnv()
+	if err != nil {
+		t.Fatalf("FromEnv: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		remote    string
+		forwarded []string
+		realIP    string
+		want      string
+	}{
+		{"direct client", "203.0.113.5:4000", nil, "", "203.0.113.5:4000"},
+		{"spoofed by a direct client", "203.0.113.5:4000", []string{"198.51.100.1"}, "198.51.100.2", "203.0.113.5:4000"},
+		{"through a proxy", "10.1.2.3:4000", []string{"198.51.100.1"}, "", "198.51.100.1"},
+		{"through a single proxy IP", "192.0.2.10:4000", []string{"198.51.100.1"}, "", "198.51.100.1"},
+		{"through a chain of proxies", "10.1.2.3:4000", []string{"198.51.100.1, 10.9.9.9"}, "", "198.51.100.1"},
+		{"spoofed hop before the client", "10.1.2.3:4000", []string{"1.2.3.4, 198.51.100.1"}, "", "198.51.100.1"},
+		{"several headers", "10.1.2.3:4000", []string{"1.2.3.4", "198.51.100.1"}, "", "198.51.100.1"},
+		{"only proxies", "10.1.2.3:4000", []string{"10.0.0.1, 10.0.0.2"}, "", "10.0.0.1"},
+		{"malformed last hop", "10.1.2.3:4000", []string{"198.51.100.1, nonsense"}, "", "10.1.2.3:4000"},
+		{"malformed hop before the client", "10.1.2.3:4000", []string{"nonsense, 198.51.100.1"}, "", "198.51.100.1"},
+		{"real ip header", "10.1.2.3:4000", nil, "198.51.100.2", "198.51.100.2"},
+		{"forwarded for wins over real ip", "10.1.2.3:4000", []string{"198.51.100.1"}, "198.51.100.2", "198.51.100.1"},
+		{"ipv6 client", "10.1.2.3:4000", []string{"2001:db8::1"}, "", "2001:db8::1"},
+		{"ipv4 mapped proxy", "[::ffff:10.1.2.3]:4000", []string{"198.51.100.1"}, "", "198.51.100.1"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var got string
+
+			handler := RealIP(trusted)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = r.RemoteAddr
+			}))
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.RemoteAddr = test.remote
+			for _, value := range test.forwarded {
+				r.Header.Add("X-Forwarded-For", value)
+			}
+			if test.realIP != "" {
+				r.Header.Set("X-Real-IP", test.realIP)
+			}
+
+			handler.ServeHTTP(httptest.NewRecorder(), r)
+
+			if got != test.want {
+				t.Errorf("remote address = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestFromEnv(t *testing.T) {
+	tests := []struct {
+		value   string
+		count   int
+		wantErr bool
+	}{
+		{"", 0, false},
+		{"10.0.0.0/8", 1, false},
+		{"10.0.0.1, 2001:db8::/32,", 2, false},
+		{"10.0.0.1/33", 0, true},
+		{"proxy.internal", 0, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.value, func(t *testing.T) {
+			t.Setenv("TRUSTED_PROXIES", test.value)
+
+			proxies, err := FromEnv()
+
+			if (err != nil) != test.wantErr {
+				t.Fatalf("err = %v, want error %v", err, test.wantErr)
+			}
+			if len(proxies) != test.count {
+				t.Errorf("got %d proxies, want %d", len(proxies), test.count)
+			}
+		})
+	}
+}
@@ -0,0 +1,181 @@
+package render
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+var ErrInvalidCursor = errors.New("invalid or malformed cursor")
+
+// Cursor is the decoded form of the opaque cursor token used by keyset
+// pagination. It holds the sort-key tuple of the last item of a page, in the
+// same order as the order fields the page was sorted by, and the order string
+// itself so that a cursor cannot be replayed against a different sort. Query is
+// the QueryDigest of the search filter, which binds the cursor to that search.
+type Cursor struct {
+	Order  string        `json:"o"`
+	Query  string        `json:"q,omitempty"`
+	Values []interface{} `json:"v"`
+}
+
+// CursorValueType is the type a cursor value must have for the column it was
+// read from.
+type CursorValueType int
+
+const (
+	CursorInteger CursorValueType = iota
+	CursorString
+	CursorTime
+)
+
+type CursorMeta struct {
+	Size       int    `json:"size"`
+	Count      int    `json:"count"`
+	Cursor     string `json:"cursor"`
+	NextCursor string `json:"next_cursor"`
+	Query      string `json:"query"`
+	Order      string `json:"order"`
+}
+
+type CursorPaginatedResults struct {
+	Meta    *CursorMeta `json:"meta"`
+	Results interface{} `json:"results"`
+}
+
+// EncodeCursor serializes a cursor into an opaque, URL safe token.
+//
+// Example:
+//
+//	token, err := EncodeCursor(&Cursor{Order: "created_at:desc", Values: []interface{}{createdAt, 42}})
+func EncodeCursor(cursor *Cursor) (string, error) {
+	data, err := json.Marshal(cursor)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// DecodeCursor parses a token created by EncodeCursor. Numbers are kept as
+// json.Number so that large IDs do not lose precision. It returns
+// ErrInvalidCursor if the token cannot be decoded.
+func DecodeCursor(token string) (*Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var cursor Cursor
+	if err := decoder.Decode(&cursor); err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	return &cursor, nil
+}
+
+// ParseValues checks the cursor values against the expected types, one per value,
+// and returns them converted to what the database driver expects: integers as
+// int64, strings as is and timestamps as time.Time. It returns ErrInvalidCursor
+// if the counts differ or a value has the wrong type, so that a tampered cursor
+// never reaches the SQL layer.
+func (cursor *Cursor) ParseValues(types []CursorValueType) ([]interface{}, error) {
+	if len(cursor.Values) != len(types) {
+		return nil, ErrInvalidCursor
+	}
+
+	values := make([]interface{}, len(types))
+
+	for i, valueType := range types {
+		switch valueType {
+		case CursorInteger:
+			number, ok := cursor.Values[i].(json.Number)
+			if !ok {
+				return nil, ErrInvalidCursor
+			}
+			integer, err := number.Int64()
+			if err != nil {
+				return nil, ErrInvalidCursor
+			}
+			values[i] = integer
+		case CursorString:
+			text, ok := cursor.Values[i].(string)
+			if !ok {
+				return nil, ErrInvalidCursor
+			}
+			values[i] = text
+		case CursorTime:
+			text, ok := cursor.Values[i].(string)
+			if !ok {
+				return nil, ErrInvalidCursor
+			}
+			timestamp, err := time.Parse(time.RFC3339Nano, text)
+			if err != nil {
+				return nil, ErrInvalidCursor
+			}
+			values[i] = timestamp
+		default:
+			return nil, ErrInvalidCursor
+		}
+	}
+
+	return values, nil
+}
+
+// QueryDigest returns the digest of a search filter stored in Cursor.Query. An
+// empty filter has an empty digest.
+func QueryDigest(query string) string {
+	if query == "" {
+		return ""
+	}
+
+	sum := sha256.Sum256([]byte(query))
+	return hex.EncodeToString(sum[:16])
+}
+
+// GenerateCursorMeta generates metadata for a page fetched in cursor mode. Unlike
+// GenerateMeta it has no totals, since counting defeats the point of keyset paging.
+func GenerateCursorMeta(queryParams *QueryParams, count int, nextCursor string) *CursorMeta {
+	return &CursorMeta{
+		Size:       queryParams.Limit,
+		Count:      count,
+		Cursor:     queryParams.Cursor,
+		NextCursor: nextCursor,
+		Query:      queryParams.Query,
+		Order:      queryParams.Order,
+	}
+}
+
+// SetCursorLinks sets an RFC 8288 Link header pointing at the first page and,
+// when there is one, the next page of a cursor paginated listing. All other
+// query parameters of the current request are preserved.
+//
+// Example:
+//
+//	Link: </api/users?cursor=&limit=15>; rel="first", </api/users?cursor=eyJvIjoi...&limit=15>; rel="next"
+func SetCursorLinks(w http.ResponseWriter, r *http.Request, nextCursor string) {
+	links := []string{cursorLink(r, "", "first")}
+
+	if nextCursor != "" {
+		links = append(links, cursorLink(r, nextCursor, "next"))
+	}
+
+	w.Header().Set("Link", strings.Join(links, ", "))
+}
+
+func cursorLink(r *http.Request, cursor string, rel string) string {
+	values := r.URL.Query()
+	values.Set("cursor", cursor)
+
+	return fmt.Sprintf(`<%s?%s>; rel="%s"`, r.URL.Path, values.Encode(), rel)
+}
@@ -0,0 +1,116 @@
+package render
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestDecodeCursor(t *testing.T) {
+	createdAt := time.Date(2026, 10, 17, 12, 30, 0, 123456789, time.UTC)
+
+	token, err := EncodeCursor(&Cursor{
+		Order:  "created_at:desc",
+		Query:  QueryDigest("ada"),
+		Values: []interface{}{createdAt, 9007199254740993},
+	})
+	if err != nil {
+		t.Fatalf("EncodeCursor: %v", err)
+	}
+
+	cursor, err := DecodeCursor(token)
+	if err != nil {
+		t.Fatalf("DecodeCursor: %v", err)
+	}
+
+	if cursor.Order != "created_at:desc" || cursor.Query != QueryDigest("ada") {
+		t.Fatalf("cursor = %+v", cursor)
+	}
+
+	values, err := cursor.ParseValues([]CursorValueType{CursorTime, CursorInteger})
+	if err != nil {
+		t.Fatalf("ParseValues: %v", err)
+	}
+
+	if !values[0].(time.Time).Equal(createdAt) {
+		t.Errorf("values[0] = %v, want %v", values[0], createdAt)
+	}
+
+	// Larger than 2^53, which would lose precision as a float64
+	if values[1] != int64(9007199254740993) {
+		t.Errorf("values[1] = %v, want 9007199254740993", values[1])
+	}
+}
+
+func TestDecodeCursorMalformed(t *testing.T) {
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"not base64", "%%%"},
+		{"not json", base64.RawURLEncoding.EncodeToString([]byte("nope"))},
+		{"wrong shape", base64.RawURLEncoding.EncodeToString([]byte(`{"v":"id"}`))},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := DecodeCursor(test.token); !errors.Is(err, ErrInvalidCursor) {
+				t.Errorf("err = %v, want ErrInvalidCursor", err)
+			}
+		})
+	}
+}
+
+func TestCursorParseValues(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+		types   []CursorValueType
+		wantErr bool
+	}{
+		{"integer", `{"v":[42]}`, []CursorValueType{CursorInteger}, false},
+		{"string", `{"v":["ada@example.com"]}`, []CursorValueType{CursorString}, false},
+		{"time", `{"v":["2026-10-17T12:30:00Z"]}`, []CursorValueType{CursorTime}, false},
+		{"tuple", `{"v":["Lovelace",7]}`, []CursorValueType{CursorString, CursorInteger}, false},
+		{"object", `{"v":[{"a":1}]}`, []CursorValueType{CursorInteger}, true},
+		{"array", `{"v":[[1,2]]}`, []CursorValueType{CursorString}, true},
+		{"null", `{"v":[null]}`, []CursorValueType{CursorString}, true},
+		{"fractional id", `{"v":[4.5]}`, []CursorValueType{CursorInteger}, true},
+		{"string id", `{"v":["42"]}`, []CursorValueType{CursorInteger}, true},
+		{"number for string", `{"v":[42]}`, []CursorValueType{CursorString}, true},
+		{"bad time", `{"v":["yesterday"]}`, []CursorValueType{CursorTime}, true},
+		{"too few values", `{"v":[1]}`, []CursorValueType{CursorString, CursorInteger}, true},
+		{"too many values", `{"v":[1,2]}`, []CursorValueType{CursorInteger}, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cursor, err := DecodeCursor(base64.RawURLEncoding.EncodeToString([]byte(test.payload)))
+			if err != nil {
+				t.Fatalf("DecodeCursor: %v", err)
+			}
+
+			_, err = cursor.ParseValues(test.types)
+
+			if test.wantErr && !errors.Is(err, ErrInvalidCursor) {
+				t.Errorf("err = %v, want ErrInvalidCursor", err)
+			}
+			if !test.wantErr && err != nil {
+				t.Errorf("err = %v, want nil", err)
+			}
+		})
+	}
+}
+
+func TestQueryDigest(t *testing.T) {
+	if QueryDigest("") != "" {
+		t.Errorf("QueryDigest(\"\") should be empty")
+	}
+	if QueryDigest("ada") != QueryDigest("ada") {
+		t.Errorf("QueryDigest is not deterministic")
+	}
+	if QueryDigest("ada") == QueryDigest("grace") {
+		t.Errorf("different queries share a digest")
+	}
+}
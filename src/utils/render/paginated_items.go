@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"math"
 	"net/url"
+	"slices"
 	"strconv"
 	"strings"
 )
@@ -22,10 +23,12 @@ type Meta struct {
 
 // Basic query params for pagination and searching
 type QueryParams struct {
-	Page  int    `json:"page,omitempty"`  // Offset for pagination (default: 0)
-	Limit int    `json:"limit,omitempty"` // Number of items per page (default: 10)
-	Query string `json:"query,omitempty"` // Query string for filtering
-	Order string `json:"order,omitempty"` // Query string for filtering
+	Page      int    `json:"page,omitempty"`   // Offset for pagination (default: 0)
+	Limit     int    `json:"limit,omitempty"`  // Number of items per page (default: 10)
+	Query     string `json:"query,omitempty"`  // Query string for filtering
+	Order     string `json:"order,omitempty"`  // Query string for filtering
+	Cursor    string `json:"cursor,omitempty"` // Opaque keyset cursor, empty for the first page
+	UseCursor bool   `json:"-"`                // Whether the cursor parameter was given at all
 }
 
 type PaginatedResults struct {
@@ -80,12 +83,40 @@ func ParseOrderString(orderString string) ([]*OrderFields, error) {
 	return orders, nil
 }
 
+// ValidateOrderFields checks every parsed order field against an allowlist of
+// sortable columns and returns one validation error detail per unknown field.
+// Sorting must never pass user input to the database as a column name unchecked.
+//
+// Example:
+//
+//	orders, _ := ParseOrderString("name:asc,password:desc")
+//	details := ValidateOrderFields(orders, []string{"name", "created_at"})
+//	// details will contain an error for "password"
+func ValidateOrderFields(orders []*OrderFields, allowed []string) []ValidationErrorDetails {
+	var details []ValidationErrorDetails
+
+	for _, order := range orders {
+		if !slices.Contains(allowed, order.Field) {
+			details = append(details, ValidationErrorDetails{
+				Field:   "order",
+				Message: fmt.Sprintf("cannot order by %s, allowed fields are [%s]", order.Field, strings.Join(allowed, " ")),
+			})
+		}
+	}
+
+	return details
+}
+
 // ParseQueryFilterParams parses a raw query string into a struct of query parameters.
 // It extracts values for fields like "page", "limit", "query", and "order" from the
 // query string and sets default values when not specified.
 //
 // The raw query string should be in a format like "?page=1&limit=10&query=search&order=name:asc".
 //
+// Passing a "cursor" parameter, even an empty one, switches the listing to cursor
+// (keyset) mode, where "page" is ignored and the next page is requested with the
+// "next_cursor" of the previous one.
+//
 // Example:
 //
 //	rawQuery := "?page=2&limit=20&query=keyword&order=name:desc"
@@ -119,6 +150,8 @@ func ParseQueryFilterParams(rawQuery string) (*QueryParams, error) {
 	params.Limit = getIntValue(values, "limit", 15)
 	params.Query = getStringValue(values, "query", "")
 	params.Order = getStringValue(values, "order", "created_at:desc")
+	params.Cursor = getStringValue(values, "cursor", "")
+	params.UseCursor = values.Has("cursor")
 
 	return params, nil
 }
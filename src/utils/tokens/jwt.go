@@ -0,0 +1,103 @@
+package tokens
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// AccessClaims are the claims carried by every access token issued by the API.
+// The subject holds the user ID and the token ID (jti) uniquely identifies the token.
+type AccessClaims struct {
+	jwt.RegisteredClaims
+
+	// IssuedAtMillis is the issuance time in Unix milliseconds. The standard iat
+	// claim only has whole seconds, too coarse to tell a login apart from a
+	// logout in the same second.
+	IssuedAtMillis int64 `json:"iat_ms"`
+}
+
+// UserID returns the user ID stored in the subject claim.
+func (claims *AccessClaims) UserID() (int, error) {
+	return strconv.Atoi(claims.Subject)
+}
+
+// GenerateAccessToken signs a new HS256 access token for the given user that
+// expires after the provided duration.
+//
+// Example:
+//
+//	token, err := GenerateAccessToken(42, os.Getenv("JWT_SECRET"), 15*time.Minute)
+func GenerateAccessToken(userID int, secret string, expiration time.Duration) (string, error) {
+	id, err := RandomString(16)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	claims := AccessClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        id,
+			Subject:   strconv.Itoa(userID),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(expiration)),
+		},
+		IssuedAtMillis: now.UnixMilli(),
+	}
+
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+}
+
+// ParseAccessToken verifies the signature and the time based claims of an access
+// token and returns its claims. Tokens signed with any other algorithm than HS256
+// are rejected.
+func ParseAccessToken(tokenString string, secret string) (*AccessClaims, error) {
+	claims := &AccessClaims{}
+
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		return []byte(secret), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithExpirationRequired())
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !token.Valid {
+		return nil, errors.New("invalid token")
+	}
+
+	return claims, nil
+}
+
+// derivedKey derives the key tokens issued for purpose are signed with from the
+// JWT secret, so that tokens of one purpose can never pass as tokens of another,
+// e.g. as access tokens.
+func derivedKey(secret string, purpose string) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(purpose))
+	return mac.Sum(nil)
+}
+
+// RandomString returns a hex encoded string built from n cryptographically secure random bytes.
+func RandomString(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(buf), nil
+}
+
+// Hash returns the hex encoded SHA-256 digest of an opaque token. Only hashes of
+// refresh tokens are persisted so that a leaked store cannot be replayed.
+func Hash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,65 @@
+package tokens
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// MFAClaims are the claims carried by "mfa pending" tokens, which prove that the
+// password of a user with two-factor authentication was checked. The token ID
+// (jti) makes sure each of them completes at most one login.
+type MFAClaims struct {
+	jwt.RegisteredClaims
+}
+
+// UserID returns the user ID stored in the subject claim.
+func (claims *MFAClaims) UserID() (int, error) {
+	return strconv.Atoi(claims.Subject)
+}
+
+// GenerateMFAToken signs an "mfa pending" token for the given user.
+//
+// Example:
+//
+//	token, err := GenerateMFAToken(42, os.Getenv("JWT_SECRET"), 5*time.Minute)
+func GenerateMFAToken(userID int, secret string, expiration time.Duration) (string, error) {
+	id, err := RandomString(16)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	claims := MFAClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        id,
+			Subject:   strconv.Itoa(userID),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(expiration)),
+		},
+	}
+
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(derivedKey(secret, "mfa-pending"))
+}
+
+// ParseMFAToken verifies the signature and expiry of an "mfa pending" token and
+// returns its claims.
+func ParseMFAToken(tokenString string, secret string) (*MFAClaims, error) {
+	claims := &MFAClaims{}
+
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		return derivedKey(secret, "mfa-pending"), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithExpirationRequired())
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !token.Valid {
+		return nil, errors.New("invalid token")
+	}
+
+	return claims, nil
+}
@@ -0,0 +1,115 @@
+package workers
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/ryuudan/golang-rest-api/ent/generated"
+	"github.com/ryuudan/golang-rest-api/src/constants"
+	"github.com/ryuudan/golang-rest-api/src/internal/repositories"
+	"github.com/ryuudan/golang-rest-api/src/utils/mailer"
+)
+
+// OutboxWorker delivers the mail queued in the outbox. Any number of instances
+// may run it at once, every message is claimed by only one of them at a time.
+type OutboxWorker struct {
+	tx     repositories.Transactor
+	outbox repositories.OutboxRepository
+	mailer mailer.Mailer
+}
+
+func NewOutboxWorker(client *generated.Client, mailer mailer.Mailer) *OutboxWorker {
+	return &OutboxWorker{
+		tx:     repositories.NewTransactor(client),
+		outbox: repositories.NewOutboxRepository(client.OutboxMessage),
+		mailer: mailer,
+	}
+}
+
+// Run delivers due messages every OUTBOX_POLL_INTERVAL until ctx is cancelled.
+//
+// Example:
+//
+//	go workers.NewOutboxWorker(client, mail).Run(ctx)
+func (worker *OutboxWorker) Run(ctx context.Context) {
+	ticker := time.NewTicker(constants.OUTBOX_POLL_INTERVAL)
+	defer ticker.Stop()
+
+	for {
+		// Keep going while full batches come back, there may be more waiting
+		for {
+			delivered, err := worker.deliverBatch(ctx)
+			if err != nil {
+				log.Printf("❌ Failed to deliver outbox messages: %v", err)
+				break
+			}
+			if delivered < constants.OUTBOX_BATCH_SIZE {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// deliverBatch sends one batch of due messages and returns how many it claimed.
+// Failed messages are retried later with an exponential backoff, until they run
+// out of attempts and stay in the outbox for inspection.
+//
+// Messages are claimed in a short transaction and sent after it committed, each
+// marked as sent or failed on its own, so that no transaction stays open while
+// talking to the mail server and a failure never takes back what was sent
+// already. A message sent but not marked, because the worker stopped in between,
+// is sent again once its lease runs out.
+func (worker *OutboxWorker) deliverBatch(ctx context.Context) (int, error) {
+	leaseUntil := time.Now().Add(constants.OUTBOX_LEASE)
+
+	var messages []*generated.OutboxMessage
+
+	err := worker.tx.WithTx(ctx, func(ctx context.Context) error {
+		var err error
+		messages, err = worker.outbox.ClaimDue(ctx, constants.OUTBOX_BATCH_SIZE, constants.OUTBOX_MAX_ATTEMPTS, leaseUntil)
+		return err
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	// Sending stops when the lease runs out, the rest are claimed again later
+	sendCtx, cancel := context.WithDeadline(ctx, leaseUntil)
+	defer cancel()
+
+	for _, message := range messages {
+		if sendCtx.Err() != nil {
+			break
+		}
+
+		err := worker.mailer.Send(sendCtx, &mailer.Message{
+			To:      message.Recipient,
+			Subject: message.Subject,
+			Body:    message.Body,
+		})
+
+		if err == nil {
+			if err := worker.outbox.MarkSent(ctx, message.ID); err != nil {
+				return len(messages), err
+			}
+			continue
+		}
+
+		log.Printf("❌ Failed to deliver outbox message %d to %s: %v", message.ID, message.Recipient, err)
+
+		retryAt := time.Now().Add(constants.OUTBOX_RETRY_BACKOFF << message.Attempts)
+		if err := worker.outbox.MarkFailed(ctx, message.ID, err, retryAt); err != nil {
+			return len(messages), err
+		}
+	}
+
+	return len(messages), nil
+}